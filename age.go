@@ -0,0 +1,60 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import "time"
+
+// Field key used to record the level an error had before being reclassified.
+const fieldReclassifiedFrom = "reclassified.from"
+
+// Time returns the time err was created at, according to the package clock,
+// or the zero time if it is unknown.
+func Time(err error) time.Time {
+	if em := messageOf(err); em != nil {
+		return em.time
+	}
+	return time.Time{}
+}
+
+// AgeRule describes the reclassification of stale errors: errors of the given
+// Level older than Age are given the level To.
+type AgeRule struct {
+	Level Level
+	Age   time.Duration
+	To    Level
+}
+
+// ReclassifyByAge applies to err the first of rules matching its level and
+// its age at the time at, e.g. to turn a transient WARNING which has been
+// stuck in a retry queue for an hour into an ERROR. The level err had before
+// is recorded. Errors of unknown age, and informational warnings (see
+// Warning), are left unchanged.
+func ReclassifyByAge(err Error, at time.Time, rules ...AgeRule) Error {
+	em := ownMessage(err)
+	if em == nil || em.time.IsZero() || em.informational() {
+		return err
+	}
+	age := at.Sub(em.time)
+	for _, r := range rules {
+		if r.Level == Level(em.level) && age > r.Age {
+			if r.To != Level(em.level) && r.To.Valid() {
+				em.setField(fieldReclassifiedFrom, levelName(em.level))
+				em.level = int8(r.To)
+			}
+			break
+		}
+	}
+	return err
+}
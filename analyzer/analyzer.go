@@ -0,0 +1,166 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package analyzer provides static checks helping to adopt package
+// github.com/agext/errors consistently.
+//
+// It is built on golang.org/x/tools/go/analysis, so its analyzers can be run
+// by any driver supporting that framework, e.g. singlechecker.
+package analyzer
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// errorsPath is the import path of the package whose adoption is checked.
+const errorsPath = "github.com/agext/errors"
+
+// Boundary reports, in packages importing github.com/agext/errors:
+//   - exported functions returning errors created with the standard errors.New
+//     or fmt.Errorf, instead of Errors carrying a level and code;
+//   - Errors passed to the standard log functions, which ignore their level:
+//     their Log method should be used instead.
+var Boundary = &analysis.Analyzer{
+	Name: "errboundary",
+	Doc:  "check that exported functions return Errors and that logging honors their level",
+	Run:  runBoundary,
+}
+
+func runBoundary(pass *analysis.Pass) (interface{}, error) {
+	iface := errorInterface(pass.Pkg)
+	if iface == nil {
+		return nil, nil
+	}
+	for _, file := range pass.Files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.FuncDecl:
+				if n.Body != nil && n.Name.IsExported() {
+					checkReturns(pass, n)
+				}
+			case *ast.CallExpr:
+				checkLogCall(pass, n, iface)
+			}
+			return true
+		})
+	}
+	return nil, nil
+}
+
+// errorInterface returns the Error interface of github.com/agext/errors if
+// pkg imports it, or nil otherwise.
+func errorInterface(pkg *types.Package) *types.Interface {
+	for _, imp := range pkg.Imports() {
+		if imp.Path() == errorsPath {
+			if obj, ok := imp.Scope().Lookup("Error").(*types.TypeName); ok {
+				iface, _ := obj.Type().Underlying().(*types.Interface)
+				return iface
+			}
+		}
+	}
+	return nil
+}
+
+// callee returns the package path and name of the function called by call,
+// or empty strings if it is not a statically known function. Methods are named
+// after their receiver type, as in "Logger.Print".
+func callee(info *types.Info, call *ast.CallExpr) (path, name string) {
+	var id *ast.Ident
+	switch fun := ast.Unparen(call.Fun).(type) {
+	case *ast.Ident:
+		id = fun
+	case *ast.SelectorExpr:
+		id = fun.Sel
+	default:
+		return "", ""
+	}
+	fn, ok := info.Uses[id].(*types.Func)
+	if !ok || fn.Pkg() == nil {
+		return "", ""
+	}
+	if recv := fn.Type().(*types.Signature).Recv(); recv != nil {
+		t := recv.Type()
+		if ptr, ok := t.(*types.Pointer); ok {
+			t = ptr.Elem()
+		}
+		if named, ok := t.(*types.Named); ok {
+			return fn.Pkg().Path(), named.Obj().Name() + "." + fn.Name()
+		}
+		return "", ""
+	}
+	return fn.Pkg().Path(), fn.Name()
+}
+
+// checkReturns reports return statements of fd yielding raw standard errors
+// in its results of type error.
+func checkReturns(pass *analysis.Pass, fd *ast.FuncDecl) {
+	sig, ok := pass.TypesInfo.Defs[fd.Name].Type().(*types.Signature)
+	if !ok {
+		return
+	}
+	var errIdx []int
+	for i := 0; i < sig.Results().Len(); i++ {
+		if types.Identical(sig.Results().At(i).Type(), types.Universe.Lookup("error").Type()) {
+			errIdx = append(errIdx, i)
+		}
+	}
+	if len(errIdx) == 0 {
+		return
+	}
+	ast.Inspect(fd.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			// returns in closures do not belong to fd
+			return false
+		case *ast.ReturnStmt:
+			if len(n.Results) != sig.Results().Len() {
+				return true
+			}
+			for _, i := range errIdx {
+				call, ok := ast.Unparen(n.Results[i]).(*ast.CallExpr)
+				if !ok {
+					continue
+				}
+				switch path, name := callee(pass.TypesInfo, call); {
+				case path == "errors" && name == "New", path == "fmt" && name == "Errorf":
+					pass.Reportf(call.Pos(), "exported function %s returns a raw error from %s.%s; use %s instead", fd.Name.Name, path, name, errorsPath)
+				}
+			}
+		}
+		return true
+	})
+}
+
+// logFuncs lists the functions of the standard log package which ignore the
+// level of Errors passed to them.
+var logFuncs = map[string]bool{
+	"Print": true, "Printf": true, "Println": true,
+	"Logger.Print": true, "Logger.Printf": true, "Logger.Println": true,
+}
+
+// checkLogCall reports Errors passed to the standard log functions.
+func checkLogCall(pass *analysis.Pass, call *ast.CallExpr, iface *types.Interface) {
+	path, name := callee(pass.TypesInfo, call)
+	if path != "log" || !logFuncs[name] {
+		return
+	}
+	for _, arg := range call.Args {
+		if t := pass.TypesInfo.TypeOf(arg); t != nil && types.Implements(t, iface) {
+			pass.Reportf(arg.Pos(), "the level of this Error is ignored by log.%s; use its Log method instead", name)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analyzer
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestBoundary(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), Boundary, "a", "b")
+}
+
+func TestCatalogOnly(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), CatalogOnly, "e")
+}
+
+func TestCodes(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), Codes, "c", "d", "f")
+}
@@ -0,0 +1,83 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analyzer
+
+import (
+	"go/ast"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// generatedMarker starts the files generated by errgen.
+const generatedMarker = "// Code generated by errgen. DO NOT EDIT."
+
+// CatalogOnly reports, in packages whose errors are generated by errgen from
+// a catalog, errors created by other means in their other files: with New,
+// NewCtx, Define or Tree from github.com/agext/errors, with the standard
+// errors.New or with fmt.Errorf.
+var CatalogOnly = &analysis.Analyzer{
+	Name: "errcatalog",
+	Doc:  "check that errors of packages with a generated catalog come from it",
+	Run:  runCatalogOnly,
+}
+
+// constructors lists the functions creating errors, by package path.
+var constructors = map[string]map[string]bool{
+	errorsPath: {"New": true, "NewCtx": true, "Define": true, "Tree": true},
+	"errors":   {"New": true},
+	"fmt":      {"Errorf": true},
+}
+
+func runCatalogOnly(pass *analysis.Pass) (interface{}, error) {
+	var files []*ast.File
+	generated := false
+	for _, file := range pass.Files {
+		if isGenerated(file) {
+			generated = true
+		} else {
+			files = append(files, file)
+		}
+	}
+	if !generated {
+		return nil, nil
+	}
+	for _, file := range files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			if call, ok := n.(*ast.CallExpr); ok {
+				if path, name := callee(pass.TypesInfo, call); constructors[path][name] {
+					pass.Reportf(call.Pos(), "error created outside the generated catalog of package %s; use its constructors", pass.Pkg.Name())
+				}
+			}
+			return true
+		})
+	}
+	return nil, nil
+}
+
+// isGenerated reports whether file has been generated by errgen.
+func isGenerated(file *ast.File) bool {
+	for _, c := range file.Comments {
+		if c.Pos() >= file.Package {
+			break
+		}
+		for _, l := range c.List {
+			if strings.TrimSpace(l.Text) == generatedMarker {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,199 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analyzer
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"hash/fnv"
+	"sort"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// Codes reports, in packages importing github.com/agext/errors:
+//   - codes registered more than once with different names, in the package
+//     or in its dependencies;
+//   - names registered more than once with different codes, likewise;
+//   - codes registered in the range reserved for the predefined codes of
+//     github.com/agext/errors, or in the code namespace of another package;
+//   - error codes given as bare numbers instead of named constants;
+//   - error codes set with SetCode or Desc{Code: ...} which are not registered
+//     with RegisterCode in the package or in its dependencies.
+var Codes = &analysis.Analyzer{
+	Name:      "errcodes",
+	Doc:       "check that error codes are named, registered and unique",
+	Run:       runCodes,
+	FactTypes: []analysis.Fact{new(codesFact)},
+}
+
+// codesFact records the codes registered by a package and its dependencies,
+// sorted by code (facts must encode deterministically, which maps do not).
+type codesFact struct {
+	Codes []registeredCode
+}
+
+type registeredCode struct {
+	Code int64
+	Name string
+}
+
+func (*codesFact) AFact() {}
+
+func (f *codesFact) String() string {
+	return "codes"
+}
+
+// Code ranges of github.com/agext/errors, see RESERVED_CODES_MIN and
+// NAMESPACE_FIRST there.
+const (
+	reservedCodesMin = 0x7fff0000
+	reservedCodesMax = 0x7fffffff
+	namespaceFirst   = 0x1000000
+	namespaceSize    = 0x100
+	namespaceCount   = 0x10000
+)
+
+// namespaceBase returns the first code of the namespace derived from the
+// package path pkg, as computed by Namespace.Base when not pinned.
+func namespaceBase(pkg string) int64 {
+	h := fnv.New32a()
+	h.Write([]byte(pkg))
+	return namespaceFirst + int64(h.Sum32()%namespaceCount)*namespaceSize
+}
+
+// checkRange reports the registration at pos of code, as name, by the
+// package of pass, if code belongs to a range the package may not use.
+func checkRange(pass *analysis.Pass, pos token.Pos, code int64, name string) {
+	switch {
+	case code >= reservedCodesMin && code <= reservedCodesMax:
+		pass.Reportf(pos, "code 0x%04x registered as %s is reserved for github.com/agext/errors", code, name)
+	case code >= namespaceFirst && code < namespaceFirst+namespaceCount*namespaceSize:
+		if base := namespaceBase(pass.Pkg.Path()); code < base || code >= base+namespaceSize {
+			pass.Reportf(pos, "code 0x%04x registered as %s is in the code namespace of another package (0x%04x-0x%04x is the one of %s)", code, name, base, base+namespaceSize-1, pass.Pkg.Path())
+		}
+	}
+}
+
+// codeUse is an error code found in the source.
+type codeUse struct {
+	expr ast.Expr
+	code int64
+}
+
+func runCodes(pass *analysis.Pass) (interface{}, error) {
+	if errorInterface(pass.Pkg) == nil {
+		return nil, nil
+	}
+	names := make(map[int64]string)
+	codes := make(map[string]int64)
+	for _, f := range pass.AllPackageFacts() {
+		for _, rc := range f.Fact.(*codesFact).Codes {
+			names[rc.Code] = rc.Name
+			codes[rc.Name] = rc.Code
+		}
+	}
+
+	var uses []codeUse
+	for _, file := range pass.Files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.CallExpr:
+				switch path, name := callee(pass.TypesInfo, n); {
+				case path == errorsPath && name == "RegisterCode" && len(n.Args) == 2:
+					code, ok1 := constInt(pass, n.Args[0])
+					name, ok2 := constString(pass, n.Args[1])
+					if !ok1 || !ok2 || name == "" {
+						break
+					}
+					if prev, ok := names[code]; ok && prev != name {
+						pass.Reportf(n.Pos(), "code 0x%04x registered as %s is already registered as %s", code, name, prev)
+						break
+					}
+					if prev, ok := codes[name]; ok && prev != code {
+						pass.Reportf(n.Pos(), "name %s registered for code 0x%04x is already registered for code 0x%04x", name, code, prev)
+						break
+					}
+					checkRange(pass, n.Pos(), code, name)
+					names[code], codes[name] = name, code
+				case path == errorsPath && name == "Error.SetCode" && len(n.Args) == 1:
+					if code, ok := constInt(pass, n.Args[0]); ok {
+						uses = append(uses, codeUse{n.Args[0], code})
+					}
+				}
+			case *ast.CompositeLit:
+				if !isDesc(pass.TypesInfo.TypeOf(n)) {
+					break
+				}
+				for _, elt := range n.Elts {
+					if kv, ok := elt.(*ast.KeyValueExpr); ok {
+						if key, ok := kv.Key.(*ast.Ident); ok && key.Name == "Code" {
+							if code, ok := constInt(pass, kv.Value); ok {
+								uses = append(uses, codeUse{kv.Value, code})
+							}
+						}
+					}
+				}
+			}
+			return true
+		})
+	}
+
+	for _, use := range uses {
+		if use.code == 0 {
+			continue
+		}
+		if _, ok := ast.Unparen(use.expr).(*ast.BasicLit); ok {
+			pass.Reportf(use.expr.Pos(), "magic number 0x%04x used as error code; use a named constant", use.code)
+		} else if _, ok := names[use.code]; !ok {
+			pass.Reportf(use.expr.Pos(), "error code 0x%04x is not registered", use.code)
+		}
+	}
+	if len(names) > 0 {
+		fact := &codesFact{}
+		for code, name := range names {
+			fact.Codes = append(fact.Codes, registeredCode{code, name})
+		}
+		sort.Slice(fact.Codes, func(i, j int) bool {
+			return fact.Codes[i].Code < fact.Codes[j].Code
+		})
+		pass.ExportPackageFact(fact)
+	}
+	return nil, nil
+}
+
+// isDesc reports whether t is the Desc type of github.com/agext/errors.
+func isDesc(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == errorsPath && named.Obj().Name() == "Desc"
+}
+
+// constInt returns the value of expr if it is an integer constant.
+func constInt(pass *analysis.Pass, expr ast.Expr) (int64, bool) {
+	if tv, ok := pass.TypesInfo.Types[expr]; ok && tv.Value != nil && tv.Value.Kind() == constant.Int {
+		return constant.Int64Val(tv.Value)
+	}
+	return 0, false
+}
+
+// constString returns the value of expr if it is a string constant.
+func constString(pass *analysis.Pass, expr ast.Expr) (string, bool) {
+	if tv, ok := pass.TypesInfo.Types[expr]; ok && tv.Value != nil && tv.Value.Kind() == constant.String {
+		return constant.StringVal(tv.Value), true
+	}
+	return "", false
+}
@@ -0,0 +1,38 @@
+package a
+
+import (
+	stderrors "errors"
+	"fmt"
+	"log"
+
+	"github.com/agext/errors"
+)
+
+func Exported() error {
+	return stderrors.New("abc") // want `exported function Exported returns a raw error from errors.New`
+}
+
+func ExportedPair(ok bool) (int, error) {
+	if !ok {
+		return 0, fmt.Errorf("abc %d", 17) // want `exported function ExportedPair returns a raw error from fmt.Errorf`
+	}
+	f := func() error {
+		return stderrors.New("closures are not checked")
+	}
+	return 1, f()
+}
+
+func ExportedGood() error {
+	return errors.New("abc")
+}
+
+func unexported() error {
+	return stderrors.New("abc")
+}
+
+func Logging(l *log.Logger) {
+	err := errors.New("abc")
+	log.Print(err)            // want `the level of this Error is ignored by log.Print`
+	l.Println("failed:", err) // want `the level of this Error is ignored by log.Logger.Println`
+	log.Print(unexported())
+}
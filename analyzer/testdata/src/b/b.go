@@ -0,0 +1,8 @@
+// Package b does not import github.com/agext/errors, so it is not checked.
+package b
+
+import "errors"
+
+func Exported() error {
+	return errors.New("abc")
+}
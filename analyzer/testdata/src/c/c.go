@@ -0,0 +1,17 @@
+package c // want package:"codes"
+
+import "github.com/agext/errors"
+
+const (
+	NotFound = 0x2104
+	Conflict = 0x2105
+)
+
+func init() {
+	errors.RegisterCode(NotFound, "NOT_FOUND")
+	errors.RegisterCode(Conflict, "CONFLICT")
+}
+
+func Lookup() error {
+	return errors.New(errors.Desc{Code: NotFound, Text: "not found"})
+}
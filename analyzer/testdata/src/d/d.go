@@ -0,0 +1,25 @@
+package d // want package:"codes"
+
+import (
+	"c"
+
+	"github.com/agext/errors"
+)
+
+const (
+	Gone    = 0x2106
+	Missing = 0x2104
+)
+
+func init() {
+	errors.RegisterCode(Missing, "MISSING") // want `code 0x2104 registered as MISSING is already registered as NOT_FOUND`
+	errors.RegisterCode(c.Conflict, "CONFLICT")
+}
+
+func Codes() {
+	errors.New(errors.Desc{Code: c.NotFound})
+	errors.New(errors.Desc{Code: 0x17}) // want `magic number 0x0017 used as error code; use a named constant`
+	errors.New("abc").SetCode(Gone)     // want `error code 0x2106 is not registered`
+	errors.New(&errors.Desc{Code: c.Conflict})
+	errors.New("abc").SetCode(0)
+}
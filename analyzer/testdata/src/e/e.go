@@ -0,0 +1,23 @@
+package e
+
+import (
+	stderrors "errors"
+	"fmt"
+
+	"github.com/agext/errors"
+)
+
+func Lookup(id string) error {
+	if id == "" {
+		return errors.New("empty id") // want `error created outside the generated catalog of package e`
+	}
+	if id == "x" {
+		return fmt.Errorf("bad id %q", id) // want `error created outside the generated catalog of package e`
+	}
+	if id == "y" {
+		return stderrors.New("bad id") // want `error created outside the generated catalog of package e`
+	}
+	return NewNotFound("id=" + id)
+}
+
+var errTemplate = errors.Define(errors.Desc{Text: "oops"}) // want `error created outside the generated catalog of package e`
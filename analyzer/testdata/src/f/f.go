@@ -0,0 +1,24 @@
+package f // want package:"codes"
+
+import (
+	"c"
+
+	"github.com/agext/errors"
+)
+
+const (
+	Own      = 0x1279901 // in the namespace derived from "f"
+	Borrowed = 0x1000042
+	Reserved = 0x7fff0042
+	Other    = 0x2107
+	Again    = 0x2108
+)
+
+func init() {
+	errors.RegisterCode(Own, "OWN")
+	errors.RegisterCode(Borrowed, "BORROWED") // want `code 0x1000042 registered as BORROWED is in the code namespace of another package \(0x1279900-0x12799ff is the one of f\)`
+	errors.RegisterCode(Reserved, "RESERVED") // want `code 0x7fff0042 registered as RESERVED is reserved for github.com/agext/errors`
+	errors.RegisterCode(Other, "NOT_FOUND")   // want `name NOT_FOUND registered for code 0x2107 is already registered for code 0x2104`
+	errors.RegisterCode(Again, "OWN")         // want `name OWN registered for code 0x2108 is already registered for code 0x1279901`
+	errors.RegisterCode(c.Conflict, "CONFLICT")
+}
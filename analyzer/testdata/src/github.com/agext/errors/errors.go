@@ -0,0 +1,37 @@
+package errors
+
+type Error interface {
+	error
+	Level() int8
+	SetCode(int) Error
+}
+
+type Desc struct {
+	Level      int8
+	Code       int
+	Text       string
+	PublicText string
+	Info       []string
+}
+
+type errorMessage string
+
+func (em errorMessage) Error() string       { return string(em) }
+func (em errorMessage) Level() int8         { return 3 }
+func (em errorMessage) SetCode(c int) Error { return em }
+
+func New(desc interface{}) Error { return errorMessage("") }
+
+func RegisterCode(code int, name string) {}
+
+func Define(desc Desc) *Sentinel { return nil }
+
+type Sentinel struct{}
+
+type Sealed struct{ e Error }
+
+func (s Sealed) Error() string       { return "" }
+func (s Sealed) Level() int8         { return 3 }
+func (s Sealed) SetCode(c int) Error { return s }
+
+func Seal(err Error) Sealed { return Sealed{err} }
@@ -0,0 +1,183 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// defaultMaxRecord is the default maximum size of a record read by a
+// Decoder.
+const defaultMaxRecord = 1 << 20
+
+// Decoder reads errors from an archive of serialized errors, one record at a
+// time, so that archives of any size are read in bounded memory. Records are
+// in the serialized form of this package, as sent to cloud events or spill
+// files, possibly compressed, either one per line (NDJSON), or each preceded
+// by its length as a 4-byte big-endian integer; the framing is detected from
+// the first byte.
+type Decoder struct {
+	src       io.Reader
+	r         *bufio.Reader
+	maxRecord int
+	framed    bool
+	detected  bool
+	n         int
+	err       error
+	strict    *DecodeLimits
+}
+
+// NewDecoder returns a Decoder reading from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{src: r, maxRecord: defaultMaxRecord}
+}
+
+// SetMaxRecord sets the maximum size of a record (1MiB by default); larger
+// records are skipped in NDJSON archives, and end the reading of
+// length-prefixed ones. It must be called before the first call to Decode.
+func (d *Decoder) SetMaxRecord(n int) {
+	if n > 0 && d.r == nil {
+		d.maxRecord = n
+	}
+}
+
+// SetStrict makes the Decoder validate records like FromJSONStrict, with
+// limits; invalid records then yield an Error with the code ERR_DECODE.
+func (d *Decoder) SetStrict(limits DecodeLimits) {
+	d.strict = &limits
+}
+
+// Decode returns the next error of the archive, with its fields converted
+// according to their registered schemas, see RegisterFieldSchema. It returns
+// io.EOF at the end of the archive. An invalid record yields an error naming
+// it; reading can go on after it, unless the framing itself is broken, in
+// which case that error is returned from then on.
+func (d *Decoder) Decode() (Error, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	if d.r == nil {
+		d.r = bufio.NewReaderSize(d.src, d.maxRecord+1)
+	}
+	if !d.detected {
+		if err := d.detect(); err != nil {
+			d.err = err
+			return nil, err
+		}
+	}
+	var data []byte
+	var err error
+	if d.framed {
+		data, err = d.readFramed()
+	} else {
+		data, err = d.readLine()
+	}
+	if err != nil {
+		return nil, err
+	}
+	d.n++
+	if d.strict != nil {
+		e, err := FromJSONStrict(data, *d.strict)
+		if err != nil {
+			return nil, Wrap(err, fmt.Sprintf("record #%d", d.n))
+		}
+		return e, nil
+	}
+	w, err := decodeWire(data)
+	if err != nil {
+		return nil, fmt.Errorf("record #%d: %v", d.n, err)
+	}
+	em := fromWire(w).(*errorMessage)
+	normalizeFields(em)
+	return em, nil
+}
+
+// detect determines the framing of the archive from its first byte.
+func (d *Decoder) detect() error {
+	for {
+		b, err := d.r.Peek(1)
+		if err != nil {
+			return err
+		}
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			d.r.ReadByte()
+			continue
+		}
+		d.framed, d.detected = b[0] != '{', true
+		return nil
+	}
+}
+
+// readLine returns the next non-blank line, skipping those longer than the
+// maximum record size.
+func (d *Decoder) readLine() ([]byte, error) {
+	for {
+		line, err := d.r.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			d.n++
+			for err == bufio.ErrBufferFull {
+				_, err = d.r.ReadSlice('\n')
+			}
+			if err != nil && err != io.EOF {
+				d.err = err
+				return nil, err
+			}
+			return nil, fmt.Errorf("record #%d: larger than %d bytes", d.n, d.maxRecord)
+		}
+		if err != nil && (err != io.EOF || len(line) == 0) {
+			if err != io.EOF {
+				d.err = err
+			}
+			return nil, err
+		}
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			return line, nil
+		}
+		if err == io.EOF {
+			return nil, err
+		}
+	}
+}
+
+// readFramed returns the next length-prefixed record.
+func (d *Decoder) readFramed() ([]byte, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(d.r, size[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			err = fmt.Errorf("record #%d: truncated length", d.n+1)
+		}
+		d.err = err
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if uint64(n) > uint64(d.maxRecord) {
+		d.err = fmt.Errorf("record #%d: length %d larger than %d bytes", d.n+1, n, d.maxRecord)
+		return nil, d.err
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(d.r, data); err != nil {
+		d.err = fmt.Errorf("record #%d: truncated", d.n+1)
+		return nil, d.err
+	}
+	return data, nil
+}
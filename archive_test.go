@@ -0,0 +1,88 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDecoder(t *testing.T) {
+	var ndjson, framed bytes.Buffer
+	for _, text := range []string{"first", "second"} {
+		data, err := encodeWire(New(Desc{Code: 0x42, Text: text}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ndjson.Write(append(data, '\n'))
+		var size [4]byte
+		binary.BigEndian.PutUint32(size[:], uint32(len(data)))
+		framed.Write(size[:])
+		framed.Write(data)
+	}
+	for name, archive := range map[string]*bytes.Buffer{"NDJSON": &ndjson, "framed": &framed} {
+		d := NewDecoder(archive)
+		var texts []string
+		for {
+			e, err := d.Decode()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf(`%s: Decode() failed: %v`, name, err)
+			}
+			if e.Code() != 0x42 {
+				t.Errorf(`%s: Decode().Code() = %#x`, name, e.Code())
+			}
+			texts = append(texts, e.Text())
+		}
+		if strings.Join(texts, ",") != "first,second" {
+			t.Errorf(`%s: decoded %q`, name, texts)
+		}
+	}
+
+	d := NewDecoder(strings.NewReader(`{"level":"ERROR","text":"` + strings.Repeat("a", 100) + `"}` + "\n" +
+		"{broken\n\n" + `{"level":"WARNING","text":"last"}`))
+	d.SetMaxRecord(64)
+	if _, err := d.Decode(); err == nil || !strings.Contains(err.Error(), "record #1: larger than 64 bytes") {
+		t.Errorf(`Decode(too large) = %v`, err)
+	}
+	if _, err := d.Decode(); err == nil || !strings.HasPrefix(err.Error(), "record #2: ") {
+		t.Errorf(`Decode(broken) = %v`, err)
+	}
+	if e, err := d.Decode(); err != nil || e.Text() != "last" || e.Level() != WARNING {
+		t.Errorf(`Decode() after errors = %v, %v`, e, err)
+	}
+	if _, err := d.Decode(); err != io.EOF {
+		t.Errorf(`Decode() at end = %v, want io.EOF`, err)
+	}
+}
+
+func TestDecoderStrict(t *testing.T) {
+	d := NewDecoder(strings.NewReader(`{"level":"ERROR","text":"ok"}` + "\n" + `{"level":"SEVERE","text":"bad"}`))
+	d.SetStrict(DecodeLimits{})
+	if e, err := d.Decode(); err != nil || e.Text() != "ok" {
+		t.Errorf(`Decode(valid) = %v, %v`, e, err)
+	}
+	if _, err := d.Decode(); err == nil || err.(Error).Code() != ERR_DECODE || !strings.HasPrefix(err.Error(), "record #2: ") {
+		t.Errorf(`Decode(invalid) = %v`, err)
+	}
+}
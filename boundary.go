@@ -0,0 +1,131 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.7 && !errors_minimal
+// +build go1.7,!errors_minimal
+
+package errors
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// fieldPlugin is the field key recording the plugin an error is attributed
+// to, see Boundary.
+const fieldPlugin = "plugin"
+
+// BoundaryPolicy configures a Boundary.
+type BoundaryPolicy struct {
+	// Timeout is the maximum duration of a call; zero means no limit.
+	Timeout time.Duration
+	// Sanitize configures the sanitization of the errors returned.
+	Sanitize SanitizeOptions
+	// Redact lists the fields whose values are replaced by "[REDACTED]".
+	Redact []string
+}
+
+// Boundary contains the failures of the calls into an extension, such as a
+// plugin or a callback, so that a misbehaving one cannot take the host down
+// or leak into its logs unchecked.
+type Boundary struct {
+	plugin string
+	policy BoundaryPolicy
+	calls  sync.WaitGroup // running calls, including abandoned ones
+}
+
+// NewBoundary returns a Boundary for the calls into the extension named
+// plugin, applying policy.
+func NewBoundary(plugin string, policy BoundaryPolicy) *Boundary {
+	policy.Redact = append([]string(nil), policy.Redact...)
+	return &Boundary{plugin: plugin, policy: policy}
+}
+
+// Call runs fn and returns its failure, if any, as an Error: a panic is
+// converted into an Error with the level PANIC and the code ERR_SYSTEM; a call
+// lasting longer than the timeout of the policy is abandoned, with an Error
+// with the code ERR_TIMEOUT, while fn keeps running until it returns, so it
+// should honor the cancellation of ctx; and a returned error goes through
+// Classify. The result is then sanitized and redacted according to the
+// policy, and attributed to the plugin with the field "plugin", see Plugin.
+// Call returns nil if fn succeeds.
+func (b *Boundary) Call(ctx context.Context, fn func(ctx context.Context) error) Error {
+	if b.policy.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.policy.Timeout)
+		defer cancel()
+	}
+	done := make(chan Error, 1)
+	b.calls.Add(1)
+	go func() {
+		defer b.calls.Done()
+		defer func() {
+			if v := recover(); v != nil {
+				done <- b.panicked(v)
+			}
+		}()
+		done <- Classify(fn(ctx))
+	}()
+	var e Error
+	select {
+	case e = <-done:
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			e = WithField(New(Desc{
+				Code: ERR_TIMEOUT,
+				Text: fmt.Sprintf("plugin %s: timed out after %v", b.plugin, b.policy.Timeout),
+			}), fieldTimeout, true)
+		} else {
+			e = Wrap(ctx.Err(), "plugin "+b.plugin)
+		}
+	}
+	if e == nil {
+		return nil
+	}
+	return b.contain(e)
+}
+
+// contain returns a sanitized copy of e, redacted according to the policy
+// and attributed to the plugin.
+func (b *Boundary) contain(e Error) Error {
+	e = Sanitize(e, b.policy.Sanitize)
+	if em := ownMessage(e); em != nil {
+		redact(em, b.policy.Redact)
+		em.setField(fieldPlugin, b.plugin)
+	}
+	return e
+}
+
+// panicked returns an Error describing a panic of the plugin with value v,
+// wrapping v if it is an error.
+func (b *Boundary) panicked(v interface{}) Error {
+	desc := Desc{Code: ERR_SYSTEM, Level: PANIC, Text: "plugin " + b.plugin + " panicked", Info: []string{"debug.stack"}}
+	if err, ok := v.(error); ok {
+		return Wrap(err, desc)
+	}
+	desc.Text += fmt.Sprintf(": %v", v)
+	return New(desc)
+}
+
+// Plugin returns the plugin err is attributed to by a Boundary, or an empty
+// string.
+func Plugin(err error) string {
+	if em := messageOf(err); em != nil {
+		plugin, _ := em.field(fieldPlugin).(string)
+		return plugin
+	}
+	return ""
+}
@@ -0,0 +1,63 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.7 && !errors_minimal
+// +build go1.7,!errors_minimal
+
+package errors
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBoundary(t *testing.T) {
+	b := NewBoundary("thumbnailer", BoundaryPolicy{Timeout: 20 * time.Millisecond, Redact: []string{"token"}})
+	ctx := context.Background()
+
+	if e := b.Call(ctx, func(context.Context) error { return nil }); e != nil {
+		t.Errorf(`Call(succeeding) = %v`, e)
+	}
+	e := b.Call(ctx, func(context.Context) error {
+		return WithField(New("bad\nimage"), "token", "s3cr3t")
+	})
+	if e == nil || e.Text() != `bad\nimage` || Plugin(e) != "thumbnailer" || Fields(e)["token"] != "[REDACTED]" {
+		t.Errorf(`Call(failing) = %q, plugin %q, fields %v`, e, Plugin(e), Fields(e))
+	}
+	e = b.Call(ctx, func(context.Context) error { panic("nil map") })
+	if e == nil || e.Level() != PANIC || e.Code() != ERR_SYSTEM || e.Text() != "plugin thumbnailer panicked: nil map" {
+		t.Errorf(`Call(panicking) = %v, level %d`, e, e.Level())
+	}
+	cause := fmt.Errorf("index out of range")
+	if e := b.Call(ctx, func(context.Context) error { panic(cause) }); e == nil || e.(interface{ Unwrap() error }).Unwrap() == nil {
+		t.Errorf(`Call(panicking with an error) = %v, without cause`, e)
+	}
+	e = b.Call(ctx, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if e == nil || e.Code() != ERR_TIMEOUT || Plugin(e) != "thumbnailer" {
+		t.Errorf(`Call(slow) = %v`, e)
+	}
+	b.calls.Wait() // for the abandoned call to finish
+
+	e = b.Call(ctx, func(context.Context) error {
+		panic(Wrap(timeoutError{}, "dialing"))
+	})
+	if _, ok := e.(interface{ Timeout() bool }); !ok || e.Level() != PANIC || Plugin(e) != "thumbnailer" {
+		t.Errorf(`Call(panicking with a net error) = %v, plugin %q`, e, Plugin(e))
+	}
+}
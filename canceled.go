@@ -0,0 +1,108 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.7
+// +build go1.7
+
+package errors
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// fieldClientCanceled marks the errors caused by the cancellation of a
+// context, presumably by the client.
+const fieldClientCanceled = "client_canceled"
+
+// CanceledOptions configures the classification of the errors caused by
+// context.Canceled, see SetCanceledOptions.
+type CanceledOptions struct {
+	// Disabled leaves such errors unclassified.
+	Disabled bool
+	// Level is the level given to such errors (default WARNING).
+	Level Level
+	// Count makes such errors counted by metrics, see SetMetrics.
+	Count bool
+}
+
+var canceledOptions atomic.Value // CanceledOptions
+
+// SetCanceledOptions sets the classification of the errors caused by
+// context.Canceled, i.e. wrapping it or converted from an error wrapping it.
+// By default, they are marked with the field "client_canceled", given the
+// level WARNING, and left out of metrics, so that requests abandoned by
+// clients do not inflate error rates. Classifiers, cause extractors and the
+// hook set with SetSeverityFn still take precedence.
+func SetCanceledOptions(opts CanceledOptions) {
+	if !opts.Level.Valid() {
+		opts.Level = LEVEL_WARNING
+	}
+	canceledOptions.Store(opts)
+}
+
+// currentCanceledOptions returns the options set with SetCanceledOptions.
+func currentCanceledOptions() CanceledOptions {
+	if opts, ok := canceledOptions.Load().(CanceledOptions); ok {
+		return opts
+	}
+	return CanceledOptions{Level: LEVEL_WARNING}
+}
+
+// ClientCanceled reports whether err has been classified as caused by the
+// cancellation of a context, see SetCanceledOptions.
+func ClientCanceled(err error) bool {
+	em := messageOf(err)
+	return em != nil && em.clientCanceled()
+}
+
+// clientCanceled reports whether em is marked as client-canceled.
+func (em *errorMessage) clientCanceled() bool {
+	v, _ := em.field(fieldClientCanceled).(bool)
+	return v
+}
+
+// classifyCanceled marks em as client-canceled if err, its cause, is
+// context.Canceled or wraps it. The level is left alone if err already is a
+// client-canceled error of this package, having been classified then.
+func classifyCanceled(em *errorMessage, err error) {
+	opts := currentCanceledOptions()
+	if opts.Disabled {
+		return
+	}
+	for err != nil {
+		if err == context.Canceled {
+			em.level = int8(opts.Level)
+			em.setField(fieldClientCanceled, true)
+			return
+		}
+		if m := messageOf(err); m != nil && m.clientCanceled() {
+			em.setField(fieldClientCanceled, true)
+			return
+		}
+		u, isWrapper := err.(interface {
+			Unwrap() error
+		})
+		if !isWrapper {
+			return
+		}
+		err = u.Unwrap()
+	}
+}
+
+// uncountedCanceled reports whether em is left out of metrics as
+// client-canceled.
+func uncountedCanceled(em *errorMessage) bool {
+	return em.clientCanceled() && !currentCanceledOptions().Count
+}
@@ -0,0 +1,79 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.9 && !errors_minimal
+// +build go1.9,!errors_minimal
+
+package errors
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestClientCanceled(t *testing.T) {
+	defer SetCanceledOptions(CanceledOptions{})
+
+	e := Wrap(context.Canceled, "fetch")
+	if !ClientCanceled(e) || e.Level() != WARNING {
+		t.Errorf(`Wrap(context.Canceled): ClientCanceled = %v, level %d`, ClientCanceled(e), e.Level())
+	}
+	if e := Wrap(e, "handle"); !ClientCanceled(e) || e.Level() != WARNING {
+		t.Errorf(`Wrap(Wrap(context.Canceled)): ClientCanceled = %v, level %d`, ClientCanceled(e), e.Level())
+	}
+	if e := Classify(context.Canceled); !ClientCanceled(e) || e.Level() != WARNING {
+		t.Errorf(`Classify(context.Canceled): ClientCanceled = %v, level %d`, ClientCanceled(e), e.Level())
+	}
+	if e := Wrap(context.DeadlineExceeded, "fetch"); ClientCanceled(e) || e.Level() != ERROR {
+		t.Errorf(`Wrap(context.DeadlineExceeded): ClientCanceled = %v, level %d`, ClientCanceled(e), e.Level())
+	}
+
+	SetCanceledOptions(CanceledOptions{Level: LEVEL_ERROR})
+	if e := Wrap(context.Canceled, "fetch"); !ClientCanceled(e) || e.Level() != ERROR {
+		t.Errorf(`level ERROR: ClientCanceled = %v, level %d`, ClientCanceled(e), e.Level())
+	}
+	SetCanceledOptions(CanceledOptions{Disabled: true})
+	if e := Wrap(context.Canceled, "fetch"); ClientCanceled(e) || e.Level() != ERROR {
+		t.Errorf(`disabled: ClientCanceled = %v, level %d`, ClientCanceled(e), e.Level())
+	}
+}
+
+func TestClientCanceledMetrics(t *testing.T) {
+	defer SetMetrics(false)
+	defer SetCanceledOptions(CanceledOptions{})
+	SetMetrics(true)
+
+	log := &mockLogger{}
+	Wrap(context.Canceled, "fetch").Log(log)
+	New("failed").Log(log)
+	var buf bytes.Buffer
+	if err := WriteMetrics(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "WARNING") {
+		t.Errorf(`canceled error counted:\n%s`, buf.String())
+	}
+
+	SetCanceledOptions(CanceledOptions{Count: true})
+	Wrap(context.Canceled, "fetch").Log(log)
+	buf.Reset()
+	if err := WriteMetrics(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `level="WARNING"} 1`) {
+		t.Errorf(`canceled error not counted:\n%s`, buf.String())
+	}
+}
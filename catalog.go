@@ -0,0 +1,127 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Catalog describes the error space of a service, e.g. for contract tests
+// between the service and its clients. It can be stored and exchanged as JSON.
+type Catalog struct {
+	Codes map[int]CatalogEntry `json:"codes"`
+}
+
+// CatalogEntry describes an error code in a Catalog.
+type CatalogEntry struct {
+	// Name is the symbolic name of the code.
+	Name string `json:"name"`
+	// Text is the public text errors with the code are given.
+	Text string `json:"text,omitempty"`
+	// Mappings holds the equivalents of the code in other error spaces, e.g.
+	// "http": "404".
+	Mappings map[string]string `json:"mappings,omitempty"`
+}
+
+// ExportCatalog returns a Catalog of the codes registered with RegisterCode.
+func ExportCatalog() Catalog {
+	codes.RLock()
+	defer codes.RUnlock()
+	c := Catalog{Codes: make(map[int]CatalogEntry, len(codes.names))}
+	for code, name := range codes.names {
+		c.Codes[code] = CatalogEntry{Name: name}
+	}
+	return c
+}
+
+// Kinds of catalog changes.
+const (
+	CHANGE_ADDED int8 = iota + 1
+	CHANGE_REMOVED
+	CHANGE_MODIFIED
+)
+
+// CatalogChange describes a difference between two catalogs.
+type CatalogChange struct {
+	Kind int8
+	Code int
+	// Attr is the modified attribute: "name", "text", or "mappings." followed
+	// by the mapping key.
+	Attr     string
+	Old, New string
+}
+
+func (c CatalogChange) String() string {
+	switch c.Kind {
+	case CHANGE_ADDED:
+		return fmt.Sprintf("added 0x%04x %s", c.Code, c.New)
+	case CHANGE_REMOVED:
+		return fmt.Sprintf("removed 0x%04x %s", c.Code, c.Old)
+	}
+	return fmt.Sprintf("changed 0x%04x %s: %q -> %q", c.Code, c.Attr, c.Old, c.New)
+}
+
+// CatalogDiff returns the changes from catalog a to catalog b, ordered by
+// code: the codes added and removed, and the attributes modified.
+func CatalogDiff(a, b Catalog) []CatalogChange {
+	var all []int
+	for code := range a.Codes {
+		all = append(all, code)
+	}
+	for code := range b.Codes {
+		if _, ok := a.Codes[code]; !ok {
+			all = append(all, code)
+		}
+	}
+	sort.Ints(all)
+
+	var changes []CatalogChange
+	for _, code := range all {
+		ea, inA := a.Codes[code]
+		eb, inB := b.Codes[code]
+		switch {
+		case !inA:
+			changes = append(changes, CatalogChange{Kind: CHANGE_ADDED, Code: code, New: eb.Name})
+		case !inB:
+			changes = append(changes, CatalogChange{Kind: CHANGE_REMOVED, Code: code, Old: ea.Name})
+		default:
+			modified := func(attr, old, new string) {
+				if old != new {
+					changes = append(changes, CatalogChange{CHANGE_MODIFIED, code, attr, old, new})
+				}
+			}
+			modified("name", ea.Name, eb.Name)
+			modified("text", ea.Text, eb.Text)
+			var keys []string
+			for k := range ea.Mappings {
+				keys = append(keys, k)
+			}
+			for k := range eb.Mappings {
+				if _, ok := ea.Mappings[k]; !ok {
+					keys = append(keys, k)
+				}
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				modified("mappings."+k, ea.Mappings[k], eb.Mappings[k])
+			}
+		}
+	}
+	return changes
+}
@@ -0,0 +1,52 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCatalogDiff(t *testing.T) {
+	RegisterCode(0x2104, "NOT_FOUND")
+	defer RegisterCode(0x2104, "")
+	a := ExportCatalog()
+	if len(a.Codes) != 1 || a.Codes[0x2104].Name != "NOT_FOUND" {
+		t.Errorf(`ExportCatalog() = %v`, a)
+	}
+	a.Codes[0x2105] = CatalogEntry{Name: "CONFLICT", Text: "Conflict.", Mappings: map[string]string{"http": "409", "grpc": "ABORTED"}}
+	a.Codes[0x2106] = CatalogEntry{Name: "GONE"}
+	b := Catalog{Codes: map[int]CatalogEntry{
+		0x2104: {Name: "NOT_FOUND"},
+		0x2105: {Name: "CONFLICT", Text: "Already exists.", Mappings: map[string]string{"http": "409", "grpc": "ALREADY_EXISTS", "ws": "4009"}},
+		0x2107: {Name: "LOCKED"},
+	}}
+	want := []string{
+		`changed 0x2105 text: "Conflict." -> "Already exists."`,
+		`changed 0x2105 mappings.grpc: "ABORTED" -> "ALREADY_EXISTS"`,
+		`changed 0x2105 mappings.ws: "" -> "4009"`,
+		`removed 0x2106 GONE`,
+		`added 0x2107 LOCKED`,
+	}
+	if got := CatalogDiff(a, b); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf(`CatalogDiff(a, b) = %q, want %q`, got, want)
+	}
+	if got := CatalogDiff(b, b); got != nil {
+		t.Errorf(`CatalogDiff(b, b) = %q, want none`, got)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import "sync"
+
+// Cause holds the details extracted from a third-party error: its native
+// code and status, as a code and level of this package (zero if unknown),
+// and any fields worth keeping, e.g. "aws.request_id" or "pq.constraint".
+type Cause struct {
+	Code   int
+	Level  Level
+	Fields map[string]interface{}
+}
+
+// CauseExtractor extracts a Cause from err, for the error types of a given
+// library (an SDK, a database driver, ...). The last result is false if err
+// is not of such a type.
+type CauseExtractor func(err error) (Cause, bool)
+
+var extractors struct {
+	sync.RWMutex
+	list []CauseExtractor
+}
+
+// AddCauseExtractor appends x to the extractors applied when plain errors
+// are converted, by Classify and the handlers of this package. The first
+// extractor applying to the converted error, or to an error it wraps (through
+// an Unwrap method), provides the code, level and fields of the result;
+// classifiers still take precedence over them.
+func AddCauseExtractor(x CauseExtractor) {
+	extractors.Lock()
+	extractors.list = append(extractors.list, x)
+	extractors.Unlock()
+}
+
+// extractCause returns the Cause extracted from the chain of err, if any.
+func extractCause(err error) (c Cause, ok bool) {
+	extractors.RLock()
+	xs := extractors.list
+	extractors.RUnlock()
+	if len(xs) == 0 {
+		return
+	}
+	for err != nil {
+		for _, x := range xs {
+			if c, ok = x(err); ok {
+				return
+			}
+		}
+		u, isWrapper := err.(interface {
+			Unwrap() error
+		})
+		if !isWrapper {
+			break
+		}
+		err = u.Unwrap()
+	}
+	return
+}
+
+// applyCause sets the details extracted from err on em.
+func applyCause(em *errorMessage, err error) {
+	c, ok := extractCause(err)
+	if !ok {
+		classifyCanceled(em, err)
+		return
+	}
+	if c.Code != 0 {
+		em.code = c.Code
+	}
+	if c.Level.Valid() {
+		em.level = int8(c.Level)
+	}
+	for k, v := range c.Fields {
+		em.setField(k, v)
+	}
+}
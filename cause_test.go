@@ -0,0 +1,71 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"fmt"
+	"testing"
+)
+
+type apiError struct {
+	status    int
+	requestID string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("api: status %d", e.status)
+}
+
+// plainWrapper is a plain error wrapping another one.
+type plainWrapper struct {
+	cause error
+}
+
+func (w plainWrapper) Error() string {
+	return "calling api: " + w.cause.Error()
+}
+
+func (w plainWrapper) Unwrap() error {
+	return w.cause
+}
+
+func TestCauseExtractor(t *testing.T) {
+	defer func() { extractors.list = nil }()
+	AddCauseExtractor(func(err error) (Cause, bool) {
+		ae, ok := err.(*apiError)
+		if !ok {
+			return Cause{}, false
+		}
+		c := Cause{Code: 0x3000 + ae.status, Fields: map[string]interface{}{"api.request_id": ae.requestID}}
+		if ae.status >= 500 {
+			c.Level = LEVEL_WARNING
+		}
+		return c, true
+	})
+
+	e := Classify(plainWrapper{&apiError{503, "r-1"}})
+	if e.Code() != 0x3000+503 || e.Level() != WARNING {
+		t.Errorf(`Classify(wrapped apiError) code %#x, level %d`, e.Code(), e.Level())
+	}
+	if v, _ := Field(e, "api.request_id"); v != "r-1" {
+		t.Errorf(`field "api.request_id" = %v`, v)
+	}
+	if e := Classify(&apiError{404, "r-2"}); e.Code() != 0x3000+404 || e.Level() != ERROR {
+		t.Errorf(`Classify(apiError 404) code %#x, level %d`, e.Code(), e.Level())
+	}
+	if e := Classify(fmt.Errorf("other")); e.Code() != 0 {
+		t.Errorf(`Classify(other) code %#x`, e.Code())
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.7 && !errors_minimal
+// +build go1.7,!errors_minimal
+
+package errors
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// fromChan converts an error received on the i-th channel into an Error.
+// Values that already implement Error are passed through as they are; any
+// other error is wrapped and stamped with the index of the originating channel.
+func fromChan(err error, i int) Error {
+	if e, ok := err.(Error); ok {
+		return e
+	}
+	em := newMessage(err.Error())
+	em.appendInfo(fmt.Sprintf("from channel %d", i))
+	em.cause = err
+	return wrapped(em, err)
+}
+
+// FanIn merges the errors received on chans into a single channel of Error
+// values, skipping nils. The returned channel is closed once all input
+// channels are closed.
+//
+// FanIn starts a goroutine per input channel, which runs until the channel is
+// closed: every channel in chans must eventually be closed, or its goroutine
+// is leaked.
+func FanIn(chans ...<-chan error) <-chan Error {
+	out := make(chan Error)
+	var wg sync.WaitGroup
+	wg.Add(len(chans))
+	for i, ch := range chans {
+		go func(i int, ch <-chan error) {
+			defer wg.Done()
+			for err := range ch {
+				if err != nil {
+					out <- fromChan(err, i)
+				}
+			}
+		}(i, ch)
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+// FirstError returns the first non-nil error received on any of chans,
+// converted to an Error the same way as FanIn does. It returns nil if all
+// channels are closed without delivering an error, and an Error describing
+// the context error, and wrapping it, if ctx is done first. Errors delivered
+// after the first one are discarded.
+//
+// The channels are drained after FirstError returns, so that their senders
+// are not blocked, until they are closed: as with FanIn, every channel in
+// chans must eventually be closed, or goroutines are leaked.
+func FirstError(ctx context.Context, chans ...<-chan error) Error {
+	in := FanIn(chans...)
+	// keep draining the merged channel after returning, so that no sender is
+	// left blocked on it
+	defer func() {
+		go func() {
+			for range in {
+			}
+		}()
+	}()
+	select {
+	case err := <-in:
+		return err
+	case <-ctx.Done():
+		em := newMessage(ctx.Err().Error())
+		em.cause = ctx.Err()
+		return wrapped(em, ctx.Err())
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.7 && !errors_minimal
+// +build go1.7,!errors_minimal
+
+package errors
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestFanIn(t *testing.T) {
+	c1, c2 := make(chan error, 2), make(chan error, 2)
+	sentinel := New("abc")
+	c1 <- sentinel
+	c1 <- nil
+	cause := fmt.Errorf("xyz")
+	c2 <- cause
+	close(c1)
+	close(c2)
+	var got []Error
+	for err := range FanIn(c1, c2) {
+		got = append(got, err)
+	}
+	if len(got) != 2 {
+		t.Fatalf(`len(FanIn(...)) = %d, want %d`, len(got), 2)
+	}
+	for _, err := range got {
+		if err == sentinel {
+			continue
+		}
+		if err.Text() != "xyz" {
+			t.Errorf(`FanIn(...) error text = %q, want %q`, err.Text(), "xyz")
+		}
+		if len(err.Info()) != 1 || err.Info()[0] != "from channel 1" {
+			t.Errorf(`FanIn(...) error info = %q, want %q`, err.Info(), []string{"from channel 1"})
+		}
+		if err.(interface{ Unwrap() error }).Unwrap() != cause {
+			t.Errorf(`FanIn(...) error does not wrap the received error`)
+		}
+	}
+}
+
+func TestFirstError(t *testing.T) {
+	c1, c2 := make(chan error), make(chan error, 1)
+	c2 <- fmt.Errorf("xyz")
+	if err := FirstError(context.Background(), c1, c2); err == nil || err.Text() != "xyz" {
+		t.Errorf(`FirstError(...) = %v, want %q`, err, "xyz")
+	}
+	close(c1)
+
+	c3 := make(chan error)
+	close(c3)
+	if err := FirstError(context.Background(), c3); err != nil {
+		t.Errorf(`FirstError(closed) = %v, want <nil>`, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c4 := make(chan error)
+	if err := FirstError(ctx, c4); err == nil || err.Text() != context.Canceled.Error() {
+		t.Errorf(`FirstError(canceled) = %v, want %q`, err, context.Canceled.Error())
+	} else if err.(interface{ Unwrap() error }).Unwrap() != context.Canceled {
+		t.Errorf(`FirstError(canceled) does not wrap context.Canceled`)
+	}
+	close(c4)
+}
+
+func TestGroupCollect(t *testing.T) {
+	a, b := make(chan error, 2), make(chan error, 1)
+	a <- fmt.Errorf("disk full")
+	a <- nil
+	b <- New(Desc{Level: WARNING, Text: "slow"})
+	close(a)
+	close(b)
+	g := NewGroup("workers failed").Collect(FanIn(a, b))
+	if g.Len() != 2 || g.Level() != ERROR {
+		t.Errorf(`Collect(FanIn(a, b)): %q, level %d`, g.Error(), g.Level())
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Field keys used to record the results of shadow classification.
+const (
+	fieldShadowCode  = "shadow.code"
+	fieldShadowLevel = "shadow.level"
+)
+
+// Field keys used to describe I/O failures.
+const (
+	fieldIOOp       = "io.op"
+	fieldIOBytes    = "io.bytes"
+	fieldIOElapsed  = "io.elapsed"
+	fieldIODeadline = "io.deadline"
+	fieldTimeout    = "timeout"
+	fieldRetryable  = "retryable"
+)
+
+// Retryable reports whether err has been classified as retryable.
+func Retryable(err error) bool {
+	if em := messageOf(err); em != nil {
+		r, _ := em.field(fieldRetryable).(bool)
+		return r
+	}
+	return false
+}
+
+// Classifier assigns a code and a level to err. The last result is false if
+// the classifier does not apply to err.
+type Classifier func(err error) (code int, level Level, ok bool)
+
+var classifiers struct {
+	sync.RWMutex
+	live, shadow []Classifier
+}
+
+// counters of shadow classifications, and of those which diverged
+var shadowedCount, divergedCount uint64
+
+// AddClassifier appends c to the rules used by Classify.
+func AddClassifier(c Classifier) {
+	classifiers.Lock()
+	classifiers.live = append(classifiers.live, c)
+	classifiers.Unlock()
+}
+
+// SetShadowClassifiers sets a rule set run by Classify alongside the live one
+// without affecting its result, to validate a reclassification before
+// switching to it. When the shadow rules yield a different code or level than
+// the live ones, the shadow results are recorded as the fields "shadow.code"
+// and "shadow.level", and observers are notified with EVENT_SHADOW_DIVERGENCE.
+// Calling it without arguments ends shadow mode.
+func SetShadowClassifiers(cs ...Classifier) {
+	classifiers.Lock()
+	classifiers.shadow = cs
+	classifiers.Unlock()
+}
+
+// ShadowStats returns the number of errors classified in shadow mode, and
+// the number of those for which the shadow rules diverged from the live ones.
+func ShadowStats() (classified, diverged uint64) {
+	return atomic.LoadUint64(&shadowedCount), atomic.LoadUint64(&divergedCount)
+}
+
+// classify returns the code and level assigned to err by the first of cs
+// which applies to it.
+func classify(cs []Classifier, err error) (code int, level Level, ok bool) {
+	for _, c := range cs {
+		if code, level, ok = c(err); ok {
+			return
+		}
+	}
+	return
+}
+
+// Classify returns err as an Error, with the code and level assigned by the
+// first classifier applying to it; if err already is an Error, it is modified
+// in place. If no classifier applies, plain errors are converted with the
+// level ERROR and no code. The level is then subject to the hook set with
+// SetSeverityFn. Classify returns nil if err is nil.
+func Classify(err error) Error {
+	if err == nil {
+		return nil
+	}
+	classifiers.RLock()
+	live, shadow := classifiers.live, classifiers.shadow
+	classifiers.RUnlock()
+
+	e := from(err)
+	code, level, ok := classify(live, err)
+	if ok {
+		e.SetCode(code).SetLevel(int8(level))
+	} else {
+		code, level = e.Code(), Level(e.Level())
+	}
+	applySeverity(e)
+	if len(shadow) == 0 {
+		return e
+	}
+	atomic.AddUint64(&shadowedCount, 1)
+	sCode, sLevel, sOk := classify(shadow, err)
+	if !sOk {
+		sCode, sLevel = code, level
+	}
+	if sCode != code || sLevel != level {
+		atomic.AddUint64(&divergedCount, 1)
+		if em := ownMessage(e); em != nil {
+			em.setField(fieldShadowCode, sCode)
+			em.setField(fieldShadowLevel, sLevel.String())
+		}
+		notify(EVENT_SHADOW_DIVERGENCE, e)
+	}
+	return e
+}
@@ -0,0 +1,77 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"fmt"
+	"io"
+	"testing"
+)
+
+func resetClassifiers() {
+	classifiers.live, classifiers.shadow = nil, nil
+	shadowedCount, divergedCount = 0, 0
+}
+
+func TestClassify(t *testing.T) {
+	defer resetClassifiers()
+	if Classify(nil) != nil {
+		t.Errorf(`Classify(nil) != nil`)
+	}
+	AddClassifier(func(err error) (int, Level, bool) {
+		return ERR_UNAVAILABLE, LEVEL_WARNING, err == io.ErrUnexpectedEOF
+	})
+	err := Classify(io.ErrUnexpectedEOF)
+	if err.Code() != ERR_UNAVAILABLE || err.Level() != WARNING || err.Text() != io.ErrUnexpectedEOF.Error() {
+		t.Errorf(`Classify(io.ErrUnexpectedEOF) = %v, level %s`, err, levelName(err.Level()))
+	}
+	e := New(Desc{Code: 1, Text: "abc"})
+	if err = Classify(e); err != e || err.Code() != 1 || err.Level() != ERROR {
+		t.Errorf(`Classify(e) = %v, level %s`, err, levelName(err.Level()))
+	}
+}
+
+func TestShadowClassifiers(t *testing.T) {
+	defer resetClassifiers()
+	var diverged []Error
+	remove := AddObserver(func(event int8, err Error) {
+		if event == EVENT_SHADOW_DIVERGENCE {
+			diverged = append(diverged, err)
+		}
+	})
+	defer remove()
+	AddClassifier(func(err error) (int, Level, bool) {
+		return ERR_UNAVAILABLE, LEVEL_WARNING, err == io.ErrUnexpectedEOF
+	})
+	SetShadowClassifiers(func(err error) (int, Level, bool) {
+		return ERR_INVALID, LEVEL_ERROR, err == io.ErrUnexpectedEOF
+	}, func(err error) (int, Level, bool) {
+		return ERR_SYSTEM, LEVEL_ERROR, err == io.ErrClosedPipe
+	})
+
+	err := Classify(io.ErrUnexpectedEOF)
+	if err.Code() != ERR_UNAVAILABLE || err.Level() != WARNING {
+		t.Errorf(`Classify(...) live result = %v, level %s`, err, levelName(err.Level()))
+	}
+	em := err.(*errorMessage)
+	if em.field(fieldShadowCode) != ERR_INVALID || em.field(fieldShadowLevel) != "ERROR" {
+		t.Errorf(`Classify(...) shadow result = %v, %v`, em.field(fieldShadowCode), em.field(fieldShadowLevel))
+	}
+	Classify(fmt.Errorf("abc"))
+	Classify(io.ErrClosedPipe)
+	if c, d := ShadowStats(); c != 3 || d != 2 || len(diverged) != 2 {
+		t.Errorf(`ShadowStats() = %d, %d, with %d notifications, want 3, 2, 2`, c, d, len(diverged))
+	}
+}
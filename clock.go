@@ -0,0 +1,116 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the source of the current time for everything in this package
+// that needs one. It can be replaced with SetClock, e.g. for deterministic
+// tests.
+type Clock interface {
+	Now() time.Time
+}
+
+// IDGenerator is the source of unique identifiers for everything in this
+// package that needs one. It can be replaced with SetIDGenerator.
+type IDGenerator interface {
+	NewID() string
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// ulidGenerator generates ULIDs: 48 bits of millisecond timestamp taken from
+// the package clock, followed by 80 random bits, in Crockford's base32.
+type ulidGenerator struct{}
+
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+func (ulidGenerator) NewID() string {
+	var b [16]byte
+	ms := uint64(now().UnixNano() / int64(time.Millisecond))
+	for i := 5; i >= 0; i-- {
+		b[i] = byte(ms)
+		ms >>= 8
+	}
+	randomBytes(b[6:])
+	// 128 bits are encoded as 26 characters, the first one holding 3 bits
+	var id [26]byte
+	var acc uint
+	var bits uint = 2 // zero padding in front of the first character
+	j := 0
+	for _, c := range b {
+		acc = acc<<8 | uint(c)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			id[j] = crockford[(acc>>bits)&31]
+			j++
+		}
+	}
+	return string(id[:])
+}
+
+var sources = struct {
+	sync.RWMutex
+	clock Clock
+	ids   IDGenerator
+}{
+	clock: systemClock{},
+	ids:   ulidGenerator{},
+}
+
+// SetClock sets the clock used by the package; nil restores the system clock.
+func SetClock(c Clock) {
+	if c == nil {
+		c = systemClock{}
+	}
+	sources.Lock()
+	sources.clock = c
+	sources.Unlock()
+}
+
+// SetIDGenerator sets the identifier source used by the package; nil restores
+// the default ULID generator.
+func SetIDGenerator(g IDGenerator) {
+	if g == nil {
+		g = ulidGenerator{}
+	}
+	sources.Lock()
+	sources.ids = g
+	sources.Unlock()
+}
+
+// now returns the current time according to the package clock.
+func now() time.Time {
+	sources.RLock()
+	c := sources.clock
+	sources.RUnlock()
+	return c.Now()
+}
+
+// newID returns a new identifier from the package identifier source.
+func newID() string {
+	sources.RLock()
+	g := sources.ids
+	sources.RUnlock()
+	return g.NewID()
+}
@@ -0,0 +1,86 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+type seqIDs int
+
+func (s *seqIDs) NewID() string {
+	*s++
+	return strings.Repeat("x", int(*s))
+}
+
+func TestClock(t *testing.T) {
+	at := time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC)
+	SetClock(fixedClock(at))
+	defer SetClock(nil)
+	if !now().Equal(at) {
+		t.Errorf(`now() = %v, want %v`, now(), at)
+	}
+	// the timestamp part of a ULID is derived from the package clock
+	if id := newID(); len(id) != 26 || id[:10] != "01A804NG48" {
+		t.Errorf(`newID() = %q, want 26 characters starting with %q`, id, "01A804NG48")
+	}
+	SetClock(nil)
+	if time.Since(now()) > time.Minute {
+		t.Errorf(`now() = %v after SetClock(nil)`, now())
+	}
+}
+
+func TestIDGenerator(t *testing.T) {
+	if newID() == newID() {
+		t.Errorf(`newID() == newID()`)
+	}
+	ids := seqIDs(0)
+	SetIDGenerator(&ids)
+	defer SetIDGenerator(nil)
+	if id := newID(); id != "x" {
+		t.Errorf(`newID() = %q, want %q`, id, "x")
+	}
+}
+
+func TestReclassifyByAge(t *testing.T) {
+	at := time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC)
+	SetClock(fixedClock(at))
+	err := New(Desc{Level: WARNING, Text: "abc"})
+	SetClock(nil)
+	if !Time(err).Equal(at) {
+		t.Errorf(`Time(err) = %v, want %v`, Time(err), at)
+	}
+	rules := []AgeRule{
+		{Level: LEVEL_ERROR, Age: time.Minute, To: LEVEL_FATAL},
+		{Level: LEVEL_WARNING, Age: time.Hour, To: LEVEL_ERROR},
+	}
+	if ReclassifyByAge(err, at.Add(time.Hour), rules...).Level() != WARNING {
+		t.Errorf(`ReclassifyByAge(err, +1h) reclassified err`)
+	}
+	if ReclassifyByAge(err, at.Add(time.Hour+1), rules...).Level() != ERROR {
+		t.Errorf(`ReclassifyByAge(err, +1h1ns).Level() = %s, want ERROR`, levelName(err.Level()))
+	}
+	if from := err.(*errorMessage).field(fieldReclassifiedFrom); from != "WARNING" {
+		t.Errorf(`reclassified from %v, want WARNING`, from)
+	}
+}
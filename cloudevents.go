@@ -0,0 +1,90 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// cloudEvent is a CloudEvents 1.0 event in structured JSON mode.
+type cloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            *time.Time      `json:"time,omitempty"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            json.RawMessage `json:"data"`
+}
+
+// ToCloudEvent returns err as a CloudEvents 1.0 event in structured JSON mode.
+// The event type is typePrefix followed by a dot and the name of the code of
+// err (its hexadecimal value if unregistered, or the lowercase level name if
+// err has no code), and its subject is the origin of err. The data is the
+// serialized error, which FromCloudEvent turns back into an Error.
+func ToCloudEvent(err Error, source, typePrefix string) ([]byte, error) {
+	data, e := encodeWire(err)
+	if e != nil {
+		return nil, e
+	}
+	name := strings.ToLower(levelName(err.Level()))
+	if code := err.Code(); code != 0 {
+		if name = CodeName(code); name == "" {
+			name = fmt.Sprintf("0x%04x", code)
+		}
+	}
+	ev := cloudEvent{
+		SpecVersion:     "1.0",
+		ID:              newID(),
+		Source:          source,
+		Type:            typePrefix + "." + name,
+		DataContentType: "application/json",
+		Data:            data,
+	}
+	if service, instance := Origin(err); service != "" || instance != "" {
+		ev.Subject = strings.Trim(service+"/"+instance, "/")
+	}
+	if t := Time(err); !t.IsZero() {
+		ev.Time = &t
+	}
+	return json.Marshal(ev)
+}
+
+// FromCloudEvent returns the Error carried by a CloudEvents 1.0 event in
+// structured JSON mode, as produced by ToCloudEvent.
+func FromCloudEvent(data []byte) (Error, error) {
+	var ev cloudEvent
+	if err := json.Unmarshal(data, &ev); err != nil {
+		return nil, err
+	}
+	if ev.SpecVersion != "1.0" {
+		return nil, fmt.Errorf("unsupported CloudEvents version %q", ev.SpecVersion)
+	}
+	if ev.DataContentType != "application/json" || ev.Data == nil {
+		return nil, fmt.Errorf("event %s does not carry a serialized error", ev.ID)
+	}
+	w, err := decodeWire(ev.Data)
+	if err != nil {
+		return nil, err
+	}
+	return fromWire(w), nil
+}
@@ -0,0 +1,77 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCloudEvent(t *testing.T) {
+	at := time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC)
+	SetClock(fixedClock(at))
+	SetOrigin("svc", "host-1")
+	RegisterCode(0x2104, "STORAGE_NOT_FOUND")
+	err := New(Desc{Level: WARNING, Code: 0x2104, Text: "abc", Info: []string{"x"}, PublicText: "Not found."})
+	SetClock(nil)
+	SetOrigin("", "")
+	defer RegisterCode(0x2104, "")
+
+	data, e := ToCloudEvent(err, "/storage", "com.example.error")
+	if e != nil {
+		t.Fatalf(`ToCloudEvent(...) error %v`, e)
+	}
+	var ev map[string]interface{}
+	json.Unmarshal(data, &ev)
+	for k, v := range map[string]interface{}{
+		"specversion":     "1.0",
+		"source":          "/storage",
+		"type":            "com.example.error.STORAGE_NOT_FOUND",
+		"subject":         "svc/host-1",
+		"time":            "2016-01-02T03:04:05Z",
+		"datacontenttype": "application/json",
+	} {
+		if ev[k] != v {
+			t.Errorf(`event %q = %v, want %v`, k, ev[k], v)
+		}
+	}
+
+	got, e := FromCloudEvent(data)
+	if e != nil {
+		t.Fatalf(`FromCloudEvent(...) error %v`, e)
+	}
+	if !reflect.DeepEqual(got, err) {
+		t.Errorf(`FromCloudEvent(ToCloudEvent(err)) = %#v, want %#v`, got, err)
+	}
+
+	data, _ = ToCloudEvent(New(Desc{Code: 0x17}), "/x", "p")
+	json.Unmarshal(data, &ev)
+	if ev["type"] != "p.0x0017" {
+		t.Errorf(`event type = %v, want %q`, ev["type"], "p.0x0017")
+	}
+	data, _ = ToCloudEvent(New("abc"), "/x", "p")
+	json.Unmarshal(data, &ev)
+	if ev["type"] != "p.error" {
+		t.Errorf(`event type = %v, want %q`, ev["type"], "p.error")
+	}
+	if _, e = FromCloudEvent([]byte(`{"specversion":"0.3"}`)); e == nil {
+		t.Errorf(`FromCloudEvent(v0.3) did not fail`)
+	}
+}
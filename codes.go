@@ -0,0 +1,167 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"strconv"
+	"sync"
+)
+
+// Styles in which error codes are rendered, see SetCodeStyle.
+const (
+	// CODES_HEX renders codes as hexadecimal numbers only.
+	CODES_HEX int8 = iota
+	// CODES_SYMBOLIC renders registered codes by name, followed by the number
+	// in parentheses, e.g. "STORAGE_NOT_FOUND (0x2104)".
+	CODES_SYMBOLIC
+	// CODES_STRICT renders codes like CODES_SYMBOLIC, and notifies observers
+	// with EVENT_UNREGISTERED_CODE the first time an unregistered code is
+	// rendered.
+	CODES_STRICT
+)
+
+var codes struct {
+	sync.RWMutex
+	names      map[int]string
+	style      int8
+	rendered   map[int]string // renderings of the registered codes in style
+	unreported map[int]bool   // unregistered codes notified in CODES_STRICT
+}
+
+// RegisterCode registers name as the symbolic name of code. An empty name
+// removes the registration.
+func RegisterCode(code int, name string) {
+	codes.Lock()
+	if name == "" {
+		delete(codes.names, code)
+	} else {
+		if codes.names == nil {
+			codes.names = make(map[int]string)
+		}
+		codes.names[code] = name
+	}
+	delete(codes.rendered, code)
+	delete(codes.unreported, code)
+	codes.Unlock()
+}
+
+// CodeName returns the name registered for code, or an empty string.
+func CodeName(code int) string {
+	codes.RLock()
+	defer codes.RUnlock()
+	return codes.names[code]
+}
+
+// CodeByName returns the code registered with the given name, if any.
+func CodeByName(name string) (int, bool) {
+	codes.RLock()
+	defer codes.RUnlock()
+	for code, n := range codes.names {
+		if n == name {
+			return code, true
+		}
+	}
+	return 0, false
+}
+
+// Domain returns the domain of the code of err, set by the Factory creating
+// it: a reverse-DNS name identifying the producer of the error, such as
+// "com.example.billing", for consumers of errors from several producers to
+// tell numerically identical codes apart. It is serialized along with the
+// code.
+func Domain(err error) string {
+	if em := messageOf(err); em != nil {
+		return em.domain
+	}
+	return ""
+}
+
+// SetCodeStyle sets the style used to render error codes, e.g. by Error, for
+// the errors created by factories without a style of their own, see
+// Factory.CodeStyle.
+func SetCodeStyle(style int8) {
+	if style >= CODES_HEX && style <= CODES_STRICT {
+		codes.Lock()
+		codes.style, codes.rendered, codes.unreported = style, nil, nil
+		codes.Unlock()
+	}
+}
+
+// formatCode returns the rendering of the code of em. The renderings of
+// registered codes in the global style are cached. Unregistered codes are
+// notified in CODES_STRICT style once each; as they are marked before the
+// observers are called, the observers may render the error.
+func formatCode(em *errorMessage) string {
+	codes.RLock()
+	name, style := codes.names[em.code], codes.style
+	s, cached := codes.rendered[em.code]
+	if em.codeStyle != nil && *em.codeStyle != style {
+		style, cached = *em.codeStyle, false
+	}
+	codes.RUnlock()
+	if cached {
+		return s
+	}
+	if name == "" {
+		if style == CODES_STRICT {
+			codes.Lock()
+			first := !codes.unreported[em.code]
+			if first {
+				if codes.unreported == nil {
+					codes.unreported = make(map[int]bool)
+				}
+				codes.unreported[em.code] = true
+			}
+			codes.Unlock()
+			if first {
+				notify(EVENT_UNREGISTERED_CODE, em)
+			}
+		}
+		return hexCode(em.code)
+	}
+	if s = hexCode(em.code); style != CODES_HEX {
+		s = name + " (" + s + ")"
+	}
+	if em.codeStyle != nil {
+		return s
+	}
+	codes.Lock()
+	if codes.names[em.code] == name && codes.style == style {
+		if codes.rendered == nil {
+			codes.rendered = make(map[int]string)
+		}
+		codes.rendered[em.code] = s
+	}
+	codes.Unlock()
+	return s
+}
+
+// hexCode returns code in hexadecimal with at least four digits, as
+// formatted by fmt with "0x%04x".
+func hexCode(code int) string {
+	if code < 0 {
+		return "0x-" + zeroPad(strconv.FormatUint(uint64(-int64(code)), 16), 3)
+	}
+	return "0x" + zeroPad(strconv.FormatInt(int64(code), 16), 4)
+}
+
+// zeroPad returns s preceded by as many zeros as needed to have width
+// characters.
+func zeroPad(s string, width int) string {
+	for len(s) < width {
+		s = "0" + s
+	}
+	return s
+}
@@ -0,0 +1,78 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import "sort"
+
+// Errors is a collection of errors, e.g. gathered from a batch operation,
+// with helpers for reporting on it.
+type Errors []Error
+
+// Summary holds the number of errors in a collection, per level and per code.
+type Summary struct {
+	Total   int
+	ByLevel map[Level]int
+	ByCode  map[int]int
+}
+
+// Filter returns the errors in es for which pred returns true.
+func (es Errors) Filter(pred func(Error) bool) Errors {
+	var res Errors
+	for _, e := range es {
+		if pred(e) {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
+// GroupBy returns the errors in es grouped by the key returned by keyFn, in
+// their original order within each group.
+func (es Errors) GroupBy(keyFn func(Error) string) map[string][]Error {
+	res := make(map[string][]Error)
+	for _, e := range es {
+		k := keyFn(e)
+		res[k] = append(res[k], e)
+	}
+	return res
+}
+
+// bySeverity sorts errors by level, most severe first.
+type bySeverity Errors
+
+func (es bySeverity) Len() int           { return len(es) }
+func (es bySeverity) Less(i, j int) bool { return es[i].Level() > es[j].Level() }
+func (es bySeverity) Swap(i, j int)      { es[i], es[j] = es[j], es[i] }
+
+// SortBySeverity sorts es in place, most severe first; errors of the same
+// level keep their relative order. It returns es.
+func (es Errors) SortBySeverity() Errors {
+	sort.Stable(bySeverity(es))
+	return es
+}
+
+// Summarize returns the number of errors in es per level and per code.
+func (es Errors) Summarize() Summary {
+	s := Summary{
+		Total:   len(es),
+		ByLevel: make(map[Level]int),
+		ByCode:  make(map[int]int),
+	}
+	for _, e := range es {
+		s.ByLevel[Level(e.Level())]++
+		s.ByCode[e.Code()]++
+	}
+	return s
+}
@@ -0,0 +1,44 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import "testing"
+
+func TestErrors(t *testing.T) {
+	es := Errors{
+		New(Desc{Level: WARNING, Code: ERR_TIMEOUT, Text: "a"}),
+		New(Desc{Code: ERR_NOT_FOUND, Text: "b"}),
+		New(Desc{Level: FATAL, Code: ERR_SYSTEM, Text: "c"}),
+		New(Desc{Level: WARNING, Code: ERR_TIMEOUT, Text: "d"}),
+	}
+	if got := es.Filter(func(e Error) bool { return e.Code() == ERR_TIMEOUT }); len(got) != 2 || got[1].Text() != "d" {
+		t.Errorf(`es.Filter(ERR_TIMEOUT) = %v`, got)
+	}
+	g := es.GroupBy(func(e Error) string { return Level(e.Level()).String() })
+	if len(g) != 3 || len(g["WARNING"]) != 2 || g["WARNING"][0].Text() != "a" {
+		t.Errorf(`es.GroupBy(level) = %v`, g)
+	}
+	s := es.Summarize()
+	if s.Total != 4 || s.ByLevel[LEVEL_WARNING] != 2 || s.ByCode[ERR_SYSTEM] != 1 {
+		t.Errorf(`es.Summarize() = %+v`, s)
+	}
+	got := ""
+	for _, e := range es.SortBySeverity() {
+		got += e.Text()
+	}
+	if got != "cbad" {
+		t.Errorf(`es.SortBySeverity() order = %q, want "cbad"`, got)
+	}
+}
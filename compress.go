@@ -0,0 +1,176 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+)
+
+// Compressor compresses serialized errors, see SetCompression. A Compressor
+// also implementing NewReader(io.Reader) (io.ReadCloser, error), as Gzip
+// does, is decoded as a stream, so that size limits on decompressed errors
+// are enforced before the whole content is in memory.
+type Compressor interface {
+	// Name identifies the compression algorithm in serialized errors.
+	Name() string
+	Compress(data []byte) ([]byte, error)
+	Decompress(data []byte) ([]byte, error)
+}
+
+// compressedPayload is the serialized form of a compressed error.
+type compressedPayload struct {
+	Algorithm string `json:"algorithm"`
+	Data      []byte `json:"data"`
+}
+
+var compression struct {
+	sync.RWMutex
+	use         Compressor
+	threshold   int
+	compressors map[string]Compressor
+}
+
+func init() {
+	compression.compressors = map[string]Compressor{"gzip": Gzip}
+}
+
+// Gzip is a Compressor using gzip; it is always available for decoding.
+var Gzip Compressor = gzipCompressor{}
+
+type gzipCompressor struct{}
+
+func (gzipCompressor) Name() string {
+	return "gzip"
+}
+
+func (gzipCompressor) Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gzipCompressor) Decompress(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+func (gzipCompressor) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+// streamDecompressor is implemented by the Compressors decoded as a stream.
+type streamDecompressor interface {
+	NewReader(r io.Reader) (io.ReadCloser, error)
+}
+
+// SetCompression sets the Compressor applied to serialized errors larger than
+// threshold bytes, e.g. ones with long stack traces, to fit message size
+// limits; nil disables compression (the default). Compressed errors are
+// marked with the name of the algorithm, and decompressed transparently when
+// decoded: other compressors than Gzip, e.g. zstd, must be set with
+// SetCompression or RegisterCompressor on the decoding side too.
+func SetCompression(c Compressor, threshold int) {
+	compression.Lock()
+	compression.use, compression.threshold = c, threshold
+	if c != nil {
+		compression.compressors[c.Name()] = c
+	}
+	compression.Unlock()
+}
+
+// RegisterCompressor makes c available for decoding serialized errors,
+// without using it for encoding.
+func RegisterCompressor(c Compressor) {
+	compression.Lock()
+	compression.compressors[c.Name()] = c
+	compression.Unlock()
+}
+
+// compress returns data as is, or wrapped in a compressed payload if larger
+// than the compression threshold.
+func compress(data []byte) ([]byte, error) {
+	compression.RLock()
+	c, threshold := compression.use, compression.threshold
+	compression.RUnlock()
+	if c == nil || len(data) <= threshold {
+		return data, nil
+	}
+	z, err := c.Compress(data)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(struct {
+		Compressed compressedPayload `json:"compressed"`
+	}{compressedPayload{c.Name(), z}})
+}
+
+// decompress returns the decompressed content of p. If budget is not nil, the
+// size of the content is deducted from it, and decompression fails as soon as
+// the budget is exceeded.
+func (p *compressedPayload) decompress(budget *int64) ([]byte, error) {
+	compression.RLock()
+	c := compression.compressors[p.Algorithm]
+	compression.RUnlock()
+	if c == nil {
+		return nil, fmt.Errorf("unsupported error compression %q", p.Algorithm)
+	}
+	if budget == nil {
+		return c.Decompress(p.Data)
+	}
+	var data []byte
+	if s, ok := c.(streamDecompressor); ok {
+		r, err := s.NewReader(bytes.NewReader(p.Data))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		if data, err = ioutil.ReadAll(io.LimitReader(r, *budget+1)); err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		if data, err = c.Decompress(p.Data); err != nil {
+			return nil, err
+		}
+	}
+	if int64(len(data)) > *budget {
+		return nil, errDecompressedSize
+	}
+	*budget -= int64(len(data))
+	return data, nil
+}
+
+// errDecompressedSize is returned when decompressed errors exceed their size
+// limit.
+var errDecompressedSize = fmt.Errorf("decompressed error exceeds the size limit")
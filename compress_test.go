@@ -0,0 +1,45 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCompression(t *testing.T) {
+	defer SetCompression(nil, 0)
+	SetCompression(Gzip, 200)
+	big := New(Desc{Code: 1, Text: "abc", Info: []string{strings.Repeat("frame\n", 100)}})
+	data, err := encodeWire(big)
+	if err != nil || !bytes.HasPrefix(data, []byte(`{"compressed":{"algorithm":"gzip",`)) || len(data) > 300 {
+		t.Errorf(`encodeWire(big) = %s, %v`, data, err)
+	}
+	w, err := decodeWire(data)
+	if err != nil || w.Text != "abc" || len(w.Info) != 1 || len(w.Info[0]) != 600 {
+		t.Errorf(`decodeWire(compressed) = %+v, %v`, w, err)
+	}
+
+	if data, _ = encodeWire(New("abc")); !bytes.HasPrefix(data, []byte(`{"schema_version"`)) {
+		t.Errorf(`encodeWire(small) = %s, want uncompressed`, data)
+	}
+	if _, err = decodeWire([]byte(`{"compressed":{"algorithm":"lz4","data":""}}`)); err == nil {
+		t.Errorf(`decodeWire(lz4) did not fail`)
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Verbosity levels of Log, see SetVerbosity.
+const (
+	// VERBOSITY_TEXT logs the text and code of errors only.
+	VERBOSITY_TEXT = iota
+	// VERBOSITY_INFO also logs their Info, one element per line.
+	VERBOSITY_INFO
+	// VERBOSITY_FIELDS also logs their fields, one per line.
+	VERBOSITY_FIELDS
+)
+
+// Policies for logging FATAL errors, see SetFatalPolicy.
+const (
+	// FATAL_EXIT logs FATAL errors with the Fatal function of the logger,
+	// which normally exits the program.
+	FATAL_EXIT int8 = iota
+	// FATAL_PANIC logs them with its Panic function.
+	FATAL_PANIC
+	// FATAL_LOG logs them with its Print function.
+	FATAL_LOG
+	// FATAL_CONFIRM logs them with its Print function, and starts a
+	// confirmation phase ending with a call to its Fatal function, see
+	// SetFatalConfirmation.
+	FATAL_CONFIRM
+)
+
+var config = struct {
+	sync.RWMutex
+	logLevel    Level
+	noStacks    bool
+	verbosity   int
+	fatalPolicy int8
+	goroutines  bool
+	noCollapse  bool
+}{
+	logLevel: LEVEL_WARNING,
+}
+
+// SetLogLevel sets the minimum level of the errors actually logged by Log;
+// less severe errors are skipped.
+func SetLogLevel(l Level) {
+	if l.Valid() {
+		config.Lock()
+		config.logLevel = l
+		config.Unlock()
+	}
+}
+
+// SetStacks sets whether "debug.stack" elements of Info are replaced by stack
+// traces (the default), or dropped.
+func SetStacks(on bool) {
+	config.Lock()
+	config.noStacks = !on
+	config.Unlock()
+}
+
+// SetVerbosity sets how much detail Log includes, from VERBOSITY_TEXT (the
+// default) to VERBOSITY_FIELDS.
+func SetVerbosity(v int) {
+	if v >= VERBOSITY_TEXT && v <= VERBOSITY_FIELDS {
+		config.Lock()
+		config.verbosity = v
+		config.Unlock()
+	}
+}
+
+// SetFatalPolicy sets how Log handles FATAL errors, from FATAL_EXIT (the
+// default) to FATAL_CONFIRM.
+func SetFatalPolicy(p int8) {
+	if p >= FATAL_EXIT && p <= FATAL_CONFIRM {
+		config.Lock()
+		config.fatalPolicy = p
+		config.Unlock()
+	}
+}
+
+// SetGoroutineCapture sets whether the ID of the goroutine creating an error
+// is recorded on it, as well as, by NewCtx, the pprof labels of its context.
+// It is off by default, as finding the goroutine ID is relatively costly.
+func SetGoroutineCapture(on bool) {
+	config.Lock()
+	config.goroutines = on
+	config.Unlock()
+}
+
+// SetFrameCollapse sets whether Log replaces each run of dependency frames
+// in stack traces by a line stating their number (the default), see
+// SetAppPackages.
+func SetFrameCollapse(on bool) {
+	config.Lock()
+	config.noCollapse = !on
+	config.Unlock()
+}
+
+func fatalPolicy() int8 {
+	config.RLock()
+	defer config.RUnlock()
+	return config.fatalPolicy
+}
+
+func goroutinesEnabled() bool {
+	config.RLock()
+	defer config.RUnlock()
+	return config.goroutines
+}
+
+func stacksEnabled() bool {
+	if o := currentOverrides(); o != nil && o.Stacks != nil {
+		return *o.Stacks
+	}
+	config.RLock()
+	defer config.RUnlock()
+	return !config.noStacks
+}
+
+// logDetails returns what Log passes to the logger for em, according to the
+// configuration, and whether it should be logged at all.
+func logDetails(em *errorMessage) (interface{}, bool) {
+	config.RLock()
+	logLevel, verbosity, collapse := config.logLevel, config.verbosity, !config.noCollapse
+	config.RUnlock()
+	if o := currentOverrides(); o != nil {
+		if o.LogLevel != nil {
+			logLevel = *o.LogLevel
+		}
+		if o.Verbosity != nil {
+			verbosity = *o.Verbosity
+		}
+	}
+	if Level(em.level).Less(logLevel) {
+		return nil, false
+	}
+	if verbosity == VERBOSITY_TEXT || (len(em.fields) == 0 && len(em.branches) == 0) {
+		return em, true
+	}
+	lines := em.detailLines([]string{em.Error()}, collapse, verbosity >= VERBOSITY_FIELDS)
+	return strings.Join(lines, "\n"), true
+}
+
+// detailLines appends to lines the info of em, with stack traces collapsed if
+// collapse is set, its cause tree and, if fields is set, its fields.
+func (em *errorMessage) detailLines(lines []string, collapse, fields bool) []string {
+	for _, s := range em.infoList() {
+		if collapse && strings.HasPrefix(s, "goroutine ") {
+			s = collapseFrames(s)
+		}
+		lines = append(lines, "\t"+strings.Replace(s, "\n", "\n\t", -1))
+	}
+	lines = em.treeLines(lines, "\t")
+	if fields {
+		keys := make([]string, 0, len(em.fields))
+		for k := range em.fields {
+			if !isInfoKey(k) {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			lines = append(lines, "\t"+k+"="+sprint(em.fields[k]))
+		}
+	}
+	return lines
+}
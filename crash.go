@@ -0,0 +1,144 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.12 && !errors_minimal
+// +build go1.12,!errors_minimal
+
+package errors
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+// fieldCrashReport is the field key recording the path of the crash report
+// written for an error.
+const fieldCrashReport = "crash.report"
+
+// secretMarkers are the substrings of environment variable names whose
+// values are always redacted from crash reports.
+var secretMarkers = []string{"KEY", "SECRET", "TOKEN", "PASSWORD", "CREDENTIAL"}
+
+// CrashOptions configures the crash reports written by Log, see
+// SetCrashReports.
+type CrashOptions struct {
+	// Dir is the directory reports are written to; empty disables them.
+	Dir string
+	// Level is the minimum level of the errors reported (default PANIC).
+	Level Level
+	// Env lists the environment variables included in reports. The values of
+	// those whose names contain KEY, SECRET, TOKEN, PASSWORD or CREDENTIAL
+	// are redacted.
+	Env []string
+	// Redact lists additional variables of Env whose values are redacted.
+	Redact []string
+}
+
+var crash struct {
+	sync.RWMutex
+	opts CrashOptions
+}
+
+// SetCrashReports sets whether, and how, Log writes a crash report before
+// logging PANIC and FATAL errors, mimicking native crash reporters: the
+// error, the stacks of all goroutines, memory statistics, build information,
+// the diagnostics of Dump (including the recent errors, see
+// SetRecentErrors), and the selected environment variables. The path of the
+// report is recorded on the error as the field "crash.report".
+func SetCrashReports(opts CrashOptions) {
+	if !opts.Level.Valid() {
+		opts.Level = LEVEL_PANIC
+	}
+	crash.Lock()
+	crash.opts = opts
+	crash.Unlock()
+}
+
+// reportCrash writes a crash report for em, if enabled for its level.
+func reportCrash(em *errorMessage) {
+	crash.RLock()
+	opts := crash.opts
+	crash.RUnlock()
+	if opts.Dir == "" || Level(em.level).Less(opts.Level) {
+		return
+	}
+	t := now()
+	path := filepath.Join(opts.Dir, fmt.Sprintf("crash-%s-%d.txt", t.UTC().Format("20060102T150405.000000000"), os.Getpid()))
+	if ioutil.WriteFile(path, crashReport(em, opts), 0600) == nil {
+		em.setField(fieldCrashReport, path)
+	}
+}
+
+// crashReport returns the content of the crash report for em.
+func crashReport(em *errorMessage, opts CrashOptions) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "error: %s %s\n", Level(em.level), strings.Replace(FormatTree(em), "\n", "\n  ", -1))
+	for _, s := range em.infoList() {
+		fmt.Fprintf(&b, "  %s\n", strings.Replace(s, "\n", "\n  ", -1))
+	}
+
+	fmt.Fprintf(&b, "\nbuild: go=%s os=%s arch=%s", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		fmt.Fprintf(&b, " main=%s@%s", bi.Main.Path, bi.Main.Version)
+	}
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	fmt.Fprintf(&b, "\nmemstats: alloc=%d total_alloc=%d sys=%d heap_objects=%d num_gc=%d goroutines=%d\n",
+		ms.Alloc, ms.TotalAlloc, ms.Sys, ms.HeapObjects, ms.NumGC, runtime.NumGoroutine())
+
+	redact := make(map[string]bool, len(opts.Redact))
+	for _, name := range opts.Redact {
+		redact[name] = true
+	}
+	b.WriteString("environment:\n")
+	for _, name := range opts.Env {
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		upper := strings.ToUpper(name)
+		for _, m := range secretMarkers {
+			if strings.Contains(upper, m) {
+				redact[name] = true
+			}
+		}
+		if redact[name] {
+			v = "[REDACTED]"
+		}
+		fmt.Fprintf(&b, "  %s=%s\n", name, v)
+	}
+
+	b.WriteString("\n")
+	Dump(&b)
+
+	buf := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	b.WriteString("\ngoroutines:\n")
+	b.Write(buf)
+	return b.Bytes()
+}
@@ -0,0 +1,25 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !go1.12 || errors_minimal
+// +build !go1.12 errors_minimal
+
+package errors
+
+// fieldCrashReport is the field key recording the path of the crash report
+// written for an error.
+const fieldCrashReport = "crash.report"
+
+// reportCrash does nothing: crash reports are not available.
+func reportCrash(em *errorMessage) {}
@@ -0,0 +1,62 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.12 && !errors_minimal
+// +build go1.12,!errors_minimal
+
+package errors
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCrashReports(t *testing.T) {
+	dir, err := ioutil.TempDir("", "errors")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer SetCrashReports(CrashOptions{})
+	SetCrashReports(CrashOptions{Dir: dir, Env: []string{"ERRORS_TEST_REGION", "ERRORS_TEST_API_KEY", "ERRORS_TEST_DSN"}, Redact: []string{"ERRORS_TEST_DSN"}})
+	os.Setenv("ERRORS_TEST_REGION", "eu-west-1")
+	os.Setenv("ERRORS_TEST_API_KEY", "k3y")
+	os.Setenv("ERRORS_TEST_DSN", "postgres://u:p@db")
+	defer os.Unsetenv("ERRORS_TEST_REGION")
+	defer os.Unsetenv("ERRORS_TEST_API_KEY")
+	defer os.Unsetenv("ERRORS_TEST_DSN")
+
+	New(Desc{Level: ERROR, Text: "not severe"}).Log(&mockLogger{})
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		t.Errorf(`ERROR error wrote %d reports`, len(files))
+	}
+
+	e := New(Desc{Level: PANIC, Text: "out of invariants"})
+	e.Log(&mockLogger{})
+	_, path, _ := FindField(e, func(k string, _ interface{}) bool { return k == fieldCrashReport })
+	p, _ := path.(string)
+	b, err := ioutil.ReadFile(p)
+	if err != nil {
+		t.Fatalf(`reading crash report %v: %v`, path, err)
+	}
+	report := string(b)
+	for _, want := range []string{"error: PANIC out of invariants", "memstats: ", "ERRORS_TEST_REGION=eu-west-1",
+		"ERRORS_TEST_API_KEY=[REDACTED]", "ERRORS_TEST_DSN=[REDACTED]", "errors diagnostics at", "goroutines:\ngoroutine "} {
+		if !strings.Contains(report, want) {
+			t.Errorf(`crash report lacks %q:\n%s`, want, report)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// Field keys used to record the details of decoding errors.
+const (
+	fieldDecodeFormat     = "decode.format"
+	fieldDecodeOffset     = "decode.offset"
+	fieldDecodeLine       = "decode.line"
+	fieldDecodeColumn     = "decode.column"
+	fieldDecodeField      = "decode.field"
+	fieldDecodeValue      = "decode.value"
+	fieldDecodeExpected   = "decode.expected"
+	fieldDecodeViolations = "decode.violations"
+)
+
+// WrapDecode returns an Error with the code ERR_DECODE describing err, a
+// failure to decode input (a []byte or a string, or nil if unavailable). The
+// details provided by the errors of encoding/json, encoding/csv and
+// gopkg.in/yaml are recorded as the fields "decode.format", "decode.offset",
+// "decode.line", "decode.column" (lines and columns counting from 1, derived
+// from the offset and input if needed), "decode.field", "decode.value" and
+// "decode.expected", as applicable, from the first of them in the chain of
+// err. The Error wraps err, so that errors.As can find these errors. It
+// returns nil if err is nil.
+func WrapDecode(err error, input interface{}) Error {
+	if err == nil {
+		return nil
+	}
+	em := newMessage(err.Error())
+	em.code = ERR_DECODE
+	em.cause = err
+	for c := err; c != nil && !classifyDecode(em, c, input); {
+		u, ok := c.(interface {
+			Unwrap() error
+		})
+		if !ok {
+			break
+		}
+		c = u.Unwrap()
+	}
+	return Default().finish(em)
+}
+
+// classifyDecode records on em the details of err if it is a decoding error
+// of a known package, and reports whether it is.
+func classifyDecode(em *errorMessage, err error, input interface{}) bool {
+	switch e := err.(type) {
+	case *json.SyntaxError:
+		em.setField(fieldDecodeFormat, "json")
+		setDecodeOffset(em, e.Offset, input)
+	case *json.UnmarshalTypeError:
+		em.setField(fieldDecodeFormat, "json")
+		setDecodeOffset(em, e.Offset, input)
+		if e.Field != "" {
+			em.setField(fieldDecodeField, e.Field)
+		}
+		em.setField(fieldDecodeValue, e.Value)
+		em.setField(fieldDecodeExpected, e.Type.String())
+	case *csv.ParseError:
+		em.setField(fieldDecodeFormat, "csv")
+		em.setField(fieldDecodeLine, e.Line)
+		em.setField(fieldDecodeColumn, e.Column)
+	default:
+		// gopkg.in/yaml is not a dependency: its TypeError, listing one
+		// problem per line, is recognized by name and shape.
+		v := reflect.Indirect(reflect.ValueOf(err))
+		t := v.Type()
+		if t.Kind() != reflect.Struct || t.Name() != "TypeError" || !strings.HasPrefix(t.PkgPath(), "gopkg.in/yaml.") {
+			return false
+		}
+		f := v.FieldByName("Errors")
+		if !f.IsValid() || f.Type() != reflect.TypeOf([]string(nil)) {
+			return false
+		}
+		em.setField(fieldDecodeFormat, "yaml")
+		em.appendInfo(f.Interface().([]string)...)
+	}
+	return true
+}
+
+// setDecodeOffset records on em the offset in input a decoding error occurred
+// at, and the corresponding line and column if input is available.
+func setDecodeOffset(em *errorMessage, offset int64, input interface{}) {
+	em.setField(fieldDecodeOffset, offset)
+	var data []byte
+	switch in := input.(type) {
+	case []byte:
+		data = in
+	case string:
+		data = []byte(in)
+	default:
+		return
+	}
+	if offset < 0 || offset > int64(len(data)) {
+		return
+	}
+	before := data[:offset]
+	em.setField(fieldDecodeLine, bytes.Count(before, []byte("\n"))+1)
+	em.setField(fieldDecodeColumn, len(before)-bytes.LastIndexByte(before, '\n'))
+}
@@ -0,0 +1,68 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// yamlTypeError mimics the shape of yaml.TypeError, for tests only.
+type yamlTypeError struct {
+	Errors []string
+}
+
+func (e *yamlTypeError) Error() string {
+	return "yaml: unmarshal errors:\n  " + strings.Join(e.Errors, "\n  ")
+}
+
+func TestWrapDecode(t *testing.T) {
+	field := func(err Error, key string) interface{} {
+		return err.(*errorMessage).field(key)
+	}
+	input := "{\n  \"a\": 1,\n  \"b\": x\n}"
+	var v struct{ A string }
+	err := WrapDecode(json.Unmarshal([]byte(input), &v), input)
+	if err.Code() != ERR_DECODE || field(err, fieldDecodeLine) != 3 || field(err, fieldDecodeColumn) != 9 {
+		t.Errorf(`WrapDecode(SyntaxError) = %q, fields %v`, err, err.(*errorMessage).fields)
+	}
+	syntax := json.Unmarshal([]byte(input), &v)
+	if c := err.(interface{ Unwrap() error }).Unwrap(); c == nil || c.Error() != syntax.Error() {
+		t.Errorf(`WrapDecode(SyntaxError) wraps %v`, c)
+	}
+	if err = WrapDecode(plainWrapper{syntax}, input); field(err, fieldDecodeFormat) != "json" || field(err, fieldDecodeLine) != 3 {
+		t.Errorf(`WrapDecode(wrapped SyntaxError) fields %v`, err.(*errorMessage).fields)
+	}
+	err = WrapDecode(json.Unmarshal([]byte(`{"A": 1}`), &v), nil)
+	if field(err, fieldDecodeField) != "A" || field(err, fieldDecodeExpected) != "string" || field(err, fieldDecodeLine) != nil {
+		t.Errorf(`WrapDecode(UnmarshalTypeError) fields %v`, err.(*errorMessage).fields)
+	}
+	_, e := csv.NewReader(strings.NewReader("a,b\nc,\"d\n")).ReadAll()
+	err = WrapDecode(e, nil)
+	if field(err, fieldDecodeFormat) != "csv" || field(err, fieldDecodeLine) != 2 {
+		t.Errorf(`WrapDecode(ParseError) fields %v`, err.(*errorMessage).fields)
+	}
+	if err = WrapDecode(&yamlTypeError{[]string{"line 2: cannot unmarshal"}}, nil); field(err, fieldDecodeFormat) != nil {
+		t.Errorf(`WrapDecode(non-yaml TypeError) fields %v`, err.(*errorMessage).fields)
+	}
+	if WrapDecode(nil, nil) != nil {
+		t.Errorf(`WrapDecode(nil, nil) != nil`)
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// recent holds the latest errors logged by Log, in a ring buffer.
+var recent struct {
+	sync.Mutex
+	ring []Error
+	next int
+	full bool
+}
+
+// logged counts the errors logged by Log, per level.
+var logged [maxLevel + 1]uint64
+
+// SetRecentErrors sets the number of errors logged by Log which are kept for
+// Recent and Dump; zero, the default, keeps none. The errors already kept are
+// discarded.
+func SetRecentErrors(n int) {
+	if n < 0 {
+		n = 0
+	}
+	recent.Lock()
+	recent.ring, recent.next, recent.full = make([]Error, n), 0, false
+	recent.Unlock()
+}
+
+// Recent returns the latest errors logged by Log, oldest first, see
+// SetRecentErrors.
+func Recent() Errors {
+	recent.Lock()
+	defer recent.Unlock()
+	var es Errors
+	if recent.full {
+		es = append(es, recent.ring[recent.next:]...)
+	}
+	return append(es, recent.ring[:recent.next]...)
+}
+
+// recordLogged counts err as logged, and keeps it among the recent errors.
+func recordLogged(err Error) {
+	if l := err.Level(); l >= minLevel && l <= maxLevel {
+		atomic.AddUint64(&logged[l], 1)
+	}
+	recent.Lock()
+	if len(recent.ring) > 0 {
+		recent.ring[recent.next] = err
+		if recent.next++; recent.next == len(recent.ring) {
+			recent.next, recent.full = 0, true
+		}
+	}
+	recent.Unlock()
+}
+
+// Dump writes to w a diagnostic report: the configuration of the package,
+// its counters, and the recent errors, see SetRecentErrors.
+func Dump(w io.Writer) error {
+	config.RLock()
+	cfg := fmt.Sprintf("config: level=%s verbosity=%d stacks=%t fatal_policy=%d goroutines=%t",
+		config.logLevel, config.verbosity, !config.noStacks, config.fatalPolicy, config.goroutines)
+	config.RUnlock()
+	codes.RLock()
+	cfg += fmt.Sprintf(" code_style=%d registered_codes=%d", codes.style, len(codes.names))
+	codes.RUnlock()
+
+	counters := "counters: logged"
+	for l := minLevel; l <= maxLevel; l++ {
+		counters += fmt.Sprintf(" %s=%d", levelName(l), atomic.LoadUint64(&logged[l]))
+	}
+	classified, diverged := ShadowStats()
+	counters += fmt.Sprintf(" shadow_classified=%d shadow_diverged=%d", classified, diverged)
+
+	es := Recent()
+	lines := []string{
+		"errors diagnostics at " + now().Format(time.RFC3339Nano),
+		cfg,
+		counters,
+		fmt.Sprintf("recent errors: %d", len(es)),
+	}
+	for _, e := range es {
+		lines = append(lines, "  "+Time(e).Format(time.RFC3339Nano)+" "+Level(e.Level()).String()+" "+
+			strings.Replace(FormatTree(e), "\n", "\n    ", -1))
+	}
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
+
+// DumpOnSignal calls Dump(w) whenever the process receives one of sigs, to
+// get diagnostics from hosts where sending signals is the only access
+// available. Without sigs, it listens to SIGUSR1, where it exists; it never
+// listens to all signals. SIGQUIT, which makes the runtime exit with a dump of
+// all goroutines, is only listened to if requested, e.g. with
+// DumpOnSignal(w, syscall.SIGUSR1, syscall.SIGQUIT). The returned function
+// stops it.
+func DumpOnSignal(w io.Writer, sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = dumpSignals
+	}
+	if len(sigs) == 0 {
+		return func() {}
+	}
+	c := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(c, sigs...)
+	go func() {
+		for {
+			select {
+			case <-c:
+				Dump(w)
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(c)
+			close(done)
+		})
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDump(t *testing.T) {
+	defer SetRecentErrors(0)
+	defer SetClock(nil)
+	SetClock(fixedClock(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)))
+	SetRecentErrors(2)
+	log := &mockLogger{}
+	for _, s := range []string{"a", "b", "c"} {
+		New(s).Log(log)
+	}
+	if es := Recent(); len(es) != 2 || es[0].Text() != "b" || es[1].Text() != "c" {
+		t.Errorf(`Recent() = %v, want [b c]`, es)
+	}
+
+	var buf bytes.Buffer
+	if err := Dump(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"errors diagnostics at 2020-01-02T03:04:05Z\n",
+		"config: level=WARNING verbosity=0 stacks=true",
+		"counters: logged WARNING=",
+		"recent errors: 2\n  2020-01-02T03:04:05Z ERROR b\n  2020-01-02T03:04:05Z ERROR c\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf(`Dump(w) wrote %q, missing %q`, out, want)
+		}
+	}
+}
+
+// chanWriter sends every write to a channel.
+type chanWriter chan string
+
+func (w chanWriter) Write(p []byte) (int, error) {
+	w <- string(p)
+	return len(p), nil
+}
+
+func TestDumpOnSignal(t *testing.T) {
+	if len(dumpSignals) == 0 {
+		t.Skip("no default dump signals on this platform")
+	}
+	for _, sig := range dumpSignals {
+		if sig.String() == "quit" {
+			t.Errorf(`DumpOnSignal listens to SIGQUIT by default`)
+		}
+	}
+	w := make(chanWriter, 1)
+	stop := DumpOnSignal(w)
+	defer stop()
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Signal(dumpSignals[0]); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case out := <-w:
+		if !strings.HasPrefix(out, "errors diagnostics at ") {
+			t.Errorf(`DumpOnSignal(w) wrote %q`, out)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf(`no dump on %v`, dumpSignals[0])
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// fieldDiffPrevious is the field key holding the changes since the previous
+// occurrence of an error, see SetOccurrenceDiff.
+const fieldDiffPrevious = "diff.previous"
+
+// maxDiffFingerprints bounds the number of fingerprints whose last occurrence
+// is kept for SetOccurrenceDiff.
+const maxDiffFingerprints = 1024
+
+// occurrence is the content of an error compared between occurrences.
+type occurrence struct {
+	level  int8
+	public string
+	fields map[string]string
+}
+
+var occurrences struct {
+	sync.Mutex
+	on     bool
+	ignore map[string]bool
+	last   map[string]*occurrence
+}
+
+// volatileFields are the keys ignored by SetOccurrenceDiff, as they differ
+// between any two occurrences.
+var volatileFields = []string{
+	fieldTraceID, fieldSpanID, fieldGoroutineID, fieldPprofLabels,
+	fieldOriginInstance, fieldReference, fieldCrashReport, fieldWrapAttempts,
+	fieldEscalated, fieldIOElapsed, fieldContextInFlight, fieldDiffPrevious,
+}
+
+// SetOccurrenceDiff sets whether logged errors get the field "diff.previous"
+// listing what changed since the last logged error with the same
+// fingerprint, see Fingerprint: their level, public text and fields, such as
+// the status of an upstream response. Fields that differ every time, such as
+// trace IDs, and those with the ignore keys are not compared. It is off by
+// default; the previous occurrences are discarded.
+func SetOccurrenceDiff(on bool, ignore ...string) {
+	occurrences.Lock()
+	occurrences.on, occurrences.ignore, occurrences.last = on, nil, nil
+	if on {
+		occurrences.ignore = make(map[string]bool)
+		for _, k := range append(volatileFields, ignore...) {
+			occurrences.ignore[k] = true
+		}
+	}
+	occurrences.Unlock()
+}
+
+// PreviousDiff returns the changes since the previous occurrence of err, in
+// the form "level: ERROR -> FATAL; http.status: 502 -> 503; +key=value; -key",
+// or an empty string if there are none or they are not tracked.
+func PreviousDiff(err error) string {
+	if em := messageOf(err); em != nil {
+		diff, _ := em.field(fieldDiffPrevious).(string)
+		return diff
+	}
+	return ""
+}
+
+// diffOccurrence records em as the last occurrence of its fingerprint, and
+// sets its field "diff.previous" if the previous one differed.
+func diffOccurrence(em *errorMessage) {
+	occurrences.Lock()
+	defer occurrences.Unlock()
+	if !occurrences.on {
+		return
+	}
+	cur := &occurrence{level: em.level, public: em.public, fields: make(map[string]string)}
+	for k, v := range em.fields {
+		if !occurrences.ignore[k] && !isInfoKey(k) {
+			cur.fields[k] = fmt.Sprint(v)
+		}
+	}
+	fp := em.fingerprint()
+	prev := occurrences.last[fp]
+	if occurrences.last == nil {
+		occurrences.last = make(map[string]*occurrence)
+	}
+	if prev != nil || len(occurrences.last) < maxDiffFingerprints {
+		occurrences.last[fp] = cur
+	}
+	if prev == nil {
+		return
+	}
+	if diff := prev.diff(cur); diff != "" {
+		em.setField(fieldDiffPrevious, diff)
+	}
+}
+
+// diff returns the changes from o to cur.
+func (o *occurrence) diff(cur *occurrence) string {
+	var changes []string
+	if o.level != cur.level {
+		changes = append(changes, "level: "+levelName(o.level)+" -> "+levelName(cur.level))
+	}
+	if o.public != cur.public {
+		changes = append(changes, "public: "+strconv.Quote(o.public)+" -> "+strconv.Quote(cur.public))
+	}
+	keys := make([]string, 0, len(o.fields)+len(cur.fields))
+	for k := range o.fields {
+		keys = append(keys, k)
+	}
+	for k := range cur.fields {
+		if _, ok := o.fields[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		was, hadIt := o.fields[k]
+		is, hasIt := cur.fields[k]
+		switch {
+		case !hasIt:
+			changes = append(changes, "-"+k)
+		case !hadIt:
+			changes = append(changes, "+"+k+"="+is)
+		case was != is:
+			changes = append(changes, k+": "+was+" -> "+is)
+		}
+	}
+	return strings.Join(changes, "; ")
+}
@@ -0,0 +1,58 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import "testing"
+
+func TestOccurrenceDiff(t *testing.T) {
+	defer SetOccurrenceDiff(false)
+	SetOccurrenceDiff(true, "attempt")
+	log := &mockLogger{}
+	occur := func(status int, public string, extra ...interface{}) Error {
+		err := New(Desc{Code: 0x77, Text: "upstream failed", PublicText: public})
+		err.(*errorMessage).setField("http.status", status)
+		err.(*errorMessage).setField(fieldTraceID, "t"+string(rune('a'+status%26)))
+		err.(*errorMessage).setField("attempt", status)
+		for i := 0; i+1 < len(extra); i += 2 {
+			err.(*errorMessage).setField(extra[i].(string), extra[i+1])
+		}
+		return err.Log(log)
+	}
+	if err := occur(502, "try later"); PreviousDiff(err) != "" {
+		t.Errorf(`PreviousDiff(first) = %q`, PreviousDiff(err))
+	}
+	if err := occur(502, "try later"); PreviousDiff(err) != "" {
+		t.Errorf(`PreviousDiff(same) = %q`, PreviousDiff(err))
+	}
+	err := occur(503, "unavailable", "region", "eu")
+	if want := `public: "try later" -> "unavailable"; http.status: 502 -> 503; +region=eu`; PreviousDiff(err) != want {
+		t.Errorf(`PreviousDiff(err) = %q, want %q`, PreviousDiff(err), want)
+	}
+	err = occur(503, "unavailable")
+	if want := `-region`; PreviousDiff(err) != want {
+		t.Errorf(`PreviousDiff(err) = %q, want %q`, PreviousDiff(err), want)
+	}
+	if err := New("other").Log(log); PreviousDiff(err) != "" {
+		t.Errorf(`PreviousDiff(other) = %q`, PreviousDiff(err))
+	}
+
+	SetOccurrenceDiff(false)
+	if err := occur(500, "x"); PreviousDiff(err) != "" {
+		t.Errorf(`PreviousDiff(disabled) = %q`, PreviousDiff(err))
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// envPrefix is the prefix of the environment variables read by LoadEnv.
+const envPrefix = "ERRORS_"
+
+// envVars maps the environment variables read by LoadEnv to the functions
+// applying their values.
+var envVars = map[string]func(string) error{
+	"ERRORS_LEVEL_MIN": func(v string) error {
+		var l Level
+		if err := l.UnmarshalText([]byte(v)); err != nil {
+			return err
+		}
+		SetLogLevel(l)
+		return nil
+	},
+	"ERRORS_STACKS": func(v string) error {
+		on, err := strconv.ParseBool(v)
+		if err == nil {
+			SetStacks(on)
+		}
+		return err
+	},
+	"ERRORS_VERBOSITY": func(v string) error {
+		n, err := strconv.Atoi(v)
+		if err == nil && (n < VERBOSITY_TEXT || n > VERBOSITY_FIELDS) {
+			err = fmt.Errorf("out of range [%d, %d]", VERBOSITY_TEXT, VERBOSITY_FIELDS)
+		}
+		if err == nil {
+			SetVerbosity(n)
+		}
+		return err
+	},
+	"ERRORS_CODE_STYLE": func(v string) error {
+		for style, name := range []string{"hex", "symbolic", "strict"} {
+			if strings.EqualFold(v, name) {
+				SetCodeStyle(int8(style))
+				return nil
+			}
+		}
+		return fmt.Errorf("unknown code style %q", v)
+	},
+	"ERRORS_PROFILE": func(v string) error {
+		for profile, name := range []string{"development", "production", "test"} {
+			if strings.EqualFold(v, name) {
+				UseProfile(int8(profile) + DEVELOPMENT)
+				return nil
+			}
+		}
+		return fmt.Errorf("unknown profile %q", v)
+	},
+	"ERRORS_ORIGIN": func(v string) error {
+		service, instance := v, ""
+		if i := strings.IndexByte(v, '/'); i >= 0 {
+			service, instance = v[:i], v[i+1:]
+		}
+		SetOrigin(service, instance)
+		return nil
+	},
+}
+
+var envErr Error
+
+func init() {
+	envErr = LoadEnv()
+}
+
+// EnvVars returns the names of the environment variables read by LoadEnv:
+//   - ERRORS_LEVEL_MIN: the level name passed to SetLogLevel;
+//   - ERRORS_STACKS: a boolean passed to SetStacks;
+//   - ERRORS_VERBOSITY: the number passed to SetVerbosity;
+//   - ERRORS_CODE_STYLE: "hex", "symbolic" or "strict", see SetCodeStyle;
+//   - ERRORS_ORIGIN: "service/instance", passed to SetOrigin;
+//   - ERRORS_PROFILE: "development", "production" or "test", see UseProfile.
+//
+// ERRORS_PROFILE is applied first, so that the other variables can override
+// the settings of the profile.
+func EnvVars() []string {
+	names := make([]string, 0, len(envVars))
+	for name := range envVars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// profileFirst sorts "name=value" environment entries lexically, with
+// ERRORS_PROFILE first.
+type profileFirst []string
+
+func (env profileFirst) Len() int      { return len(env) }
+func (env profileFirst) Swap(i, j int) { env[i], env[j] = env[j], env[i] }
+func (env profileFirst) Less(i, j int) bool {
+	pi, pj := strings.HasPrefix(env[i], "ERRORS_PROFILE="), strings.HasPrefix(env[j], "ERRORS_PROFILE=")
+	if pi != pj {
+		return pi
+	}
+	return env[i] < env[j]
+}
+
+// LoadEnv configures the package from the environment variables listed by
+// EnvVars. It is called at initialization; the configuration functions can
+// be used afterwards to override it. The returned warning lists the invalid
+// values and the unknown variables with the ERRORS_ prefix, if any.
+func LoadEnv() Error {
+	var problems []string
+	env := os.Environ()
+	sort.Sort(profileFirst(env))
+	for _, kv := range env {
+		if !strings.HasPrefix(kv, envPrefix) {
+			continue
+		}
+		name, value := kv, ""
+		if i := strings.IndexByte(kv, '='); i >= 0 {
+			name, value = kv[:i], kv[i+1:]
+		}
+		apply, ok := envVars[name]
+		if !ok {
+			problems = append(problems, "unknown variable "+name)
+		} else if err := apply(value); err != nil {
+			problems = append(problems, fmt.Sprintf("invalid %s: %v", name, err))
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return New(Desc{
+		Level: WARNING,
+		Code:  ERR_INVALID,
+		Text:  "invalid environment configuration",
+		Info:  problems,
+	})
+}
+
+// EnvError returns the warning returned by LoadEnv at initialization, if any.
+func EnvError() Error {
+	return envErr
+}
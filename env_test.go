@@ -0,0 +1,76 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestLoadEnv(t *testing.T) {
+	if EnvError() != nil {
+		t.Skipf(`unexpected environment configuration: %q`, EnvError().Info())
+	}
+	defer SetLogLevel(LEVEL_WARNING)
+	defer SetCodeStyle(CODES_HEX)
+	defer SetOrigin("", "")
+	for k, v := range map[string]string{
+		"ERRORS_LEVEL_MIN":  "panic",
+		"ERRORS_CODE_STYLE": "Symbolic",
+		"ERRORS_ORIGIN":     "svc/host-1",
+		"ERRORS_VERBOSITY":  "3",
+		"ERRORS_STACK":      "0",
+	} {
+		os.Setenv(k, v)
+		defer os.Unsetenv(k)
+	}
+	err := LoadEnv()
+	if err == nil {
+		t.Fatalf(`LoadEnv() = nil, want a warning`)
+	}
+	want := []string{"unknown variable ERRORS_STACK", "invalid ERRORS_VERBOSITY: out of range [0, 2]"}
+	if fmt.Sprint(err.Info()) != fmt.Sprint(want) {
+		t.Errorf(`LoadEnv().Info() = %q, want %q`, err.Info(), want)
+	}
+	if config.logLevel != LEVEL_PANIC || codes.style != CODES_SYMBOLIC {
+		t.Errorf(`LoadEnv() did not apply the configuration`)
+	}
+	if s, i := Origin(New("abc")); s != "svc" || i != "host-1" {
+		t.Errorf(`Origin after LoadEnv() = %q, %q, want %q, %q`, s, i, "svc", "host-1")
+	}
+	if len(EnvVars()) != len(envVars) {
+		t.Errorf(`EnvVars() = %q`, EnvVars())
+	}
+}
+
+func TestLoadEnvProfile(t *testing.T) {
+	defer func() {
+		SetStacks(true)
+		SetVerbosity(VERBOSITY_TEXT)
+		SetCodeStyle(CODES_HEX)
+		SetFatalPolicy(FATAL_EXIT)
+	}()
+	os.Setenv("ERRORS_PROFILE", "Production")
+	os.Setenv("ERRORS_CODE_STYLE", "strict")
+	defer os.Unsetenv("ERRORS_PROFILE")
+	defer os.Unsetenv("ERRORS_CODE_STYLE")
+	if err := LoadEnv(); err != nil || !config.noStacks || codes.style != CODES_STRICT {
+		t.Errorf(`LoadEnv() = %v with stacks %t and code style %d`, err, !config.noStacks, codes.style)
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command errgen generates the constructors of the errors of a package from
+// its catalog, stored as JSON (see errors.Catalog), e.g.:
+//
+//	//go:generate errgen -pkg users -o errors_gen.go catalog.json
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/agext/errors"
+	"github.com/agext/errors/errgen"
+)
+
+func main() {
+	pkg := flag.String("pkg", os.Getenv("GOPACKAGE"), "name of the generated package")
+	out := flag.String("o", "errors_gen.go", "output file")
+	flag.Parse()
+	if flag.NArg() != 1 || *pkg == "" {
+		fmt.Fprintln(os.Stderr, "usage: errgen [-pkg name] [-o file] catalog.json")
+		os.Exit(2)
+	}
+	if err := run(*pkg, *out, flag.Arg(0)); err != nil {
+		fmt.Fprintln(os.Stderr, "errgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(pkg, out, in string) error {
+	data, err := ioutil.ReadFile(in)
+	if err != nil {
+		return err
+	}
+	var c errors.Catalog
+	if err = json.Unmarshal(data, &c); err != nil {
+		return err
+	}
+	var b bytes.Buffer
+	if err = errgen.Generate(&b, pkg, c); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(out, b.Bytes(), 0644)
+}
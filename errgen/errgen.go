@@ -0,0 +1,124 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package errgen generates the constructors of the errors of a package from
+// its catalog (see errors.Catalog), returning a sealed interface: since no
+// other code can implement it, every error of that type originates from the
+// catalog. The errcatalog analyzer of github.com/agext/errors/analyzer checks
+// that packages using generated constructors do not create other errors.
+package errgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/agext/errors"
+)
+
+// Marker is the comment starting generated files.
+const Marker = "// Code generated by errgen. DO NOT EDIT."
+
+// Generate writes to w the source of package pkg declaring, for every entry
+// of c:
+//   - a constant ERR_<NAME> holding its code, registered with its name;
+//   - a constructor New<Name>, returning an Error with the code, and the
+//     entry text as text and public text, followed by the info passed.
+//
+// Error is declared as a sealed interface, which only the constructors can
+// produce. Entry names must be ALL_CAPS identifiers, e.g. "NOT_FOUND".
+func Generate(w io.Writer, pkg string, c errors.Catalog) error {
+	codes := make([]int, 0, len(c.Codes))
+	for code, e := range c.Codes {
+		if !validName(e.Name) {
+			return fmt.Errorf("invalid name %q for code %#x", e.Name, code)
+		}
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%s\n\npackage %s\n\nimport \"github.com/agext/errors\"\n\n", Marker, pkg)
+	b.WriteString("// Codes of the errors of the catalog.\nconst (\n")
+	for _, code := range codes {
+		fmt.Fprintf(&b, "ERR_%s = %#x\n", c.Codes[code].Name, code)
+	}
+	b.WriteString(")\n\nfunc init() {\n")
+	for _, code := range codes {
+		fmt.Fprintf(&b, "errors.RegisterCode(ERR_%s, %q)\n", c.Codes[code].Name, c.Codes[code].Name)
+	}
+	b.WriteString(`}
+
+// Error is an error of the catalog; only the constructors below create them.
+type Error interface {
+	errors.Error
+	catalogError()
+}
+
+type catalogued struct {
+	errors.Sealed
+}
+
+func (catalogued) catalogError() {}
+`)
+	for _, code := range codes {
+		e := c.Codes[code]
+		fmt.Fprintf(&b, `
+// New%[1]s returns a new %[2]s error.
+func New%[1]s(info ...string) Error {
+	return catalogued{errors.Seal(errors.New(errors.Desc{
+		Code:       ERR_%[2]s,
+		Text:       %[3]q,
+		PublicText: %[3]q,
+		Info:       info,
+	}))}
+}
+`, camel(e.Name), e.Name, e.Text)
+	}
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+// validName reports whether name is an ALL_CAPS identifier.
+func validName(name string) bool {
+	if name == "" || name[0] < 'A' || name[0] > 'Z' {
+		return false
+	}
+	for _, c := range name {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') && c != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// camel converts an ALL_CAPS name to CamelCase, e.g. "NOT_FOUND" to
+// "NotFound".
+func camel(name string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(name, "_") {
+		if part != "" {
+			b.WriteString(part[:1] + strings.ToLower(part[1:]))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,66 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errgen
+
+import (
+	"bytes"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	"github.com/agext/errors"
+)
+
+func TestGenerate(t *testing.T) {
+	c := errors.Catalog{Codes: map[int]errors.CatalogEntry{
+		0x2104: {Name: "NOT_FOUND", Text: "No such user."},
+		0x2105: {Name: "QUOTA_EXCEEDED"},
+	}}
+	var b bytes.Buffer
+	if err := Generate(&b, "users", c); err != nil {
+		t.Fatal(err)
+	}
+	src := b.String()
+	for _, want := range []string{
+		Marker + "\n\npackage users\n",
+		"ERR_NOT_FOUND      = 0x2104\n",
+		"errors.RegisterCode(ERR_QUOTA_EXCEEDED, \"QUOTA_EXCEEDED\")",
+		"func NewNotFound(info ...string) Error {",
+		"func NewQuotaExceeded(info ...string) Error {",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf(`Generate(...) source lacks %q:\n%s`, want, src)
+		}
+	}
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "errors_gen.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err = conf.Check("users", fset, []*ast.File{f}, nil); err != nil {
+		t.Errorf(`generated source does not compile: %v`, err)
+	}
+
+	c.Codes[1] = errors.CatalogEntry{Name: "notCaps"}
+	if err := Generate(&b, "users", c); err == nil {
+		t.Errorf(`Generate(...) accepted the name "notCaps"`)
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !plan9
+// +build !plan9
+
+package errors
+
+import "syscall"
+
+// Field key used to record the system error number an error was created from.
+const fieldErrno = "errno"
+
+// errnoClass is the classification of a system error number.
+type errnoClass struct {
+	code      int
+	level     int8
+	retryable bool
+}
+
+var errnoClasses = map[syscall.Errno]errnoClass{
+	syscall.ENOENT:       {ERR_NOT_FOUND, ERROR, false},
+	syscall.ENOTDIR:      {ERR_NOT_FOUND, ERROR, false},
+	syscall.EEXIST:       {ERR_EXISTS, ERROR, false},
+	syscall.EACCES:       {ERR_PERMISSION, ERROR, false},
+	syscall.EPERM:        {ERR_PERMISSION, ERROR, false},
+	syscall.EINVAL:       {ERR_INVALID, ERROR, false},
+	syscall.EISDIR:       {ERR_INVALID, ERROR, false},
+	syscall.ENAMETOOLONG: {ERR_INVALID, ERROR, false},
+	syscall.ETIMEDOUT:    {ERR_TIMEOUT, WARNING, true},
+	syscall.EINTR:        {ERR_INTERRUPTED, WARNING, true},
+	syscall.EAGAIN:       {ERR_UNAVAILABLE, WARNING, true},
+	syscall.EBUSY:        {ERR_UNAVAILABLE, WARNING, true},
+	syscall.ECONNREFUSED: {ERR_UNAVAILABLE, ERROR, true},
+	syscall.ECONNRESET:   {ERR_UNAVAILABLE, ERROR, true},
+	syscall.ECONNABORTED: {ERR_UNAVAILABLE, ERROR, true},
+	syscall.EHOSTUNREACH: {ERR_UNAVAILABLE, ERROR, true},
+	syscall.ENETUNREACH:  {ERR_UNAVAILABLE, ERROR, true},
+	syscall.ENETDOWN:     {ERR_UNAVAILABLE, ERROR, true},
+	syscall.EPIPE:        {ERR_UNAVAILABLE, ERROR, false},
+	syscall.EMFILE:       {ERR_RESOURCE, ERROR, true},
+	syscall.ENFILE:       {ERR_RESOURCE, ERROR, true},
+	syscall.ENOMEM:       {ERR_RESOURCE, ERROR, true},
+	syscall.ENOSPC:       {ERR_RESOURCE, ERROR, false},
+}
+
+// FromErrno returns an Error describing the system error number errno, with
+// the code, level and retryability given by a curated mapping of common cases.
+// Other numbers get the code ERR_SYSTEM and the level ERROR.
+func FromErrno(errno syscall.Errno) Error {
+	class, ok := errnoClasses[errno]
+	if !ok {
+		class = errnoClass{ERR_SYSTEM, ERROR, false}
+	}
+	em := newMessage(errno.Error())
+	em.code, em.level = class.code, class.level
+	em.setField(fieldErrno, errno)
+	em.setField(fieldRetryable, class.retryable)
+	return Default().finish(em)
+}
+
+// Errno returns the system error number err was created from by FromErrno, or
+// the first syscall.Errno found in its chain (e.g. inside an *os.PathError).
+func Errno(err error) (syscall.Errno, bool) {
+	for err != nil {
+		switch e := err.(type) {
+		case syscall.Errno:
+			return e, true
+		case *errorMessage:
+			if errno, ok := e.field(fieldErrno).(syscall.Errno); ok {
+				return errno, true
+			}
+		}
+		u, ok := err.(interface {
+			Unwrap() error
+		})
+		if !ok {
+			break
+		}
+		err = u.Unwrap()
+	}
+	return 0, false
+}
@@ -0,0 +1,52 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !plan9
+// +build !plan9
+
+package errors
+
+import (
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestErrno(t *testing.T) {
+	err := FromErrno(syscall.ENOENT)
+	if err.Code() != ERR_NOT_FOUND || err.Level() != ERROR || Retryable(err) || err.Text() != syscall.ENOENT.Error() {
+		t.Errorf(`FromErrno(ENOENT) = %v, level %s, retryable %t`, err, levelName(err.Level()), Retryable(err))
+	}
+	err = FromErrno(syscall.ECONNREFUSED)
+	if err.Code() != ERR_UNAVAILABLE || !Retryable(err) {
+		t.Errorf(`FromErrno(ECONNREFUSED) = %v, retryable %t`, err, Retryable(err))
+	}
+	if app := New(Desc{Code: 1, Text: "app"}); app.(interface{ Is(error) bool }).Is(FromErrno(syscall.ENOENT)) {
+		t.Errorf(`application error with code 1 matches FromErrno(ENOENT)`)
+	}
+	if err = FromErrno(syscall.Errno(12345)); err.Code() != ERR_SYSTEM {
+		t.Errorf(`FromErrno(12345).Code() = %d, want %d`, err.Code(), ERR_SYSTEM)
+	}
+
+	if errno, ok := Errno(FromErrno(syscall.EEXIST)); !ok || errno != syscall.EEXIST {
+		t.Errorf(`Errno(FromErrno(EEXIST)) = %v, %t`, errno, ok)
+	}
+	_, e := os.Open("/does/not/exist")
+	if errno, ok := Errno(e); !ok || errno != syscall.ENOENT {
+		t.Errorf(`Errno(os.Open(missing)) = %v, %t`, errno, ok)
+	}
+	if _, ok := Errno(New("abc")); ok {
+		t.Errorf(`Errno(New("abc")) found an errno`)
+	}
+}
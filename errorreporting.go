@@ -0,0 +1,93 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"encoding/json"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// errorEvent is a Google Cloud Error Reporting event.
+type errorEvent struct {
+	EventTime      string `json:"eventTime,omitempty"`
+	ServiceContext struct {
+		Service string `json:"service"`
+		Version string `json:"version,omitempty"`
+	} `json:"serviceContext"`
+	Message string `json:"message"`
+	Context *struct {
+		HTTPRequest *errorEventRequest `json:"httpRequest,omitempty"`
+	} `json:"context,omitempty"`
+}
+
+type errorEventRequest struct {
+	Method             string `json:"method,omitempty"`
+	URL                string `json:"url,omitempty"`
+	UserAgent          string `json:"userAgent,omitempty"`
+	Referrer           string `json:"referrer,omitempty"`
+	ResponseStatusCode int    `json:"responseStatusCode,omitempty"`
+}
+
+// ToErrorReporting returns err as a Google Cloud Error Reporting event, ready
+// to be logged as a JSON payload or sent to the API. The service defaults to
+// the origin service of err. The message holds the text of err followed by a
+// stack trace in the format expected for Go: the one recorded in the Info of
+// err with "debug.stack", or else the stack of the calling goroutine. The HTTP
+// request context is filled from the fields recorded by Capture.
+func ToErrorReporting(err Error, service, version string) ([]byte, error) {
+	var ev errorEvent
+	if service == "" {
+		service, _ = Origin(err)
+	}
+	ev.ServiceContext.Service = service
+	ev.ServiceContext.Version = version
+	if t := Time(err); !t.IsZero() {
+		ev.EventTime = t.UTC().Format(time.RFC3339Nano)
+	}
+
+	var stack string
+	for _, s := range err.Info() {
+		if strings.HasPrefix(s, "goroutine ") {
+			stack = s
+			break
+		}
+	}
+	if stack == "" {
+		buf := make([]byte, 4096)
+		stack = string(buf[:runtime.Stack(buf, false)])
+	}
+	ev.Message = err.Error() + "\n\n" + stack
+
+	if em := messageOf(err); em != nil {
+		if method, _ := em.field(fieldHTTPMethod).(string); method != "" {
+			req := &errorEventRequest{Method: method}
+			req.URL, _ = em.field(fieldHTTPPath).(string)
+			req.ResponseStatusCode, _ = em.field(fieldHTTPStatus).(int)
+			if headers, ok := em.field(fieldHTTPHeaders).(map[string]string); ok {
+				req.UserAgent = headers["User-Agent"]
+				req.Referrer = headers["Referer"]
+			}
+			ev.Context = &struct {
+				HTTPRequest *errorEventRequest `json:"httpRequest,omitempty"`
+			}{req}
+		}
+	}
+	return json.Marshal(ev)
+}
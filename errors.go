@@ -22,14 +22,14 @@ The `New` function still accepts a single string as argument, so no code will be
 The additional information can be used for smarter error handling and logging:
 - `Level` differentiates between warnings, regular errors, panics converted to errors, and fatal errors;
 - `Code` allows custom classification and prioritizing, by using ranges or bit-level masks;
-- `Info` offers a store for arbitrary data and messages, besides the main error `Text`; the special string "debug.stack", if present as an element in the Info slice, is automatically replaced by a stack trace at the point the error message has been created.
-
+- `Fields` are the primary store for arbitrary typed data, see `WithField` and `Fields`;
+- `Info` offers a store for messages, besides the main error `Text`, kept as fields with generated keys; the special string "debug.stack", if present as an element in the Info slice, is automatically replaced by a stack trace at the point the error message has been created. `MigrateInfo` turns "key: value" elements into fields.
 */
 package errors
 
 import (
-	"fmt"
-	"runtime"
+	"sync/atomic"
+	"time"
 )
 
 // Error represents an error descriptor capable of storing more detailed
@@ -50,19 +50,32 @@ type Error interface {
 
 // Desc provides a means to convey detailed error information to New.
 type Desc struct {
-	Level int8
-	Code  int
-	Text  string
-	Info  []string
+	Level      int8
+	Code       int
+	Text       string
+	Info       []string
+	PublicText string
+	Fields     map[string]interface{}
 }
 
 // errorMessage stores information about one error occurrence. Pointers to it
 // implement the Error interface.
 type errorMessage struct {
-	level int8
-	code  int
-	text  string
-	info  []string
+	level     int8
+	code      int
+	text      string
+	public    string
+	fields    map[string]interface{}
+	info      []string
+	time      time.Time
+	branches  []branch
+	cause     error
+	wrapSite  string
+	sentinel  *Sentinel
+	handled   uint32
+	fullText  string
+	domain    string
+	codeStyle *int8
 }
 
 // New returns an error descriptor containing the given information. It accepts
@@ -70,45 +83,209 @@ type errorMessage struct {
 //
 // It is a drop-in replacement for the corresponding function from the standard package.
 func New(desc interface{}) Error {
+	return Default().finish(newError(desc, 4))
+}
+
+// newError implements New; calldepth is the number of stack frames from
+// addInfo up to the function called by the user.
+func newError(desc interface{}, calldepth int) Error {
+	e := buildError(desc, calldepth+1)
+	if em, ok := e.(*errorMessage); ok && escalate(em) {
+		em.setField(fieldEscalated, true)
+		if st := stack(calldepth - 1); st != "" && !hasStack(em.infoList()) {
+			em.appendInfo(st)
+		}
+	}
+	return e
+}
+
+func buildError(desc interface{}, calldepth int) Error {
 	switch desc := desc.(type) {
 	case string:
-		return &errorMessage{level: ERROR, text: desc}
+		return newMessage(desc)
 	case *string:
-		return &errorMessage{level: ERROR, text: *desc}
+		return newMessage(*desc)
 	case Desc:
-		return newFromE(&desc)
+		return newFromE(&desc, calldepth)
 	case *Desc:
-		return newFromE(desc)
+		return newFromE(desc, calldepth)
 	}
 	return newFromE(&Desc{
 		Code: ERR_NEW_ARG,
-		Text: fmt.Sprintf("unsupported error descriptor type %T", desc),
+		Text: "unsupported error descriptor type " + typeName(desc),
 		Info: []string{
-			fmt.Sprintf("%T", desc),
+			typeName(desc),
 			"debug.stack",
 		},
-	})
+	}, calldepth)
+}
+
+// messageOf returns the errorMessage holding the data of err, for reading
+// only, or nil if there is none.
+func messageOf(err error) *errorMessage {
+	switch e := err.(type) {
+	case *errorMessage:
+		return e
+	case readOnly:
+		return messageOf(e.e)
+	case interface {
+		sealed() Error
+	}:
+		return messageOf(e.sealed())
+	}
+	return nil
+}
+
+// ownMessage returns the errorMessage err is, possibly returned by Wrap with
+// the Timeout and Temporary methods of its cause, for modifying it, or nil.
+// Unlike messageOf, it does not reach through read-only views and sealed
+// errors, which must not be modified.
+func ownMessage(err error) *errorMessage {
+	switch e := err.(type) {
+	case *errorMessage:
+		return e
+	case netWrapper:
+		return e.errorMessage
+	}
+	return nil
+}
+
+// clone returns a copy of em, with its own fields.
+func (em *errorMessage) clone() *errorMessage {
+	c := &errorMessage{
+		level:     em.level,
+		code:      em.code,
+		text:      em.text,
+		public:    em.public,
+		info:      em.info[:len(em.info):len(em.info)],
+		time:      em.time,
+		branches:  em.branches[:len(em.branches):len(em.branches)],
+		cause:     em.cause,
+		wrapSite:  em.wrapSite,
+		sentinel:  em.sentinel,
+		handled:   atomic.LoadUint32(&em.handled),
+		fullText:  em.fullText,
+		domain:    em.domain,
+		codeStyle: em.codeStyle,
+	}
+	if em.fields != nil {
+		c.fields = make(map[string]interface{}, len(em.fields))
+		for k, v := range em.fields {
+			c.fields[k] = v
+		}
+	}
+	return c
+}
+
+// copyMessage returns a copy of e, of the same type, along with its message
+// to annotate, so that errors shared between goroutines, such as
+// package-level sentinels, are left unchanged. It returns e and nil if e
+// cannot be annotated, see ownMessage.
+func copyMessage(e Error) (Error, *errorMessage) {
+	em := ownMessage(e)
+	if em == nil {
+		return e, nil
+	}
+	c := em.clone()
+	if _, ok := e.(netWrapper); ok {
+		return netWrapper{c}, c
+	}
+	return c, c
 }
 
-func newFromE(desc *Desc) Error {
-	return (&errorMessage{
-		level: ERROR,
-		code:  desc.Code,
-		text:  desc.Text,
-	}).addInfo(3, desc.Info...).SetLevel(desc.Level)
+// from returns err as an Error, converting it if needed. It returns nil if
+// err is nil.
+func from(err error) Error {
+	if err == nil {
+		return nil
+	}
+	if e, ok := err.(Error); ok {
+		return e
+	}
+	em := newMessage(err.Error())
+	applyCause(em, err)
+	return em
+}
+
+func newFromE(desc *Desc, calldepth int) Error {
+	em := newMessage(desc.Text)
+	em.code = desc.Code
+	em.public = desc.PublicText
+	for k, v := range desc.Fields {
+		em.setField(k, v)
+	}
+	return em.addInfo(calldepth, desc.Info...).SetLevel(desc.Level)
+}
+
+// newMessage returns an errorMessage with the given text, the default level,
+// and the package-wide fields applied.
+func newMessage(text string) *errorMessage {
+	em := &errorMessage{level: ERROR, text: text, time: now()}
+	stampOrigin(em)
+	stampOwner(em)
+	stampGoroutine(em)
+	return em
+}
+
+// field returns the value of the field with the given key, or nil if not set.
+func (em *errorMessage) field(key string) interface{} {
+	return em.fields[key]
+}
+
+// setField sets the field with the given key to v.
+func (em *errorMessage) setField(key string, v interface{}) {
+	if em.fields == nil {
+		em.fields = make(map[string]interface{})
+	}
+	em.fields[key] = v
 }
 
 // Log sends the error to the provided log, using the appropriate
 // logging function: FATAL conditions are logged using Fatal(), PANIC using
-// Panic(), and anything else using Print().
+// Panic(), and anything else using Print(). Errors below the level set with
+// SetLogLevel are skipped; the detail logged is set with SetVerbosity (PANIC
+// and FATAL conditions are logged with their full Report), and the handling
+// of FATAL conditions can be changed with SetFatalPolicy. Errors
+// are sanitized first when log is returned by SanitizeLogger. A crash report
+// is written first for severe errors, see SetCrashReports, and the changes
+// since the previous occurrence are added if enabled, see SetOccurrenceDiff.
 func (em *errorMessage) Log(log Logger) Error {
-	switch em.level {
+	markHandled(em)
+	if sl, ok := log.(*sanitizingLogger); ok {
+		Sanitize(em, sl.opts).Log(sl.Logger)
+		return em
+	}
+	reportCrash(em)
+	diffOccurrence(em)
+	v, ok := logDetails(em)
+	if !ok {
+		return em
+	}
+	recordLogged(em)
+	countMetric(em)
+	if em.level >= PANIC {
+		v = em.report()
+	}
+	level := em.level
+	if level == FATAL {
+		switch fatalPolicy() {
+		case FATAL_PANIC:
+			level = PANIC
+		case FATAL_LOG:
+			level = ERROR
+		case FATAL_CONFIRM:
+			log.Print(v)
+			confirmFatal(em, log, v)
+			return em
+		}
+	}
+	switch level {
 	case FATAL:
-		log.Fatal(em)
+		log.Fatal(v)
 	case PANIC:
-		log.Panic(em)
+		log.Panic(v)
 	default:
-		log.Print(em)
+		log.Print(v)
 	}
 	return em
 }
@@ -145,42 +322,32 @@ func (em *errorMessage) Text() string {
 // SetText sets the error text.
 func (em *errorMessage) SetText(t string) Error {
 	em.text = t
+	truncateText(em)
 	return em
 }
 
-// Info returns the error info.
+// Info returns a copy of the error info.
 func (em *errorMessage) Info() []string {
-	return em.info
+	return append([]string(nil), em.info...)
 }
 
 // addInfo adds (more) error info.
 func (em *errorMessage) addInfo(calldepth int, s ...string) Error {
 	for i, line := range s {
 		if line == "debug.stack" {
-			calldepth *= 2
-			buffer := make([]byte, 4096)
-			buffer = buffer[:runtime.Stack(buffer, true)]
-			var p1, p2, l int
-			for j, c := range buffer {
-				if c == 10 {
-					if l == 0 {
-						p1 = j + 1
-					} else if l == calldepth {
-						p2 = j + 1
-						break
-					}
-					l++
-				}
+			st := ""
+			if stacksEnabled() {
+				st = stack(calldepth + 1)
 			}
-			if p2 > 0 {
-				s[i] = string(buffer[:p1]) + string(buffer[p2:])
+			if st == "" {
+				s = append(s[:i:i], s[i+1:]...)
 			} else {
-				s[i] = string(buffer)
+				s = append(append(s[:i:i], st), s[i+1:]...)
 			}
 			break
 		}
 	}
-	em.info = append(em.info, s...)
+	em.appendInfo(s...)
 	return em
 }
 
@@ -189,11 +356,20 @@ func (em *errorMessage) AddInfo(s ...string) Error {
 	return em.addInfo(2, s...)
 }
 
-// Error returns a text containing the error message and code;
+// Error returns a text containing the error message and code, unless a
+// renderer is registered for the code, see RegisterRenderer;
 // it is useful for satisfying the `error` interface.
 func (em *errorMessage) Error() string {
+	if s, ok := render(em, RENDER_TEXT); ok {
+		return s
+	}
+	return em.plainError()
+}
+
+// plainError returns the default rendering of em by Error.
+func (em *errorMessage) plainError() string {
 	if em.code != 0 {
-		return em.text + fmt.Sprintf(" (code: 0x%04x)", em.code)
+		return em.text + " (code: " + formatCode(em) + ")"
 	}
 	return em.text
 }
@@ -16,6 +16,7 @@ package errors
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"testing"
 )
@@ -45,18 +46,22 @@ func TestNew(t *testing.T) {
 
 	err := New(17)
 	if err.Code() != ERR_NEW_ARG {
-		t.Errorf(`New(17).Code() = %q, want %q`, err.Code(), ERR_NEW_ARG)
+		t.Errorf(`New(17).Code() = %d, want %d`, err.Code(), ERR_NEW_ARG)
 	}
 	if err.Text() != "unsupported error descriptor type int" {
 		t.Errorf(`New(17).Text() = %q, want %q`, err.Text(), "unsupported error descriptor type int")
 	}
-	if len(err.Info()) != 2 {
-		t.Errorf(`len(New(17).Info()) = %q, want %q`, len(err.Info()), 2)
+	wantInfo := 2
+	if minimalBuild {
+		wantInfo = 1
+	}
+	if len(err.Info()) != wantInfo {
+		t.Errorf(`len(New(17).Info()) = %d, want %d`, len(err.Info()), wantInfo)
 	} else {
 		if err.Info()[0] != "int" {
 			t.Errorf(`New(17).Info()[0] = %q, want %q`, err.Info()[0], "int")
 		}
-		if !strings.Contains(err.Info()[1], "goroutine") || !strings.Contains(err.Info()[1], "errors.TestNew") {
+		if !minimalBuild && (!strings.Contains(err.Info()[1], "goroutine") || !strings.Contains(err.Info()[1], "errors.TestNew")) {
 			t.Errorf(`New(17).Info()[1] does not contain stack trace (got %q)`, err.Info()[1])
 		}
 	}
@@ -99,17 +104,21 @@ func TestLevelNames(t *testing.T) {
 func TestSetters(t *testing.T) {
 	err := New("abc")
 	if err.SetLevel(FATAL).Level() != FATAL {
-		t.Errorf(`SetLevel(FATAL).Level() = %q, want %q`, err.Level(), levelName(FATAL))
+		t.Errorf(`SetLevel(FATAL).Level() = %s, want %s`, levelName(err.Level()), levelName(FATAL))
 	}
 	if err.SetCode(17).Code() != 17 {
-		t.Errorf(`SetCode(17).Code() = %q, want %q`, err.Code(), 17)
+		t.Errorf(`SetCode(17).Code() = %d, want %d`, err.Code(), 17)
 	}
 	if err.SetText("xyz").Text() != "xyz" {
 		t.Errorf(`SetText("xyz").Text() = %q, want %q`, err.Text(), "xyz")
 	}
 	info := err.AddInfo("line 1", "line 2", "debug.stack").Info()
-	if len(info) != 3 {
-		t.Errorf(`len(AddInfo("line 1", "line 2", "debug.stack").Info()) = %q, want %q`, len(info), 3)
+	wantInfo := 3
+	if minimalBuild {
+		wantInfo = 2
+	}
+	if len(info) != wantInfo {
+		t.Errorf(`len(AddInfo("line 1", "line 2", "debug.stack").Info()) = %d, want %d`, len(info), wantInfo)
 	} else {
 		if info[0] != "line 1" {
 			t.Errorf(`AddInfo("line 1", "line 2", "debug.stack").Info()[0] = %q, want %q`, info[0], "line 1")
@@ -117,7 +126,7 @@ func TestSetters(t *testing.T) {
 		if info[1] != "line 2" {
 			t.Errorf(`AddInfo("line 1", "line 2", "debug.stack").Info()[1] = %q, want %q`, info[1], "line 2")
 		}
-		if !strings.Contains(info[2], "goroutine") || !strings.Contains(info[2], "errors.TestSetters") {
+		if !minimalBuild && (!strings.Contains(info[2], "goroutine") || !strings.Contains(info[2], "errors.TestSetters")) {
 			t.Errorf(`AddInfo("line 1", "line 2", "debug.stack").Info()[2] does not contain stack trace (got %q)`, info[2])
 		}
 	}
@@ -131,3 +140,204 @@ func TestLog(t *testing.T) {
 		t.Errorf(`logging test got %q, want %q`, log.log, "abc\n[PANIC] abc\n[FATAL] abc\n")
 	}
 }
+
+func TestOrigin(t *testing.T) {
+	SetOrigin("svc", "host-1")
+	err := New("abc")
+	SetOrigin("", "")
+	if s, i := Origin(err); s != "svc" || i != "host-1" {
+		t.Errorf(`Origin(err) = %q, %q, want %q, %q`, s, i, "svc", "host-1")
+	}
+	if s, i := Origin(New("xyz")); s != "" || i != "" {
+		t.Errorf(`Origin(err) = %q, %q, want "", ""`, s, i)
+	}
+}
+
+func TestOwner(t *testing.T) {
+	if o := Owner(New("abc")); o != "" {
+		t.Errorf(`Owner(New("abc")) = %q without rules, want ""`, o)
+	}
+	RegisterOwner("testing", "qa")
+	RegisterOwner("github.com/agext/errors.newFromE", "internal")
+	RegisterOwner("github.com/agext/errors.TestOwn", "nobody")
+	RegisterOwner("github.com/agext/errors.TestOwner", "core")
+	defer RegisterOwner("testing", "")
+	defer RegisterOwner("github.com/agext/errors.newFromE", "")
+	defer RegisterOwner("github.com/agext/errors.TestOwn", "")
+	defer RegisterOwner("github.com/agext/errors.TestOwner", "")
+	if o := Owner(New(Desc{Text: "abc"})); o != "core" {
+		t.Errorf(`Owner(New(Desc{...})) = %q, want %q`, o, "core")
+	}
+	RegisterOwner("github.com/agext/errors.TestOwner", "")
+	if o := Owner(New("abc")); o != "qa" {
+		t.Errorf(`Owner(New("abc")) = %q, want %q`, o, "qa")
+	}
+}
+
+func TestCodeStyle(t *testing.T) {
+	RegisterCode(0x2104, "STORAGE_NOT_FOUND")
+	defer RegisterCode(0x2104, "")
+	unregistered := make(chan Error, 4)
+	remove := AddObserver(func(event int8, err Error) {
+		if event == EVENT_UNREGISTERED_CODE {
+			_ = err.Error() // rendering from an observer must not recurse
+			unregistered <- err
+		}
+	})
+	defer remove()
+	defer SetCodeStyle(CODES_HEX)
+
+	for _, style := range []int8{CODES_HEX, CODES_SYMBOLIC, CODES_STRICT} {
+		want := [2]string{"abc (code: STORAGE_NOT_FOUND (0x2104))", "abc (code: 0x0017)"}
+		if style == CODES_HEX {
+			want[0] = "abc (code: 0x2104)"
+		}
+		SetCodeStyle(style)
+		if got := New(Desc{Code: 0x2104, Text: "abc"}).Error(); got != want[0] {
+			t.Errorf(`style %d: Error() = %q, want %q`, style, got, want[0])
+		}
+		for i := 0; i < 3; i++ {
+			if got := New(Desc{Code: 0x17, Text: "abc"}).Error(); got != want[1] {
+				t.Errorf(`style %d: Error() = %q, want %q`, style, got, want[1])
+			}
+		}
+	}
+	select {
+	case err := <-unregistered:
+		if err.Code() != 0x17 {
+			t.Errorf(`unregistered code observed = %#x, want 0x17`, err.Code())
+		}
+	default:
+		t.Errorf(`unregistered code not observed by the time Error returned`)
+	}
+	select {
+	case err := <-unregistered:
+		t.Errorf(`unregistered code %#x observed again`, err.Code())
+	default:
+	}
+
+	SetCodeStyle(CODES_SYMBOLIC)
+	RegisterCode(0x2104, "OBJECT_NOT_FOUND")
+	if got := New(Desc{Code: 0x2104, Text: "abc"}).Error(); got != "abc (code: OBJECT_NOT_FOUND (0x2104))" {
+		t.Errorf(`Error() after renaming = %q`, got)
+	}
+	hex := CODES_HEX
+	f := &Factory{CodeStyle: &hex}
+	if got := f.New(Desc{Code: 0x2104, Text: "abc"}).Error(); got != "abc (code: 0x2104)" {
+		t.Errorf(`factory style: Error() = %q`, got)
+	}
+	if got := New(Desc{Code: 0x2104, Text: "abc"}).Error(); got != "abc (code: OBJECT_NOT_FOUND (0x2104))" {
+		t.Errorf(`global style after factory style: Error() = %q`, got)
+	}
+}
+
+func TestLogConfig(t *testing.T) {
+	defer SetLogLevel(LEVEL_WARNING)
+	defer SetVerbosity(VERBOSITY_TEXT)
+	log := &mockLogger{}
+	SetLogLevel(LEVEL_ERROR)
+	New(Desc{Level: WARNING, Text: "skipped"}).Log(log)
+	SetVerbosity(VERBOSITY_INFO)
+	err := New(Desc{Code: 1, Text: "abc", Info: []string{"line 1", "line 2\nline 3"}})
+	err.(*errorMessage).setField("k", 17)
+	err.Log(log)
+	SetVerbosity(VERBOSITY_FIELDS)
+	err.Log(log)
+	want := "abc (code: 0x0001)\n\tline 1\n\tline 2\n\tline 3\n" +
+		"abc (code: 0x0001)\n\tline 1\n\tline 2\n\tline 3\n\tk=17\n"
+	if log.log != want {
+		t.Errorf(`logging test got %q, want %q`, log.log, want)
+	}
+}
+
+func TestStacks(t *testing.T) {
+	SetStacks(false)
+	info := New("abc").AddInfo("a", "debug.stack", "b").Info()
+	SetStacks(true)
+	if len(info) != 2 || info[0] != "a" || info[1] != "b" {
+		t.Errorf(`AddInfo("a", "debug.stack", "b").Info() = %q without stacks, want ["a" "b"]`, info)
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	var denied []string
+	remove := AddObserver(func(event int8, err Error) {
+		if event == EVENT_READ_ONLY && err.Code() == ERR_IMMUTABLE {
+			denied = append(denied, err.Text())
+		}
+	})
+	defer remove()
+
+	SetOrigin("svc", "")
+	err := New(Desc{Level: WARNING, Code: 1, Text: "abc", Info: []string{"x"}})
+	SetOrigin("", "")
+	ro := ReadOnly(err)
+	if ReadOnly(ro) != ro {
+		t.Errorf(`ReadOnly(ReadOnly(err)) != ReadOnly(err)`)
+	}
+	ro.SetLevel(FATAL).SetCode(2).SetText("xyz").AddInfo("y").Info()[0] = "z"
+	SetPublicText(ro, "public")
+	if err.Level() != WARNING || err.Code() != 1 || err.Text() != "abc" || len(err.Info()) != 1 || err.Info()[0] != "x" || PublicText(err) != "" {
+		t.Errorf(`read-only view modified the error: %v %q`, err, err.Info())
+	}
+	if ro.Error() != "abc (code: 0x0001)" {
+		t.Errorf(`ReadOnly(err).Error() = %q, want %q`, ro.Error(), "abc (code: 0x0001)")
+	}
+	if s, _ := Origin(ro); s != "svc" {
+		t.Errorf(`Origin(ReadOnly(err)) = %q, want %q`, s, "svc")
+	}
+	if len(denied) != 5 || denied[0] != `SetLevel called on read-only error "abc (code: 0x0001)"` {
+		t.Errorf(`denied modifications = %q`, denied)
+	}
+}
+
+func TestUseProfile(t *testing.T) {
+	defer func() {
+		SetStacks(true)
+		SetVerbosity(VERBOSITY_TEXT)
+		SetCodeStyle(CODES_HEX)
+		SetFatalPolicy(FATAL_EXIT)
+	}()
+	UseProfile(PRODUCTION)
+	if !config.noStacks || config.verbosity != VERBOSITY_TEXT || codes.style != CODES_SYMBOLIC || config.fatalPolicy != FATAL_EXIT {
+		t.Errorf(`UseProfile(PRODUCTION) did not apply the profile`)
+	}
+	UseProfile(TEST)
+	log := &mockLogger{}
+	New(Desc{Level: FATAL, Text: "abc"}).Log(log)
+	SetFatalPolicy(FATAL_LOG)
+	New(Desc{Level: FATAL, Text: "xyz"}).Log(log)
+	if log.log != "[PANIC] abc\nxyz\n" {
+		t.Errorf(`logging test got %q, want %q`, log.log, "[PANIC] abc\nxyz\n")
+	}
+}
+
+func BenchmarkErrorCode(b *testing.B) {
+	RegisterCode(0x2104, "STORAGE_NOT_FOUND")
+	defer RegisterCode(0x2104, "")
+	defer SetCodeStyle(CODES_HEX)
+	for _, style := range []int8{CODES_HEX, CODES_SYMBOLIC} {
+		SetCodeStyle(style)
+		e := New(Desc{Code: 0x2104, Text: "object not found"})
+		b.Run(strconv.Itoa(int(style)), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = e.Error()
+			}
+		})
+	}
+}
+
+func BenchmarkLogRender(b *testing.B) {
+	RegisterCode(0x2104, "STORAGE_NOT_FOUND")
+	defer RegisterCode(0x2104, "")
+	defer SetCodeStyle(CODES_HEX)
+	defer SetVerbosity(VERBOSITY_TEXT)
+	SetCodeStyle(CODES_SYMBOLIC)
+	SetVerbosity(VERBOSITY_INFO)
+	e := New(Desc{Code: 0x2104, Level: WARNING, Text: "object not found", Info: []string{"bucket: photos"}})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		logDetails(e.(*errorMessage))
+	}
+}
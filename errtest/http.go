@@ -0,0 +1,93 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.9
+// +build go1.9
+
+package errtest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/agext/errors"
+)
+
+// errorDocument is the common part of the error documents rendered by
+// errors.ToJSONAPI and, with the default options, errors.ToOpenAPI.
+type errorDocument struct {
+	Errors []struct {
+		Status  string `json:"status"`
+		Code    string `json:"code"`
+		Detail  string `json:"detail"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// DecodeErrorResponse reads the body of resp, an error document rendered by
+// errors.ToJSONAPI or errors.ToOpenAPI (with the default options), and
+// returns its first error, with its code resolved by name (see
+// errors.RegisterCode), its detail or message as text and public text, and
+// the status of resp as the field "http.status". It fails the test if the
+// body is not such a document.
+func DecodeErrorResponse(t testing.TB, resp *http.Response) errors.Error {
+	t.Helper()
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("reading error response: %v", err)
+	}
+	var doc errorDocument
+	if err := json.Unmarshal(body, &doc); err != nil || len(doc.Errors) == 0 {
+		t.Fatalf("response is not an error document: %q", body)
+	}
+	o := doc.Errors[0]
+	text := o.Detail
+	if text == "" {
+		text = o.Message
+	}
+	code, ok := errors.CodeByName(o.Code)
+	if !ok && strings.HasPrefix(o.Code, "0x") {
+		c, err := strconv.ParseInt(o.Code[2:], 16, 0)
+		code, ok = int(c), err == nil
+	}
+	if !ok && o.Code != "" {
+		t.Fatalf("unknown error code %q in response", o.Code)
+	}
+	return errors.New(errors.Desc{
+		Code:       code,
+		Text:       text,
+		PublicText: text,
+		Fields:     map[string]interface{}{"http.status": resp.StatusCode},
+	})
+}
+
+// AssertProblem checks that resp has the status wantStatus and holds an error
+// document whose first error has the code wantCode, and returns that error,
+// see DecodeErrorResponse.
+func AssertProblem(t testing.TB, resp *http.Response, wantCode, wantStatus int) errors.Error {
+	t.Helper()
+	if resp.StatusCode != wantStatus {
+		t.Errorf("response status %d, want %d", resp.StatusCode, wantStatus)
+	}
+	e := DecodeErrorResponse(t, resp)
+	if e.Code() != wantCode {
+		t.Errorf("response error code %#x (%q), want %#x", e.Code(), e.Text(), wantCode)
+	}
+	return e
+}
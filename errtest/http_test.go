@@ -0,0 +1,72 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.9 && !errors_minimal
+// +build go1.9,!errors_minimal
+
+package errtest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/agext/errors"
+)
+
+func TestAssertProblem(t *testing.T) {
+	errors.RegisterCode(0x2500, "OUT_OF_STOCK")
+	defer errors.RegisterCode(0x2500, "")
+	h := func(w http.ResponseWriter, r *http.Request) {
+		status := http.StatusConflict
+		e := errors.New(errors.Desc{Code: 0x2500, Text: "sku 42 out of stock", PublicText: "Out of stock."})
+		if r.URL.Path == "/unnamed" {
+			e = errors.New(errors.Desc{Code: 0x2501, Text: "gone"})
+			status = http.StatusGone
+		}
+		doc, _ := errors.ToJSONAPI(status, errors.ShapeOptions{}, e)
+		w.WriteHeader(status)
+		w.Write(doc)
+	}
+
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest("GET", "/", nil))
+	e := AssertProblem(t, rec.Result(), 0x2500, http.StatusConflict)
+	if e.Text() != "Out of stock." || errors.PublicText(e) != "Out of stock." {
+		t.Errorf(`decoded error %q`, e.Text())
+	}
+	rec = httptest.NewRecorder()
+	h(rec, httptest.NewRequest("GET", "/unnamed", nil))
+	AssertProblem(t, rec.Result(), 0x2501, http.StatusGone)
+
+	ft := &fakeT{TB: t}
+	rec = httptest.NewRecorder()
+	h(rec, httptest.NewRequest("GET", "/", nil))
+	AssertProblem(ft, rec.Result(), 0x2501, http.StatusOK)
+	if ft.errors != 2 {
+		t.Errorf(`AssertProblem reported %d failures, want 2`, ft.errors)
+	}
+}
+
+// fakeT counts the failures reported to it instead of failing the test.
+type fakeT struct {
+	testing.TB
+	errors int
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.errors++
+}
@@ -0,0 +1,166 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.7
+// +build go1.7
+
+// Package errtest provides helpers for testing code built on
+// github.com/agext/errors, such as load generators validating how error
+// pipelines (observers, reporters, aggregators) behave under failure floods.
+package errtest
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/agext/errors"
+)
+
+// StormOptions configures an error storm.
+type StormOptions struct {
+	// Rate is the number of errors generated per second; zero means as fast
+	// as the target accepts them.
+	Rate float64
+	// Count is the number of errors generated; Duration is the longest time
+	// the storm lasts. Whichever limit is reached first ends it. Without
+	// either, 1000 errors are generated.
+	Count    int
+	Duration time.Duration
+	// Fingerprints is the number of distinct kinds of errors, each with its
+	// own code and text (default 10). Errors are spread evenly over them.
+	Fingerprints int
+	// BaseCode is the code of the first kind of errors; the others follow.
+	BaseCode int
+	// Levels gives the relative weight of each level in the mix (default all
+	// ERROR).
+	Levels map[errors.Level]int
+	// Workers is the number of goroutines delivering errors to the target
+	// concurrently (default 1).
+	Workers int
+	// Seed makes the sequence of generated errors reproducible.
+	Seed int64
+}
+
+// StormStats describes the errors delivered by a storm.
+type StormStats struct {
+	Sent          int
+	ByLevel       map[errors.Level]int
+	ByFingerprint map[int]int
+	Elapsed       time.Duration
+}
+
+// Storm generates errors as described by opts and passes them to target,
+// e.g. the Report method of a Reporter, until a limit is reached or ctx is
+// done. It returns once all generated errors have been delivered.
+func Storm(ctx context.Context, opts StormOptions, target func(errors.Error)) StormStats {
+	if opts.Count <= 0 && opts.Duration <= 0 {
+		opts.Count = 1000
+	}
+	if opts.Fingerprints <= 0 {
+		opts.Fingerprints = 10
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	levels, total := weights(opts.Levels)
+	if opts.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Duration)
+		defer cancel()
+	}
+
+	queue := make(chan errors.Error)
+	var wg sync.WaitGroup
+	wg.Add(opts.Workers)
+	for i := 0; i < opts.Workers; i++ {
+		go func() {
+			defer wg.Done()
+			for e := range queue {
+				target(e)
+			}
+		}()
+	}
+
+	stats := StormStats{
+		ByLevel:       make(map[errors.Level]int),
+		ByFingerprint: make(map[int]int),
+	}
+	rnd := rand.New(rand.NewSource(opts.Seed))
+	start := time.Now()
+loop:
+	for n := 0; opts.Count <= 0 || n < opts.Count; n++ {
+		if opts.Rate > 0 {
+			due := start.Add(time.Duration(float64(n) / opts.Rate * float64(time.Second)))
+			if d := time.Until(due); d > 0 {
+				select {
+				case <-time.After(d):
+				case <-ctx.Done():
+					break loop
+				}
+			}
+		}
+		fp := rnd.Intn(opts.Fingerprints)
+		level := errors.LEVEL_ERROR
+		if total > 0 {
+			w := rnd.Intn(total)
+			for _, l := range levels {
+				if w -= opts.Levels[l]; w < 0 {
+					level = l
+					break
+				}
+			}
+		}
+		e := errors.New(errors.Desc{
+			Level: int8(level),
+			Code:  opts.BaseCode + fp,
+			Text:  fmt.Sprintf("storm error %d", fp),
+		})
+		select {
+		case queue <- e:
+		case <-ctx.Done():
+			break loop
+		}
+		stats.Sent++
+		stats.ByLevel[level]++
+		stats.ByFingerprint[opts.BaseCode+fp]++
+	}
+	close(queue)
+	wg.Wait()
+	stats.Elapsed = time.Since(start)
+	return stats
+}
+
+// weights returns the valid levels with a positive weight in m, in order,
+// and the sum of their weights.
+func weights(m map[errors.Level]int) (levels []errors.Level, total int) {
+	for l, w := range m {
+		if l.Valid() && w > 0 {
+			levels = append(levels, l)
+			total += w
+		}
+	}
+	sort.Sort(byLevel(levels))
+	return
+}
+
+// byLevel sorts levels, least severe first.
+type byLevel []errors.Level
+
+func (ls byLevel) Len() int           { return len(ls) }
+func (ls byLevel) Less(i, j int) bool { return ls[i].Less(ls[j]) }
+func (ls byLevel) Swap(i, j int)      { ls[i], ls[j] = ls[j], ls[i] }
@@ -0,0 +1,65 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.7
+// +build go1.7
+
+package errtest
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/agext/errors"
+)
+
+func TestStorm(t *testing.T) {
+	var got int64
+	opts := StormOptions{
+		Count:        2000,
+		Fingerprints: 5,
+		BaseCode:     0x100,
+		Levels:       map[errors.Level]int{errors.LEVEL_WARNING: 3, errors.LEVEL_ERROR: 1},
+		Workers:      4,
+		Seed:         1,
+	}
+	stats := Storm(context.Background(), opts, func(e errors.Error) {
+		atomic.AddInt64(&got, 1)
+	})
+	if stats.Sent != 2000 || got != 2000 {
+		t.Errorf(`Storm(...) sent %d, delivered %d, want 2000`, stats.Sent, got)
+	}
+	if len(stats.ByFingerprint) != 5 || stats.ByFingerprint[0x104] == 0 {
+		t.Errorf(`Storm(...) fingerprints = %v`, stats.ByFingerprint)
+	}
+	if w, e := stats.ByLevel[errors.LEVEL_WARNING], stats.ByLevel[errors.LEVEL_ERROR]; w+e != 2000 || w < 2*e {
+		t.Errorf(`Storm(...) levels = %v, want about 3:1`, stats.ByLevel)
+	}
+	if again := Storm(context.Background(), opts, func(errors.Error) {}); again.ByFingerprint[0x100] != stats.ByFingerprint[0x100] {
+		t.Errorf(`Storm(...) is not reproducible with the same seed`)
+	}
+
+	stats = Storm(context.Background(), StormOptions{Rate: 1000, Duration: 50 * time.Millisecond}, func(errors.Error) {})
+	if stats.Sent < 10 || stats.Sent > 60 {
+		t.Errorf(`Storm(1000/s for 50ms) sent %d`, stats.Sent)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if stats = Storm(ctx, StormOptions{Rate: 10}, func(errors.Error) {}); stats.Sent > 1 {
+		t.Errorf(`Storm(cancelled) sent %d`, stats.Sent)
+	}
+}
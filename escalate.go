@@ -0,0 +1,140 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Field key used to mark errors created while their fingerprint is escalated.
+const fieldEscalated = "escalated"
+
+// EscalationPolicy describes when deep diagnostics are captured for a kind of
+// error: once Threshold errors with the same fingerprint (their code, or
+// their text if they have none) have been created within Window, the next
+// ones created during Duration (default Window) get a stack trace, whether
+// or not stacks are enabled, and are marked as escalated.
+type EscalationPolicy struct {
+	Threshold int
+	Window    time.Duration
+	Duration  time.Duration
+}
+
+type escalationState struct {
+	start time.Time // of the current window
+	count int
+	until time.Time // end of the escalation
+}
+
+// expired reports whether s no longer affects errors created at t.
+func (s *escalationState) expired(t time.Time, p EscalationPolicy) bool {
+	return !t.Before(s.until) && t.Sub(s.start) > p.Window
+}
+
+// minEscalationSweep is the number of states below which expired ones are
+// kept rather than swept.
+const minEscalationSweep = 64
+
+var escalation struct {
+	sync.Mutex
+	policy  EscalationPolicy
+	states  map[string]*escalationState
+	sweepAt int // number of states triggering the next sweep
+}
+
+// SetEscalation sets the policy escalating diagnostics for errors occurring
+// repeatedly; a zero Threshold disables escalation (the default). The counts
+// of previous occurrences are discarded.
+func SetEscalation(p EscalationPolicy) {
+	if p.Duration <= 0 {
+		p.Duration = p.Window
+	}
+	escalation.Lock()
+	escalation.policy, escalation.states, escalation.sweepAt = p, nil, minEscalationSweep
+	escalation.Unlock()
+}
+
+// Escalated reports whether err was created while its fingerprint was
+// escalated, see SetEscalation.
+func Escalated(err error) bool {
+	if em := messageOf(err); em != nil {
+		escalated, _ := em.field(fieldEscalated).(bool)
+		return escalated
+	}
+	return false
+}
+
+// escalate counts the occurrence of em, and reports whether its fingerprint
+// is escalated.
+func escalate(em *errorMessage) bool {
+	escalation.Lock()
+	defer escalation.Unlock()
+	p := escalation.policy
+	if p.Threshold <= 0 {
+		return false
+	}
+	if escalation.states == nil {
+		escalation.states = make(map[string]*escalationState)
+	}
+	fp := em.fingerprint()
+	s := escalation.states[fp]
+	if s == nil {
+		if len(escalation.states) >= escalation.sweepAt {
+			sweepEscalation(em.time, p)
+		}
+		s = &escalationState{start: em.time}
+		escalation.states[fp] = s
+	}
+	if em.time.Before(s.until) {
+		return true
+	}
+	if s.expired(em.time, p) {
+		s.start, s.count = em.time, 0
+	}
+	if s.count++; s.count > p.Threshold {
+		s.until = em.time.Add(p.Duration)
+		s.start, s.count = s.until, 0
+		return true
+	}
+	return false
+}
+
+// sweepEscalation removes the states expired at t, so that the fingerprints
+// of errors no longer occurring are forgotten. The next sweep happens when the
+// number of states doubles, keeping the cost of sweeps proportional to the
+// number of occurrences. The caller holds the escalation lock.
+func sweepEscalation(t time.Time, p EscalationPolicy) {
+	for fp, s := range escalation.states {
+		if s.expired(t, p) {
+			delete(escalation.states, fp)
+		}
+	}
+	escalation.sweepAt = 2 * len(escalation.states)
+	if escalation.sweepAt < minEscalationSweep {
+		escalation.sweepAt = minEscalationSweep
+	}
+}
+
+// hasStack reports whether info holds a stack trace.
+func hasStack(info []string) bool {
+	for _, s := range info {
+		if strings.HasPrefix(s, "goroutine ") || strings.HasPrefix(s, rawStackPrefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEscalation(t *testing.T) {
+	defer SetEscalation(EscalationPolicy{})
+	defer SetStacks(true)
+	defer SetClock(nil)
+	SetStacks(false)
+	start := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	at := func(d time.Duration) { SetClock(fixedClock(start.Add(d))) }
+	SetEscalation(EscalationPolicy{Threshold: 2, Window: time.Minute})
+
+	for i, c := range []struct {
+		at        time.Duration
+		escalated bool
+	}{
+		{0, false},
+		{2 * time.Minute, false}, // new window
+		{2*time.Minute + time.Second, false},
+		{2*time.Minute + 2*time.Second, true},
+		{2*time.Minute + 30*time.Second, true},
+		{4 * time.Minute, false}, // reverted
+	} {
+		at(c.at)
+		err := New(Desc{Code: 0x42, Text: "timeout"})
+		if Escalated(err) != c.escalated {
+			t.Errorf(`#%d: Escalated(err) = %t, want %t`, i, Escalated(err), c.escalated)
+		}
+		if hasStack(err.Info()) != (c.escalated && !minimalBuild) {
+			t.Errorf(`#%d: err.Info() = %q`, i, err.Info())
+		}
+	}
+	if err := New("other"); Escalated(err) {
+		t.Errorf(`Escalated(New("other")) = true`)
+	}
+
+	for i := 0; i < 3*minEscalationSweep; i++ {
+		New(Desc{Code: 0x1000 + i, Text: "transient"})
+	}
+	at(10 * time.Minute)
+	for i := 0; i < minEscalationSweep; i++ {
+		New(Desc{Code: 0x2000 + i, Text: "later"})
+	}
+	escalation.Lock()
+	n := len(escalation.states)
+	escalation.Unlock()
+	if n > 2*minEscalationSweep {
+		t.Errorf(`%d escalation states kept, expired ones not evicted`, n)
+	}
+
+	if minimalBuild {
+		return
+	}
+	SetStacks(true)
+	at(5 * time.Minute)
+	for i := 0; i < 3; i++ {
+		err := New(Desc{Code: 0x42, Text: "timeout", Info: []string{"debug.stack"}})
+		if len(err.Info()) != 1 {
+			t.Errorf(`err.Info() has %d elements, want 1`, len(err.Info()))
+		}
+		if i == 2 && !strings.Contains(err.Info()[0], "TestEscalation") {
+			t.Errorf(`err.Info()[0] = %q`, err.Info()[0])
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.12 && !errors_minimal && !plan9
+// +build go1.12,!errors_minimal,!plan9
+
+package errors
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// Field keys used to describe a failed subprocess.
+const (
+	fieldExecArgv     = "exec.argv"
+	fieldExecExitCode = "exec.exit_code"
+	fieldExecSignal   = "exec.signal"
+	fieldExecStderr   = "exec.stderr"
+)
+
+// maxExecStderr is the number of bytes kept of the end of the standard error
+// of a failed subprocess.
+const maxExecStderr = 4096
+
+// retryableExitCodes lists the exit codes meaning that a command may succeed
+// if run again, from sysexits.h.
+var retryableExitCodes = map[int]bool{
+	69: true, // EX_UNAVAILABLE
+	75: true, // EX_TEMPFAIL
+}
+
+// FromExec returns an Error describing the failure err of cmd, as returned by
+// its Run, Output or Wait method, with the code ERR_EXEC (ERR_NOT_FOUND if
+// the command could not be found). Its fields record the arguments of the
+// command, its exit code or the signal it was killed by, the end of its
+// standard error stderr (if captured), and whether it is worth retrying:
+// when it was killed by SIGKILL, SIGTERM or SIGINT, or when it exited with
+// EX_UNAVAILABLE or EX_TEMPFAIL. The Error wraps err, so that errors.Is and
+// errors.As find exec.ErrNotFound or the *exec.ExitError, and Errno the system
+// error number, if any. FromExec returns nil if err is nil.
+func FromExec(cmd *exec.Cmd, err error, stderr []byte) Error {
+	if err == nil {
+		return nil
+	}
+	name := "command"
+	em := newMessage("")
+	em.code, em.cause = ERR_EXEC, err
+	if cmd != nil {
+		if len(cmd.Args) > 0 {
+			name = cmd.Args[0]
+		}
+		em.setField(fieldExecArgv, append([]string(nil), cmd.Args...))
+	}
+	retryable := false
+	switch e := err.(type) {
+	case *exec.ExitError:
+		ws, ok := e.Sys().(syscall.WaitStatus)
+		if ok && ws.Signaled() {
+			sig := ws.Signal()
+			em.text = name + " killed by signal " + sig.String()
+			em.setField(fieldExecSignal, sig.String())
+			retryable = sig == syscall.SIGKILL || sig == syscall.SIGTERM || sig == syscall.SIGINT
+		} else {
+			code := e.ExitCode()
+			em.text = name + " exited with status " + strconv.Itoa(code)
+			em.setField(fieldExecExitCode, code)
+			retryable = retryableExitCodes[code]
+		}
+		if len(stderr) == 0 {
+			stderr = e.Stderr
+		}
+	case *exec.Error:
+		em.text = name + ": " + e.Err.Error()
+		if e.Err == exec.ErrNotFound {
+			em.code = ERR_NOT_FOUND
+		}
+	default:
+		em.text = name + ": " + err.Error()
+	}
+	if s := strings.TrimSpace(string(stderr)); s != "" {
+		if len(s) > maxExecStderr {
+			s = "..." + s[len(s)-maxExecStderr:]
+		}
+		em.setField(fieldExecStderr, s)
+	}
+	em.setField(fieldRetryable, retryable)
+	return Default().finish(em)
+}
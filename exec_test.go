@@ -0,0 +1,69 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.12 && !errors_minimal && !plan9 && !windows
+// +build go1.12,!errors_minimal,!plan9,!windows
+
+package errors
+
+import (
+	"os/exec"
+	"syscall"
+	"testing"
+)
+
+func TestFromExec(t *testing.T) {
+	if FromExec(nil, nil, nil) != nil {
+		t.Errorf(`FromExec(nil, nil, nil) != nil`)
+	}
+	field := func(e Error, key string) interface{} {
+		_, v, _ := FindField(e, func(k string, _ interface{}) bool { return k == key })
+		return v
+	}
+
+	cmd := exec.Command("sh", "-c", "echo 'disk quota exceeded' >&2; exit 75")
+	_, err := cmd.Output()
+	e := FromExec(cmd, err, nil)
+	if _, ok := e.(interface{ Unwrap() error }).Unwrap().(*exec.ExitError); !ok {
+		t.Errorf(`FromExec(exit 75) does not wrap the *exec.ExitError`)
+	}
+	if e.Code() != ERR_EXEC || e.Text() != "sh exited with status 75" {
+		t.Errorf(`FromExec(exit 75) = %v`, e)
+	}
+	if field(e, fieldExecExitCode) != 75 || field(e, fieldExecStderr) != "disk quota exceeded" || !Retryable(e) {
+		t.Errorf(`FromExec(exit 75) fields: exit code %v, stderr %q, retryable %t`,
+			field(e, fieldExecExitCode), field(e, fieldExecStderr), Retryable(e))
+	}
+	if argv, _ := field(e, fieldExecArgv).([]string); len(argv) != 3 || argv[2] != "echo 'disk quota exceeded' >&2; exit 75" {
+		t.Errorf(`argv = %q`, argv)
+	}
+
+	cmd = exec.Command("sh", "-c", "kill -TERM $$")
+	e = FromExec(cmd, cmd.Run(), []byte("  \n"))
+	if e.Text() != "sh killed by signal terminated" || field(e, fieldExecSignal) != "terminated" || !Retryable(e) || field(e, fieldExecStderr) != nil {
+		t.Errorf(`FromExec(SIGTERM) = %v, retryable %t`, e, Retryable(e))
+	}
+
+	cmd = exec.Command("errors-no-such-command")
+	if e = FromExec(cmd, cmd.Run(), nil); e.Code() != ERR_NOT_FOUND || Retryable(e) {
+		t.Errorf(`FromExec(not found) = %v`, e)
+	}
+	if e.(interface{ Unwrap() error }).Unwrap().(*exec.Error).Err != exec.ErrNotFound {
+		t.Errorf(`FromExec(not found) does not wrap exec.ErrNotFound`)
+	}
+	cmd = exec.Command("/errors-no-such-dir/cmd")
+	if errno, ok := Errno(FromExec(cmd, cmd.Run(), nil)); !ok || errno != syscall.ENOENT {
+		t.Errorf(`Errno(FromExec(no such file)) = %v, %t`, errno, ok)
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import "sync"
+
+// Factory creates errors with common enrichments, e.g. the fields
+// identifying a service or a tenant. Its fields must not be modified once it
+// is in use.
+type Factory struct {
+	// Fields are set on the errors created, unless they already are.
+	Fields map[string]interface{}
+	// Enrich, if not nil, is called on the errors created, after Fields are
+	// set and before the severity hook.
+	Enrich func(Error)
+	// Domain is the domain of the codes of the errors created, see Domain.
+	Domain string
+	// CodeStyle, if not nil, is the style the codes of the errors created are
+	// rendered in, instead of the one set with SetCodeStyle.
+	CodeStyle *int8
+}
+
+var defaultFactory struct {
+	sync.RWMutex
+	f *Factory
+}
+
+// Default returns the factory used by New, NewCtx and the other constructors
+// of the package.
+func Default() *Factory {
+	defaultFactory.RLock()
+	f := defaultFactory.f
+	defaultFactory.RUnlock()
+	if f == nil {
+		return &Factory{}
+	}
+	return f
+}
+
+// SetDefault sets the factory used by New, NewCtx and the other constructors
+// of the package; nil restores a factory with no enrichment.
+func SetDefault(f *Factory) {
+	defaultFactory.Lock()
+	defaultFactory.f = f
+	defaultFactory.Unlock()
+}
+
+// New is like the package New function, using f.
+func (f *Factory) New(desc interface{}) Error {
+	return f.finish(newError(desc, 4))
+}
+
+// finish applies the enrichments of f, the checks of the field schemas and
+// strict mode, and the severity hook to e, a newly created error, and returns
+// it.
+func (f *Factory) finish(e Error) Error {
+	em, ok := e.(*errorMessage)
+	if ok {
+		truncateText(em)
+		if em.domain == "" {
+			em.domain = f.Domain
+		}
+		if f.CodeStyle != nil {
+			em.codeStyle = f.CodeStyle
+		}
+		for k, v := range f.Fields {
+			if em.field(k) == nil {
+				em.setField(k, v)
+			}
+		}
+	}
+	if f.Enrich != nil {
+		f.Enrich(e)
+	}
+	if ok {
+		checkInvariants(em, "created")
+	}
+	applySeverity(e)
+	if ok {
+		trackHandling(em)
+		captureProfile(em)
+	}
+	return e
+}
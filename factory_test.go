@@ -0,0 +1,66 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestDefaultFactory(t *testing.T) {
+	defer SetDefault(nil)
+	if f := Default(); f == nil || f.Fields != nil || f.Enrich != nil {
+		t.Errorf(`Default() = %v`, f)
+	}
+	var enriched int
+	SetDefault(&Factory{
+		Fields: map[string]interface{}{"service": "billing", "tenant": "none"},
+		Enrich: func(e Error) { enriched++ },
+	})
+	e := New(Desc{Text: "failed", Fields: map[string]interface{}{"tenant": "acme"}})
+	if v, _ := Field(e, "service"); v != "billing" {
+		t.Errorf(`field "service" = %v`, v)
+	}
+	if v, _ := Field(e, "tenant"); v != "acme" {
+		t.Errorf(`field "tenant" = %v`, v)
+	}
+	FromErrno(0)
+	if enriched != 2 {
+		t.Errorf(`enriched %d errors, want 2`, enriched)
+	}
+
+	e = Default().New(Desc{Text: "failed", Info: []string{"debug.stack"}})
+	if info := e.Info(); !minimalBuild && (len(info) != 1 || !strings.Contains(info[0], "TestDefaultFactory")) {
+		t.Errorf(`Info() = %q`, info)
+	}
+}
+
+func TestDefaultFactoryConcurrency(t *testing.T) {
+	defer SetDefault(nil)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			SetDefault(&Factory{})
+		}()
+		go func() {
+			defer wg.Done()
+			New("concurrent")
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,127 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Field keys recording the paths of the profiles captured for an error.
+const (
+	fieldProfileGoroutine = "profile.goroutine"
+	fieldProfileCPU       = "profile.cpu"
+)
+
+// ProfileOptions configures the profiles captured on failures, see
+// SetFailureProfiling.
+type ProfileOptions struct {
+	// Level is the minimum level of the errors triggering a capture; zero
+	// disables captures.
+	Level Level
+	// CPU is the duration of the CPU profile; zero captures the goroutine
+	// profile only.
+	CPU time.Duration
+	// Interval is the minimum interval between captures (default 1 minute).
+	Interval time.Duration
+	// Dir is the directory profiles are written to (default os.TempDir()).
+	Dir string
+}
+
+var failureProfiling struct {
+	opts atomic.Value // ProfileOptions
+	last int64        // time of the latest capture, in Unix nanoseconds
+}
+
+// SetFailureProfiling enables the capture of profiles when errors of at least
+// opts.Level are created, at most once per opts.Interval: the goroutine
+// profile, written at once, and the CPU profile of the following opts.CPU,
+// skipped if CPU profiling is already running. The profiles are written in
+// the pprof format, and their paths recorded as the fields
+// "profile.goroutine" and "profile.cpu" of the error, so that an incident
+// comes with a view of what the process was doing. Writing the goroutine
+// profile stops the world briefly.
+func SetFailureProfiling(opts ProfileOptions) {
+	if opts.Interval <= 0 {
+		opts.Interval = time.Minute
+	}
+	if opts.Dir == "" {
+		opts.Dir = os.TempDir()
+	}
+	failureProfiling.opts.Store(opts)
+	atomic.StoreInt64(&failureProfiling.last, 0)
+}
+
+// captureProfile captures profiles for em, if it qualifies and the rate
+// limit allows.
+func captureProfile(em *errorMessage) {
+	opts, _ := failureProfiling.opts.Load().(ProfileOptions)
+	if opts.Level == 0 || Level(em.level).Less(opts.Level) || em.informational() {
+		return
+	}
+	t := now().UnixNano()
+	last := atomic.LoadInt64(&failureProfiling.last)
+	if last != 0 && t-last < int64(opts.Interval) ||
+		!atomic.CompareAndSwapInt64(&failureProfiling.last, last, t) {
+		return
+	}
+	base := filepath.Join(opts.Dir, "errors-"+strconv.FormatInt(t, 10))
+	if path := base + "-goroutine.pprof"; writeProfile(path, "goroutine") {
+		em.setField(fieldProfileGoroutine, path)
+	}
+	if opts.CPU <= 0 {
+		return
+	}
+	path := base + "-cpu.pprof"
+	f, err := os.Create(path)
+	if err != nil {
+		return
+	}
+	if pprof.StartCPUProfile(f) != nil {
+		f.Close()
+		os.Remove(path)
+		return
+	}
+	em.setField(fieldProfileCPU, path)
+	time.AfterFunc(opts.CPU, func() {
+		pprof.StopCPUProfile()
+		f.Close()
+	})
+}
+
+// writeProfile writes the named runtime profile to path, and reports whether
+// it succeeded.
+func writeProfile(path, name string) bool {
+	f, err := os.Create(path)
+	if err != nil {
+		return false
+	}
+	err = pprof.Lookup(name).WriteTo(f, 0)
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		os.Remove(path)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,71 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestFailureProfiling(t *testing.T) {
+	dir, err := ioutil.TempDir("", "errors")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer SetFailureProfiling(ProfileOptions{})
+	at := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	SetClock(fixedClock(at))
+	defer SetClock(nil)
+	SetFailureProfiling(ProfileOptions{Level: LEVEL_ERROR, CPU: 20 * time.Millisecond, Interval: time.Hour, Dir: dir})
+
+	field := func(e Error, key string) string {
+		s, _ := Fields(e)[key].(string)
+		return s
+	}
+	if e := New(Desc{Level: WARNING, Text: "below threshold"}); field(e, fieldProfileGoroutine) != "" {
+		t.Errorf(`profile captured for a WARNING`)
+	}
+	e := New("failed")
+	goroutines, cpu := field(e, fieldProfileGoroutine), field(e, fieldProfileCPU)
+	if fi, err := os.Stat(goroutines); err != nil || fi.Size() == 0 {
+		t.Errorf(`goroutine profile %q not written: %v`, goroutines, err)
+	}
+	if cpu == "" {
+		t.Errorf(`CPU profile not captured`)
+	}
+	if e := New("failed again"); field(e, fieldProfileGoroutine) != "" {
+		t.Errorf(`profile captured within the interval`)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if fi, err := os.Stat(cpu); err == nil && fi.Size() > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf(`CPU profile %q not written`, cpu)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	SetClock(fixedClock(at.Add(time.Hour)))
+	if e := New("failed later"); field(e, fieldProfileGoroutine) == "" {
+		t.Errorf(`profile not captured after the interval`)
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"sync"
+	"time"
+)
+
+// FatalConfirmation configures the handling of FATAL errors under the
+// FATAL_CONFIRM policy, see SetFatalConfirmation.
+type FatalConfirmation struct {
+	// Cleanup, if not nil, is called first, e.g. to stop accepting work.
+	Cleanup func(err Error)
+	// Supervisor, if not nil, is then called after every Grace period,
+	// starting right away, until it returns false; returning true vetoes
+	// exiting for now, e.g. while in-flight work finishes.
+	Supervisor func(err Error) bool
+	// Grace is the period between calls to Supervisor (default 1 second).
+	Grace time.Duration
+	// Deadline is the time after which the process exits despite vetoes
+	// (default 1 minute).
+	Deadline time.Duration
+}
+
+var fatalConfirmation struct {
+	sync.Mutex
+	opts    FatalConfirmation
+	pending bool
+}
+
+// SetFatalConfirmation configures the two-phase handling of FATAL errors by
+// Log under the FATAL_CONFIRM policy, for long-running daemons which must
+// wind down rather than exit abruptly. The first FATAL error is logged with
+// the Print function of the logger, and starts a confirmation phase: opts.Cleanup
+// is called, then opts.Supervisor until it stops vetoing or opts.Deadline
+// expires, and the error is finally logged with the Fatal function of the
+// logger. FATAL errors logged during the phase are only printed.
+func SetFatalConfirmation(opts FatalConfirmation) {
+	if opts.Grace <= 0 {
+		opts.Grace = time.Second
+	}
+	if opts.Deadline <= 0 {
+		opts.Deadline = time.Minute
+	}
+	fatalConfirmation.Lock()
+	fatalConfirmation.opts = opts
+	fatalConfirmation.pending = false
+	fatalConfirmation.Unlock()
+}
+
+// confirmFatal starts the confirmation phase for em, logged as v with log,
+// unless one is pending.
+func confirmFatal(em *errorMessage, log Logger, v interface{}) {
+	fatalConfirmation.Lock()
+	if fatalConfirmation.pending {
+		fatalConfirmation.Unlock()
+		return
+	}
+	fatalConfirmation.pending = true
+	opts := fatalConfirmation.opts
+	fatalConfirmation.Unlock()
+	if opts.Grace <= 0 {
+		opts.Grace, opts.Deadline = time.Second, time.Minute
+	}
+	go func() {
+		deadline := now().Add(opts.Deadline)
+		if opts.Cleanup != nil {
+			opts.Cleanup(em)
+		}
+		for opts.Supervisor != nil && opts.Supervisor(em) {
+			left := deadline.Sub(now())
+			if left <= 0 {
+				break
+			}
+			if left > opts.Grace {
+				left = opts.Grace
+			}
+			time.Sleep(left)
+		}
+		log.Fatal(v)
+	}()
+}
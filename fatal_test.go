@@ -0,0 +1,91 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// exitLogger is a Logger signaling calls to Fatal.
+type exitLogger struct {
+	printed int32
+	exit    chan string
+}
+
+func (l *exitLogger) Print(v ...interface{}) { atomic.AddInt32(&l.printed, 1) }
+func (l *exitLogger) Panic(v ...interface{}) {}
+func (l *exitLogger) Fatal(v ...interface{}) { l.exit <- fmt.Sprint(v...) }
+
+func TestFatalConfirmation(t *testing.T) {
+	defer SetFatalPolicy(FATAL_EXIT)
+	defer SetFatalConfirmation(FatalConfirmation{})
+	SetFatalPolicy(FATAL_CONFIRM)
+	var cleaned, asked int32
+	SetFatalConfirmation(FatalConfirmation{
+		Cleanup:    func(Error) { atomic.AddInt32(&cleaned, 1) },
+		Supervisor: func(Error) bool { return atomic.AddInt32(&asked, 1) < 3 },
+		Grace:      time.Millisecond,
+	})
+	log := &exitLogger{exit: make(chan string, 2)}
+	New(Desc{Level: FATAL, Text: "store corrupted"}).Log(log)
+	New(Desc{Level: FATAL, Text: "second"}).Log(log)
+	select {
+	case v := <-log.exit:
+		if v != "store corrupted" {
+			t.Errorf(`Fatal(%q)`, v)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal(`Fatal not called`)
+	}
+	c, a, p := atomic.LoadInt32(&cleaned), atomic.LoadInt32(&asked), atomic.LoadInt32(&log.printed)
+	if c != 1 || a != 3 || p != 2 {
+		t.Errorf(`cleaned %d, asked %d, printed %d`, c, a, p)
+	}
+
+	// vetoes do not outlast the deadline
+	SetFatalConfirmation(FatalConfirmation{
+		Supervisor: func(Error) bool { return true },
+		Grace:      time.Millisecond,
+		Deadline:   20 * time.Millisecond,
+	})
+	New(Desc{Level: FATAL, Text: "stuck"}).Log(log)
+	select {
+	case <-log.exit:
+	case <-time.After(5 * time.Second):
+		t.Fatal(`deadline not enforced`)
+	}
+
+	// the deadline is measured with the package clock
+	at := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	SetClock(fixedClock(at))
+	defer SetClock(nil)
+	SetFatalConfirmation(FatalConfirmation{
+		Supervisor: func(Error) bool {
+			SetClock(fixedClock(at.Add(2 * time.Hour)))
+			return true
+		},
+		Grace:    time.Millisecond,
+		Deadline: time.Hour,
+	})
+	New(Desc{Level: FATAL, Text: "stuck in time"}).Log(log)
+	select {
+	case <-log.exit:
+	case <-time.After(5 * time.Second):
+		t.Fatal(`deadline not measured with the package clock`)
+	}
+}
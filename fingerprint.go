@@ -0,0 +1,170 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"hash/fnv"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Fingerprinter computes the identity of the kind of an error, used to group
+// its occurrences, see Fingerprint. The result for a given error must never
+// change for a given Version: any change to the algorithm requires a new
+// one.
+type Fingerprinter interface {
+	// Version identifies the algorithm, e.g. "v1".
+	Version() string
+	// Fingerprint returns the fingerprint of err.
+	Fingerprint(err Error) string
+}
+
+// Versions of the fingerprint algorithm.
+var (
+	// FingerprintV1, the default, is the code of the error in hexadecimal,
+	// e.g. "0x42", or if it has none, its text followed by " @" and the
+	// innermost function of the application in its stack trace, if any, see
+	// Frames.
+	FingerprintV1 Fingerprinter = fingerprintV1{}
+	// FingerprintV2 is the 64-bit FNV-1a hash, in 16 hexadecimal digits, of
+	// the canonical form of the error: "code=" followed by its code in
+	// decimal, or if it has none, "text=" followed by its text, a newline,
+	// "func=" and the innermost function of the application.
+	FingerprintV2 Fingerprinter = fingerprintV2{}
+)
+
+var fingerprinters struct {
+	sync.RWMutex
+	current, previous Fingerprinter
+	until             time.Time // end of the migration from previous
+}
+
+// SetFingerprinter sets the algorithm used by Fingerprint and the features
+// grouping errors by fingerprint; nil restores FingerprintV1. During the
+// migration period following the change, PreviousFingerprint also provides
+// the fingerprints computed by the algorithm replaced, for stores grouping
+// errors to match both; a migration of zero ends any migration in progress.
+func SetFingerprinter(f Fingerprinter, migration time.Duration) {
+	if f == nil {
+		f = FingerprintV1
+	}
+	fingerprinters.Lock()
+	defer fingerprinters.Unlock()
+	if migration <= 0 {
+		fingerprinters.current, fingerprinters.previous, fingerprinters.until = f, nil, time.Time{}
+		return
+	}
+	fingerprinters.previous = fingerprinters.current
+	if fingerprinters.previous == nil {
+		fingerprinters.previous = FingerprintV1
+	}
+	fingerprinters.current, fingerprinters.until = f, now().Add(migration)
+}
+
+// currentFingerprinter returns the algorithm set with SetFingerprinter.
+func currentFingerprinter() Fingerprinter {
+	fingerprinters.RLock()
+	f := fingerprinters.current
+	fingerprinters.RUnlock()
+	if f == nil {
+		return FingerprintV1
+	}
+	return f
+}
+
+// Fingerprint returns the identity of the kind of error err is, as computed
+// by the algorithm set with SetFingerprinter, FingerprintV1 by default. For
+// errors which are not an Error, it is their text.
+func Fingerprint(err error) string {
+	if em := messageOf(err); em != nil {
+		return em.fingerprint()
+	}
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// PreviousFingerprint returns the fingerprint of err computed by the
+// algorithm replaced by the last call to SetFingerprinter, and its version,
+// until the end of the migration period. The last result is false when there
+// is no migration in progress.
+func PreviousFingerprint(err error) (fingerprint, version string, ok bool) {
+	fingerprinters.RLock()
+	f, until := fingerprinters.previous, fingerprinters.until
+	fingerprinters.RUnlock()
+	em := messageOf(err)
+	if f == nil || em == nil || !now().Before(until) {
+		return "", "", false
+	}
+	return f.Fingerprint(em), f.Version(), true
+}
+
+// fingerprint returns the identity of the kind of error em is.
+func (em *errorMessage) fingerprint() string {
+	return currentFingerprinter().Fingerprint(em)
+}
+
+// appFunction returns the innermost function of the application in the stack
+// trace of em, if any.
+func (em *errorMessage) appFunction() string {
+	for _, f := range Frames(em) {
+		if f.Kind == FRAME_APP {
+			return f.Function
+		}
+	}
+	return ""
+}
+
+type fingerprintV1 struct{}
+
+func (fingerprintV1) Version() string { return "v1" }
+
+func (fingerprintV1) Fingerprint(err Error) string {
+	em := messageOf(err)
+	if em == nil {
+		return err.Error()
+	}
+	if em.code != 0 {
+		return "0x" + strconv.FormatInt(int64(em.code), 16)
+	}
+	if fn := em.appFunction(); fn != "" {
+		return em.text + " @" + fn
+	}
+	return em.text
+}
+
+type fingerprintV2 struct{}
+
+func (fingerprintV2) Version() string { return "v2" }
+
+func (fingerprintV2) Fingerprint(err Error) string {
+	h := fnv.New64a()
+	if code := err.Code(); code != 0 {
+		h.Write([]byte("code=" + strconv.Itoa(code)))
+	} else {
+		fn := ""
+		if em := messageOf(err); em != nil {
+			fn = em.appFunction()
+		}
+		h.Write([]byte("text=" + err.Text() + "\nfunc=" + fn))
+	}
+	s := strconv.FormatUint(h.Sum64(), 16)
+	for len(s) < 16 {
+		s = "0" + s
+	}
+	return s
+}
@@ -0,0 +1,54 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFingerprinter(t *testing.T) {
+	defer SetStacks(true)
+	defer SetClock(nil)
+	defer SetFingerprinter(nil, 0)
+	SetFingerprinter(nil, 0)
+	SetStacks(false)
+	start := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	SetClock(fixedClock(start))
+	coded, plain := New(Desc{Code: 0x42, Text: "timeout"}), New("disk full")
+
+	if fp := Fingerprint(coded); fp != "0x42" {
+		t.Errorf(`Fingerprint(coded) = %q, want "0x42"`, fp)
+	}
+	if _, _, ok := PreviousFingerprint(coded); ok {
+		t.Errorf(`PreviousFingerprint(coded) reported a migration`)
+	}
+
+	// golden values: they must never change
+	SetFingerprinter(FingerprintV2, time.Hour)
+	if fp := Fingerprint(coded); fp != "784cfdffb7be1083" {
+		t.Errorf(`Fingerprint(coded) = %q`, fp)
+	}
+	if fp := Fingerprint(plain); fp != "2741163acdbac706" {
+		t.Errorf(`Fingerprint(plain) = %q`, fp)
+	}
+	if fp, v, ok := PreviousFingerprint(coded); fp != "0x42" || v != "v1" || !ok {
+		t.Errorf(`PreviousFingerprint(coded) = %q, %q, %t`, fp, v, ok)
+	}
+	SetClock(fixedClock(start.Add(time.Hour)))
+	if _, _, ok := PreviousFingerprint(coded); ok {
+		t.Errorf(`PreviousFingerprint(coded) reported a migration after its end`)
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import "fmt"
+
+// Newf returns an Error with the text formatted like by fmt.Sprintf. If the
+// format has a %w verb, the corresponding argument becomes the cause of the
+// result, returned by Unwrap, whose code and level it takes if it is an
+// Error; with several %w verbs, the cause joins their arguments.
+func Newf(format string, args ...interface{}) Error {
+	return newf(format, args)
+}
+
+// Errorf is the same as Newf.
+//
+// It is a drop-in replacement for fmt.Errorf.
+func Errorf(format string, args ...interface{}) Error {
+	return newf(format, args)
+}
+
+// newf implements Newf and Errorf.
+func newf(format string, args []interface{}) Error {
+	f := fmt.Errorf(format, args...)
+	e := newError(f.Error(), 5)
+	em, ok := e.(*errorMessage)
+	if !ok {
+		return e
+	}
+	switch u := f.(type) {
+	case interface{ Unwrap() error }:
+		em.cause = u.Unwrap()
+		if c, ok := em.cause.(Error); ok {
+			em.code, em.level = c.Code(), c.Level()
+		}
+	case interface{ Unwrap() []error }:
+		em.cause = f
+	}
+	if em.cause != nil {
+		classifyCanceled(em, em.cause)
+	}
+	return Default().finish(em)
+}
+
+// Format implements fmt.Formatter: the verbs %s and %v render the error as
+// Error does, %q renders it quoted, and %+v renders the full report of the
+// error, see Report.
+func (em *errorMessage) Format(s fmt.State, verb rune) {
+	switch {
+	case verb == 'v' && s.Flag('+'):
+		fmt.Fprint(s, em.report())
+	case verb == 'q':
+		fmt.Fprintf(s, "%q", em.Error())
+	default:
+		fmt.Fprint(s, em.Error())
+	}
+}
+
+// Format implements fmt.Formatter with the default renderings.
+func (d defaultRendering) Format(s fmt.State, verb rune) {
+	switch {
+	case verb == 'v' && s.Flag('+'):
+		fmt.Fprint(s, defaultReport(d.errorMessage, false))
+	case verb == 'q':
+		fmt.Fprintf(s, "%q", d.plainError())
+	default:
+		fmt.Fprint(s, d.plainError())
+	}
+}
+
+// sprint returns the default text of v, as formatted by fmt with "%v".
+func sprint(v interface{}) string {
+	return fmt.Sprint(v)
+}
+
+// typeName returns the name of the type of v, as formatted by fmt with "%T".
+func typeName(v interface{}) string {
+	return fmt.Sprintf("%T", v)
+}
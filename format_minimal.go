@@ -0,0 +1,89 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build errors_minimal
+// +build errors_minimal
+
+package errors
+
+import "strconv"
+
+// sprint returns the default text of v, like fmt with "%v" for the basic
+// types, errors and Stringers, and as the name of their type in parentheses
+// for others, without the reflection fmt relies on.
+func sprint(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "<nil>"
+	case string:
+		return v
+	case error:
+		return v.Error()
+	case interface{ String() string }:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case int32:
+		return strconv.FormatInt(int64(v), 10)
+	case uint:
+		return strconv.FormatUint(uint64(v), 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case uint32:
+		return strconv.FormatUint(uint64(v), 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32)
+	}
+	return "(" + typeName(v) + ")"
+}
+
+// typeName returns the name of the type of v, like fmt with "%T" for the
+// basic types, and "value" for others.
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "<nil>"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case int:
+		return "int"
+	case int64:
+		return "int64"
+	case int32:
+		return "int32"
+	case uint:
+		return "uint"
+	case uint64:
+		return "uint64"
+	case uint32:
+		return "uint32"
+	case float64:
+		return "float64"
+	case float32:
+		return "float32"
+	case []string:
+		return "[]string"
+	case map[string]interface{}:
+		return "map[string]interface {}"
+	}
+	return "value"
+}
@@ -0,0 +1,206 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Kinds of stack frames, see Frames.
+const (
+	// FRAME_APP is a frame of the application.
+	FRAME_APP int8 = iota
+	// FRAME_DEPENDENCY is a frame of a third-party module.
+	FRAME_DEPENDENCY
+	// FRAME_RUNTIME is a frame of the runtime or the standard library.
+	FRAME_RUNTIME
+)
+
+// rawStackPrefix starts the raw stack traces recorded by SetRawStacks.
+const rawStackPrefix = "rawstack "
+
+// Frame is a frame of a stack trace. PC is the program counter of the
+// frame, only known for raw stack traces, see SetRawStacks.
+type Frame struct {
+	Function string
+	File     string
+	Line     int
+	Kind     int8
+	PC       uintptr
+}
+
+var appPackages struct {
+	sync.RWMutex
+	prefixes []string
+}
+
+// SetAppPackages sets the import path prefixes of the packages of the
+// application, e.g. its module path, for classifying stack frames. Without
+// them, frames are classified by file location: those from the module cache
+// or a vendor directory belong to dependencies, all others to the
+// application. Packages of the standard library are always classified as
+// runtime.
+func SetAppPackages(prefixes ...string) {
+	appPackages.Lock()
+	appPackages.prefixes = append([]string(nil), prefixes...)
+	appPackages.Unlock()
+}
+
+// Frames returns the frames of the goroutine err was created in, innermost
+// first, as recorded by the first stack trace in its Info, or nil if it has
+// none. It gives programmatic access to the traces kept as text in place of
+// "debug.stack" elements. Frames whose function cannot be parsed are skipped.
+// Raw stack traces are resolved if they were recorded by the running
+// executable, and skipped otherwise.
+func Frames(err error) []Frame {
+	var frames []Frame
+	walkFrames(err, func(f Frame) bool {
+		frames = append(frames, f)
+		return true
+	})
+	return frames
+}
+
+// walkFrames calls fn with each frame returned by Frames for err, in order,
+// until fn returns false.
+func walkFrames(err error, fn func(f Frame) bool) {
+	em := messageOf(err)
+	if em == nil {
+		return
+	}
+	for _, s := range em.infoList() {
+		if strings.HasPrefix(s, rawStackPrefix) {
+			if frames := rawFrames(s); frames != nil {
+				for _, f := range frames {
+					if !fn(f) {
+						return
+					}
+				}
+				return
+			}
+		}
+		if strings.HasPrefix(s, "goroutine ") {
+			if i := strings.Index(s, "\n\n"); i >= 0 {
+				s = s[:i]
+			}
+			more := true
+			eachFrame(s, func(f Frame, _, _ int) {
+				if more {
+					more = fn(f)
+				}
+			})
+			return
+		}
+	}
+}
+
+// eachFrame calls fn with each frame of the stack trace st, as rendered by
+// runtime.Stack, and the lines of st it spans.
+func eachFrame(st string, fn func(f Frame, first, last int)) {
+	lines := strings.Split(st, "\n")
+	appPackages.RLock()
+	prefixes := appPackages.prefixes
+	appPackages.RUnlock()
+	for i := 0; i+1 < len(lines); i++ {
+		fun, loc := lines[i], lines[i+1]
+		if fun == "" || fun[0] == '\t' || strings.HasPrefix(fun, "goroutine ") || strings.HasPrefix(fun, "created by ") ||
+			!strings.HasPrefix(loc, "\t") {
+			continue
+		}
+		if j := strings.LastIndex(fun, "("); j > 0 && strings.HasSuffix(fun, ")") {
+			fun = fun[:j]
+		}
+		f := Frame{Function: fun}
+		loc = strings.TrimPrefix(loc, "\t")
+		if j := strings.LastIndex(loc, " +0x"); j >= 0 {
+			loc = loc[:j]
+		}
+		if j := strings.LastIndex(loc, ":"); j >= 0 {
+			f.File = loc[:j]
+			f.Line, _ = strconv.Atoi(loc[j+1:])
+		}
+		f.Kind = frameKind(f, prefixes)
+		fn(f, i, i+1)
+		i++
+	}
+}
+
+// frameKind classifies f, given the import path prefixes of the application.
+func frameKind(f Frame, prefixes []string) int8 {
+	pkg := f.Function
+	if i := strings.LastIndex(pkg, "/"); i >= 0 {
+		if j := strings.Index(pkg[i:], "."); j >= 0 {
+			pkg = pkg[:i+j]
+		}
+	} else if j := strings.Index(pkg, "."); j >= 0 {
+		pkg = pkg[:j]
+	}
+	first := pkg
+	if i := strings.Index(first, "/"); i >= 0 {
+		first = first[:i]
+	}
+	if !strings.Contains(first, ".") && pkg != "main" {
+		return FRAME_RUNTIME
+	}
+	if len(prefixes) > 0 {
+		for _, p := range prefixes {
+			if pkg == p || strings.HasPrefix(pkg, strings.TrimSuffix(p, "/")+"/") {
+				return FRAME_APP
+			}
+		}
+		return FRAME_DEPENDENCY
+	}
+	if strings.Contains(f.File, "/pkg/mod/") || strings.Contains(f.File, "/vendor/") {
+		return FRAME_DEPENDENCY
+	}
+	return FRAME_APP
+}
+
+// collapseFrames returns the stack trace st with each run of dependency
+// frames replaced by a line stating their number.
+func collapseFrames(st string) string {
+	lines := strings.Split(st, "\n")
+	skip := make([]bool, len(lines))
+	mark := make(map[int]int)
+	run := -1
+	eachFrame(st, func(f Frame, first, last int) {
+		if f.Kind != FRAME_DEPENDENCY {
+			run = -1
+			return
+		}
+		if run < 0 || run+2*mark[run] != first {
+			run = first
+		}
+		mark[run]++
+		skip[first], skip[last] = true, true
+	})
+	if len(mark) == 0 {
+		return st
+	}
+	res := make([]string, 0, len(lines))
+	for i, line := range lines {
+		if n := mark[i]; n == 1 {
+			res = append(res, "... 1 dependency frame")
+		} else if n > 1 {
+			res = append(res, "... "+strconv.Itoa(n)+" dependency frames")
+		}
+		if !skip[i] {
+			res = append(res, line)
+		}
+	}
+	return strings.Join(res, "\n")
+}
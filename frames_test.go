@@ -0,0 +1,84 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const sampleStack = `goroutine 7 [running]:
+example.com/shop/orders.(*Service).Place(0xc000010000, {0x0, 0x0})
+	/src/shop/orders/service.go:42 +0x1d
+github.com/lib/pq.(*conn).query(0xc000020000)
+	/home/u/go/pkg/mod/github.com/lib/pq@v1.10.9/conn.go:870 +0x2f
+database/sql.(*DB).QueryContext(...)
+	/usr/local/go/src/database/sql/sql.go:1700
+github.com/lib/pq.(*conn).retry(0xc000020000)
+	/home/u/go/pkg/mod/github.com/lib/pq@v1.10.9/conn.go:100 +0x2f
+github.com/jmoiron/sqlx.Get(0xc000020000)
+	/home/u/go/pkg/mod/github.com/jmoiron/sqlx@v1.3.5/sqlx.go:60 +0x2f
+main.main()
+	/src/shop/main.go:12 +0x25
+
+goroutine 8 [select]:
+net/http.(*persistConn).readLoop(0xc000030000)
+	/usr/local/go/src/net/http/transport.go:2205 +0x1d
+created by net/http.(*Transport).dialConn in goroutine 7
+	/usr/local/go/src/net/http/transport.go:1776 +0x16f1
+`
+
+func TestFrames(t *testing.T) {
+	defer SetAppPackages()
+	e := New(Desc{Text: "query failed", Info: []string{"note", sampleStack}})
+	want := []Frame{
+		{"example.com/shop/orders.(*Service).Place", "/src/shop/orders/service.go", 42, FRAME_APP, 0},
+		{"github.com/lib/pq.(*conn).query", "/home/u/go/pkg/mod/github.com/lib/pq@v1.10.9/conn.go", 870, FRAME_DEPENDENCY, 0},
+		{"database/sql.(*DB).QueryContext", "/usr/local/go/src/database/sql/sql.go", 1700, FRAME_RUNTIME, 0},
+		{"github.com/lib/pq.(*conn).retry", "/home/u/go/pkg/mod/github.com/lib/pq@v1.10.9/conn.go", 100, FRAME_DEPENDENCY, 0},
+		{"github.com/jmoiron/sqlx.Get", "/home/u/go/pkg/mod/github.com/jmoiron/sqlx@v1.3.5/sqlx.go", 60, FRAME_DEPENDENCY, 0},
+		{"main.main", "/src/shop/main.go", 12, FRAME_APP, 0},
+	}
+	if got := Frames(e); !reflect.DeepEqual(got, want) {
+		t.Errorf(`Frames(e) = %v, want %v`, got, want)
+	}
+	if fp := Fingerprint(e); fp != "query failed @example.com/shop/orders.(*Service).Place" {
+		t.Errorf(`Fingerprint(e) = %q`, fp)
+	}
+
+	SetAppPackages("github.com/jmoiron/sqlx")
+	if kinds := []int8{Frames(e)[0].Kind, Frames(e)[4].Kind}; kinds[0] != FRAME_DEPENDENCY || kinds[1] != FRAME_APP {
+		t.Errorf(`kinds with app packages = %v`, kinds)
+	}
+	if fp := Fingerprint(e); fp != "query failed @github.com/jmoiron/sqlx.Get" {
+		t.Errorf(`Fingerprint(e) with app packages = %q`, fp)
+	}
+}
+
+func TestCollapseFrames(t *testing.T) {
+	got := collapseFrames(sampleStack)
+	if strings.Contains(got, "pq") || strings.Contains(got, "sqlx") {
+		t.Errorf(`dependency frames not collapsed:\n%s`, got)
+	}
+	if strings.Count(got, "... 1 dependency frame\n") != 1 || strings.Count(got, "... 2 dependency frames") != 1 {
+		t.Errorf(`collapsed runs not counted:\n%s`, got)
+	}
+	for _, keep := range []string{"orders.(*Service).Place", "database/sql", "main.main()", "goroutine 8", "created by net/http"} {
+		if !strings.Contains(got, keep) {
+			t.Errorf(`%q dropped:\n%s`, keep, got)
+		}
+	}
+}
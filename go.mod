@@ -0,0 +1,3 @@
+module github.com/agext/errors
+
+go 1.13
@@ -0,0 +1,42 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+// Field keys used to record the goroutine an error was created on.
+const (
+	fieldGoroutineID = "goroutine.id"
+	fieldPprofLabels = "pprof.labels"
+)
+
+// Goroutine returns the ID of the goroutine err was created on, and the pprof
+// labels of the context it was created with by NewCtx, if recorded; see
+// SetGoroutineCapture. Correlating them with profiles collected at the same
+// time helps debugging concurrency issues.
+func Goroutine(err error) (id uint64, labels map[string]string) {
+	if em := messageOf(err); em != nil {
+		id, _ = em.field(fieldGoroutineID).(uint64)
+		labels, _ = em.field(fieldPprofLabels).(map[string]string)
+	}
+	return
+}
+
+// stampGoroutine records on em the ID of the current goroutine, if enabled.
+func stampGoroutine(em *errorMessage) {
+	if goroutinesEnabled() {
+		if id := goroutineID(); id != 0 {
+			em.setField(fieldGoroutineID, id)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.9 && !errors_minimal
+// +build go1.9,!errors_minimal
+
+package errors
+
+import (
+	"context"
+	"runtime/pprof"
+	"testing"
+)
+
+func TestGoroutine(t *testing.T) {
+	if id, labels := Goroutine(New("abc")); id != 0 || labels != nil {
+		t.Errorf(`Goroutine(err) = %d, %v without capture`, id, labels)
+	}
+
+	defer SetGoroutineCapture(false)
+	SetGoroutineCapture(true)
+	ids := make(chan uint64, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			id, _ := Goroutine(New("abc"))
+			ids <- id
+		}()
+	}
+	if a, b := <-ids, <-ids; a == 0 || b == 0 || a == b {
+		t.Errorf(`goroutine IDs = %d, %d, want distinct non-zero`, a, b)
+	}
+
+	pprof.Do(context.Background(), pprof.Labels("job", "reindex"), func(ctx context.Context) {
+		if _, labels := Goroutine(NewCtx(ctx, "abc")); labels["job"] != "reindex" {
+			t.Errorf(`Goroutine(NewCtx(ctx, ...)) labels = %v`, labels)
+		}
+	})
+}
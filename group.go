@@ -0,0 +1,251 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Group is an Error aggregating several errors, e.g. the failures of the
+// fields of a form, or of goroutines fanned out; it is safe for concurrent
+// use. Its level is the highest of its members, unless set with SetLevel;
+// its code, text and Info are its own. Its members are returned by Unwrap,
+// so that errors.Is and errors.As match any of them.
+type Group struct {
+	mu      sync.Mutex
+	text    string
+	code    int
+	level   int8
+	info    []string
+	members Errors
+}
+
+// NewGroup returns an empty Group with the given text.
+func NewGroup(text string) *Group {
+	return &Group{text: text}
+}
+
+// Append adds the non-nil errs to g, and returns g. Groups are added as
+// members, see Flatten, except g itself and the Groups holding it, which are
+// skipped; other joined errors add their members, as returned by FromJoined,
+// which converts plain errors.
+func (g *Group) Append(errs ...error) *Group {
+	var add Errors
+	for _, err := range errs {
+		if sub, ok := err.(*Group); ok {
+			if !sub.holds(g) {
+				add = append(add, sub)
+			}
+		} else {
+			add = append(add, FromJoined(err)...)
+		}
+	}
+	g.mu.Lock()
+	g.members = append(g.members, add...)
+	g.mu.Unlock()
+	return g
+}
+
+// Collect appends the errors received on ch to g until ch is closed, and
+// returns g; e.g. NewGroup("workers failed").Collect(FanIn(chans...)) gathers
+// the failures of fanned out goroutines.
+func (g *Group) Collect(ch <-chan Error) *Group {
+	for e := range ch {
+		g.Append(e)
+	}
+	return g
+}
+
+// holds reports whether g is sub or has it among its members, recursively.
+func (g *Group) holds(sub *Group) bool {
+	if g == sub {
+		return true
+	}
+	for _, e := range g.Errors() {
+		if m, ok := e.(*Group); ok && m.holds(sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// Len returns the number of members of g.
+func (g *Group) Len() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return len(g.members)
+}
+
+// Errors returns the members of g.
+func (g *Group) Errors() Errors {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append(Errors(nil), g.members...)
+}
+
+// Flatten returns the members of g, with those which are Groups replaced by
+// their own members, recursively.
+func (g *Group) Flatten() Errors {
+	var es Errors
+	for _, e := range g.Errors() {
+		if sub, ok := e.(*Group); ok {
+			es = append(es, sub.Flatten()...)
+		} else {
+			es = append(es, e)
+		}
+	}
+	return es
+}
+
+// ErrorOrNil returns g, or nil if g has no members, for returning a Group
+// as an error only when something failed.
+func (g *Group) ErrorOrNil() error {
+	if g.Len() == 0 {
+		return nil
+	}
+	return g
+}
+
+// Unwrap returns the members of g.
+func (g *Group) Unwrap() []error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	errs := make([]error, len(g.members))
+	for i, e := range g.members {
+		errs[i] = e
+	}
+	return errs
+}
+
+// Error returns the text of g followed by the number of its members and
+// their messages, like "validation failed (2 errors): a; b".
+func (g *Group) Error() string {
+	g.mu.Lock()
+	em := &errorMessage{code: g.code, text: g.text}
+	members := g.members[:len(g.members):len(g.members)]
+	g.mu.Unlock()
+	texts := make([]string, len(members))
+	for i, e := range members {
+		texts[i] = e.Error()
+	}
+	s := em.plainError()
+	switch len(texts) {
+	case 0:
+		return s
+	case 1:
+		return s + ": " + texts[0]
+	}
+	return s + " (" + strconv.Itoa(len(texts)) + " errors): " + strings.Join(texts, "; ")
+}
+
+// Level returns the level set with SetLevel, or else the highest level of
+// the members of g, or ERROR if it has none.
+func (g *Group) Level() int8 {
+	g.mu.Lock()
+	level := g.level
+	members := g.members[:len(g.members):len(g.members)]
+	g.mu.Unlock()
+	if level != 0 {
+		return level
+	}
+	if len(members) == 0 {
+		return ERROR
+	}
+	l := minLevel
+	for _, e := range members {
+		if e.Level() > l {
+			l = e.Level()
+		}
+	}
+	return l
+}
+
+// SetLevel sets the level of g, overriding the one of its members.
+func (g *Group) SetLevel(l int8) Error {
+	if l >= minLevel && l <= maxLevel {
+		g.mu.Lock()
+		g.level = l
+		g.mu.Unlock()
+	}
+	return g
+}
+
+// Code returns the code of g.
+func (g *Group) Code() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.code
+}
+
+// SetCode sets the code of g.
+func (g *Group) SetCode(code int) Error {
+	g.mu.Lock()
+	g.code = code
+	g.mu.Unlock()
+	return g
+}
+
+// Text returns the text of g, without its members.
+func (g *Group) Text() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.text
+}
+
+// SetText sets the text of g.
+func (g *Group) SetText(text string) Error {
+	g.mu.Lock()
+	g.text = text
+	g.mu.Unlock()
+	return g
+}
+
+// Info returns the Info of g.
+func (g *Group) Info() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]string(nil), g.info...)
+}
+
+// AddInfo adds (more) info to g, expanding "debug.stack" like the AddInfo
+// method of the errors returned by New.
+func (g *Group) AddInfo(s ...string) Error {
+	info := (&errorMessage{}).addInfo(2, s...).Info()
+	g.mu.Lock()
+	g.info = append(g.info, info...)
+	g.mu.Unlock()
+	return g
+}
+
+// Log logs g like an Error whose cause tree holds its members, named by
+// their position, and marks them handled.
+func (g *Group) Log(log Logger) Error {
+	level := g.Level()
+	g.mu.Lock()
+	em := newMessage(g.text)
+	em.code, em.level = g.code, level
+	em.appendInfo(g.info...)
+	members := g.members[:len(g.members):len(g.members)]
+	g.mu.Unlock()
+	width := len(strconv.Itoa(len(members)))
+	for i, e := range members {
+		em.branches = append(em.branches, branch{zeroPad(strconv.Itoa(i+1), width), e})
+		Handled(e)
+	}
+	em.Log(log)
+	return g
+}
@@ -0,0 +1,100 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.20
+// +build go1.20
+
+package errors
+
+import (
+	stderrors "errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestGroup(t *testing.T) {
+	g := NewGroup("validation failed")
+	if g.ErrorOrNil() != nil || g.Level() != ERROR {
+		t.Errorf(`empty group: ErrorOrNil() = %v, level %d`, g.ErrorOrNil(), g.Level())
+	}
+	name := New(Desc{Level: WARNING, Code: 0x42, Text: "name is empty"})
+	g.Append(name, nil)
+	if g.Error() != "validation failed: "+name.Error() || g.Level() != WARNING {
+		t.Errorf(`single member: %q, level %d`, g.Error(), g.Level())
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			g.Append(fmt.Errorf("field %d", i))
+		}(i)
+	}
+	wg.Wait()
+	if g.Len() != 11 || g.Level() != ERROR {
+		t.Errorf(`after concurrent appends: Len() = %d, level %d`, g.Len(), g.Level())
+	}
+	if !stderrors.Is(g, name) {
+		t.Errorf(`errors.Is(g, member) = false`)
+	}
+
+	outer := NewGroup("batch").Append(g, stderrors.Join(New("a"), New("b")))
+	outer.SetCode(0x99)
+	if n := len(outer.Flatten()); n != 13 {
+		t.Errorf(`Flatten() has %d errors, want 13`, n)
+	}
+	if outer.Error()[:len("batch (code: 0x0099) (3 errors): ")] != "batch (code: 0x0099) (3 errors): " {
+		t.Errorf(`outer.Error() = %q`, outer.Error())
+	}
+	if outer.SetLevel(FATAL); outer.Level() != FATAL {
+		t.Errorf(`SetLevel(FATAL) ignored`)
+	}
+
+	log := &mockLogger{}
+	if g.Log(log); !strings.Contains(log.log, "validation failed") || !IsHandled(name) {
+		t.Errorf(`Log logged %q, members handled %t`, log.log, IsHandled(name))
+	}
+
+	if g.Append(g, outer); g.Len() != 11 {
+		t.Errorf(`appending g or a group holding it: Len() = %d, want 11`, g.Len())
+	}
+	self := NewGroup("self")
+	self.Append(groupText{self})
+	if self.Error() != "self: self" || self.Level() != ERROR {
+		t.Errorf(`member rendering its group: %q`, self.Error())
+	}
+	if !minimalBuild && !strings.Contains(strings.Join(g.AddInfo("debug.stack").Info(), "\n"), "TestGroup") {
+		t.Errorf(`AddInfo("debug.stack") not expanded: %q`, g.Info())
+	}
+}
+
+// groupText is an error rendered as the text of a Group holding it.
+type groupText struct {
+	g *Group
+}
+
+func (e groupText) Error() string {
+	return e.g.Text()
+}
+
+func TestGroupValues(t *testing.T) {
+	g := NewGroup("parsing failed")
+	vals := Values(g, Ok(1), Err[int](New("bad")), Ok(3))
+	if len(vals) != 2 || vals[0] != 1 || vals[1] != 3 || g.Error() != "parsing failed: bad" {
+		t.Errorf(`Values() = %v, group %q`, vals, g.Error())
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+var unhandled struct {
+	sync.RWMutex
+	level Level
+}
+
+// Handled marks err and the errors it wraps as handled, for errors dropped on
+// purpose not to be reported as unhandled, see SetUnhandledDetection. Errors
+// logged by Log or queued by a Reporter are marked handled automatically,
+// along with the errors they wrap. It returns err.
+func Handled(err error) error {
+	markHandled(err)
+	return err
+}
+
+// markHandled marks err and the errors it wraps, through their Unwrap
+// methods, as handled.
+func markHandled(err error) {
+	for err != nil {
+		if em := messageOf(err); em != nil {
+			atomic.StoreUint32(&em.handled, 1)
+			err = em.cause
+			continue
+		}
+		switch u := err.(type) {
+		case interface{ Unwrap() error }:
+			err = u.Unwrap()
+		case interface{ Unwrap() []error }:
+			for _, m := range u.Unwrap() {
+				markHandled(m)
+			}
+			return
+		default:
+			return
+		}
+	}
+}
+
+// IsHandled reports whether err has been marked handled.
+func IsHandled(err error) bool {
+	em := messageOf(err)
+	return em != nil && atomic.LoadUint32(&em.handled) != 0
+}
+
+// SetUnhandledDetection sets the minimum level of the errors tracked until
+// they are handled; zero, the default, disables tracking. Tracked errors
+// which are garbage-collected without having been handled (logged, reported,
+// or marked with Handled) are sent to observers with EVENT_UNHANDLED, to
+// catch silently swallowed failures. Detection relies on finalizers, so it
+// happens at the pace of the garbage collector, and adds to its work.
+func SetUnhandledDetection(l Level) {
+	if l == 0 || l.Valid() {
+		unhandled.Lock()
+		unhandled.level = l
+		unhandled.Unlock()
+	}
+}
+
+// trackHandling arranges for em to be reported if it is dropped unhandled,
+// and accounts for its memory, see SetMemoryBudget.
+func trackHandling(em *errorMessage) {
+	unhandled.RLock()
+	level := unhandled.level
+	unhandled.RUnlock()
+	track := level != 0 && !Level(em.level).Less(level) && !em.informational()
+	size := accountMemory(em)
+	if !track && size == 0 {
+		return
+	}
+	runtime.SetFinalizer(em, func(em *errorMessage) {
+		if track && atomic.LoadUint32(&em.handled) == 0 {
+			notify(EVENT_UNHANDLED, em)
+		}
+		if size != 0 {
+			releaseMemory(size)
+		}
+	})
+}
@@ -0,0 +1,65 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestUnhandled(t *testing.T) {
+	dropped := make(chan string, 10)
+	remove := AddObserver(func(event int8, err Error) {
+		if event == EVENT_UNHANDLED {
+			dropped <- err.Text()
+		}
+	})
+	defer remove()
+	defer SetUnhandledDetection(0)
+	SetUnhandledDetection(LEVEL_ERROR)
+
+	func() {
+		New("swallowed")
+		New(Desc{Level: WARNING, Text: "below threshold"})
+		New("logged").Log(&mockLogger{})
+		Handled(New("handled"))
+		Wrap(New("wrapped by logged"), "logged wrapper").Log(&mockLogger{})
+		Handled(Wrap(New("wrapped by handled"), "handled wrapper"))
+	}()
+	if e := New("kept"); !IsHandled(Handled(e)) {
+		t.Errorf(`IsHandled(Handled(e)) = false`)
+	}
+	var got []string
+	deadline := time.After(5 * time.Second)
+	for len(got) == 0 {
+		runtime.GC()
+		select {
+		case text := <-dropped:
+			got = append(got, text)
+		case <-time.After(10 * time.Millisecond):
+		case <-deadline:
+			t.Fatalf(`no unhandled error reported`)
+		}
+	}
+	runtime.GC()
+	time.Sleep(20 * time.Millisecond)
+	for len(dropped) > 0 {
+		got = append(got, <-dropped)
+	}
+	if len(got) != 1 || got[0] != "swallowed" {
+		t.Errorf(`unhandled errors reported: %q`, got)
+	}
+}
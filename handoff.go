@@ -0,0 +1,94 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"runtime"
+	"strconv"
+)
+
+// fieldHandoffs is the field key recording the goroutine boundaries crossed
+// by an error.
+const fieldHandoffs = "handoffs"
+
+// Transfer prepares err to be sent to another goroutine, e.g. on a channel:
+// if err has no stack trace and stacks are enabled, see SetStacks, the trace
+// at the point of transfer is added, before the sending goroutine moves on;
+// and the sending goroutine and location are recorded. Paired with Receive,
+// it lets errors crossing goroutine boundaries show both sides. Plain errors
+// are converted; Transfer returns nil if err is nil.
+func Transfer(err error) Error {
+	e := from(err)
+	if e == nil {
+		return nil
+	}
+	if r, ok := e.(readOnly); ok {
+		r.deny("Transfer")
+		return r
+	}
+	em := messageOf(e)
+	if em == nil {
+		return e
+	}
+	if !hasStack(em.infoList()) {
+		em.addInfo(2, "debug.stack")
+	}
+	em.addHandoff("sent", 2)
+	return e
+}
+
+// Receive records the receiving goroutine and location on err, which was
+// sent by another goroutine after a call to Transfer, and returns it as an
+// Error. Plain errors are converted; Receive returns nil if err is nil.
+func Receive(err error) Error {
+	e := from(err)
+	if e == nil {
+		return nil
+	}
+	if r, ok := e.(readOnly); ok {
+		r.deny("Receive")
+		return r
+	}
+	if em := messageOf(e); em != nil {
+		em.addHandoff("received", 2)
+	}
+	return e
+}
+
+// Handoffs returns the goroutine boundaries crossed by err, as recorded by
+// Transfer and Receive, like "sent by goroutine 7 at main.go:42", in order.
+func Handoffs(err error) []string {
+	if em := messageOf(err); em != nil {
+		hs, _ := em.field(fieldHandoffs).([]string)
+		return append([]string(nil), hs...)
+	}
+	return nil
+}
+
+// addHandoff appends to the handoffs of em the current goroutine and the
+// location calldepth frames up.
+func (em *errorMessage) addHandoff(verb string, calldepth int) {
+	h := verb + " by goroutine "
+	if id := goroutineID(); id != 0 {
+		h += strconv.FormatUint(id, 10)
+	} else {
+		h += "?"
+	}
+	if _, file, line, ok := runtime.Caller(calldepth); ok {
+		h += " at " + file + ":" + strconv.Itoa(line)
+	}
+	hs, _ := em.field(fieldHandoffs).([]string)
+	em.setField(fieldHandoffs, append(hs[:len(hs):len(hs)], h))
+}
@@ -0,0 +1,50 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestHandoff(t *testing.T) {
+	if Transfer(nil) != nil || Receive(nil) != nil {
+		t.Errorf(`Transfer(nil) or Receive(nil) is not nil`)
+	}
+	ch := make(chan error)
+	go func() {
+		ch <- Transfer(New("failed"))
+	}()
+	e := Receive(<-ch)
+	hs := Handoffs(e)
+	if len(hs) != 2 || !strings.HasPrefix(hs[0], "sent by goroutine ") || !strings.HasPrefix(hs[1], "received by goroutine ") {
+		t.Fatalf(`Handoffs(e) = %q`, hs)
+	}
+	for _, h := range hs {
+		if !strings.Contains(h, "handoff_test.go:") {
+			t.Errorf(`handoff %q lacks the location`, h)
+		}
+	}
+	if hs[0][:strings.Index(hs[0], " at ")] == "sent by goroutine ?" {
+		return // minimal builds
+	}
+	if strings.Fields(hs[0])[3] == strings.Fields(hs[1])[3] {
+		t.Errorf(`same goroutine on both sides: %q`, hs)
+	}
+	if e := Transfer(fmt.Errorf("plain")); !hasStack(e.Info()) {
+		t.Errorf(`Transfer did not add a stack trace: %q`, e.Info())
+	}
+}
@@ -14,6 +14,8 @@
 
 package errors
 
+import "strings"
+
 // Error levels match logging levels in agext/log
 const (
 	WARNING int8 = iota + 2
@@ -30,6 +32,31 @@ const (
 	ERR_NEW_ARG int = iota
 )
 
+// Codes from RESERVED_CODES_MIN to RESERVED_CODES_MAX are reserved for the
+// predefined error codes of this package, which classify system failures;
+// applications must not use them, so that their errors never match these.
+const (
+	RESERVED_CODES_MIN = 0x7fff0000
+	RESERVED_CODES_MAX = 0x7fffffff
+)
+
+// Predefined error codes classifying system failures, in the reserved range
+const (
+	ERR_NOT_FOUND int = RESERVED_CODES_MIN + iota + 1
+	ERR_EXISTS
+	ERR_PERMISSION
+	ERR_INVALID
+	ERR_TIMEOUT
+	ERR_INTERRUPTED
+	ERR_UNAVAILABLE
+	ERR_RESOURCE
+	ERR_SYSTEM
+	ERR_IMMUTABLE
+	ERR_DECODE
+	ERR_EXEC
+	ERR_TLS
+)
+
 func levelName(l int8) string {
 	switch l {
 	case WARNING:
@@ -44,6 +71,17 @@ func levelName(l int8) string {
 	return "?"
 }
 
+// levelByName returns the level with the given name, or ERROR if the name is
+// unknown.
+func levelByName(name string) int8 {
+	for l := minLevel; l <= maxLevel; l++ {
+		if strings.EqualFold(name, levelName(l)) {
+			return l
+		}
+	}
+	return ERROR
+}
+
 // Logger defines the interface expected by the Log method of Error
 type Logger interface {
 	Fatal(...interface{})
@@ -0,0 +1,158 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Field keys used to describe the HTTP request and response an error
+// occurred in.
+const (
+	fieldHTTPMethod   = "http.method"
+	fieldHTTPPath     = "http.path"
+	fieldHTTPHeaders  = "http.headers"
+	fieldHTTPStatus   = "http.status"
+	fieldHTTPResponse = "http.response"
+)
+
+// redactedHeaders lists the request headers whose values are never captured.
+var redactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie"}
+
+// CaptureOptions configures the middleware returned by Capture.
+type CaptureOptions struct {
+	// Level is the minimum level of the errors the request and response are
+	// captured for.
+	Level Level
+	// Redact lists additional request headers whose values are not captured.
+	Redact []string
+	// MaxResponse is the maximum number of response body bytes captured
+	// (default 4096).
+	MaxResponse int
+	// Report, if not nil, is called with every error returned by the handler,
+	// after capturing.
+	Report func(Error)
+}
+
+// captureWriter records the status and the beginning of the body written to
+// an http.ResponseWriter.
+type captureWriter struct {
+	http.ResponseWriter
+	status   int
+	body     bytes.Buffer
+	max      int
+	hijacked bool
+}
+
+func (w *captureWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *captureWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if room := w.max - w.body.Len(); room > 0 {
+		if len(p) < room {
+			room = len(p)
+		}
+		w.body.Write(p[:room])
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Unwrap returns the underlying http.ResponseWriter, for
+// http.ResponseController.
+func (w *captureWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Flush sends the buffered data to the client, if the underlying
+// http.ResponseWriter supports it.
+func (w *captureWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+// Hijack lets the handler take over the connection, if the underlying
+// http.ResponseWriter supports it.
+func (w *captureWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		conn, rw, err := h.Hijack()
+		w.hijacked = err == nil
+		return conn, rw, err
+	}
+	return nil, nil, http.ErrNotSupported
+}
+
+// Capture returns an http.Handler calling h. When h returns an error at or
+// above opts.Level, a copy of the error is annotated with the request method,
+// path and headers (sensitive ones redacted), and a snapshot of the response
+// status and body written so far, and reported; the error returned by h is
+// left unchanged, as it may be shared, e.g. a package-level variable. If h has
+// not written a response, a bare 500 Internal Server Error is sent. Errors
+// that do not implement Error are converted first.
+func Capture(h func(http.ResponseWriter, *http.Request) error, opts CaptureOptions) http.Handler {
+	if opts.MaxResponse <= 0 {
+		opts.MaxResponse = 4096
+	}
+	redact := make(map[string]bool)
+	for _, name := range append(redactedHeaders, opts.Redact...) {
+		redact[http.CanonicalHeaderKey(name)] = true
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cw := &captureWriter{ResponseWriter: w, max: opts.MaxResponse}
+		e := from(h(cw, r))
+		if e == nil {
+			return
+		}
+		if cw.status == 0 && !cw.hijacked {
+			http.Error(cw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+		if em := ownMessage(e); em != nil && !Level(e.Level()).Less(opts.Level) {
+			e, em = copyMessage(e)
+			headers := make(map[string]string, len(r.Header))
+			for name, values := range r.Header {
+				if redact[name] {
+					headers[name] = "[REDACTED]"
+				} else {
+					headers[name] = strings.Join(values, ", ")
+				}
+			}
+			em.setField(fieldHTTPMethod, r.Method)
+			em.setField(fieldHTTPPath, r.URL.Path)
+			em.setField(fieldHTTPHeaders, headers)
+			em.setField(fieldHTTPStatus, cw.status)
+			em.setField(fieldHTTPResponse, cw.body.String())
+		}
+		if opts.Report != nil {
+			opts.Report(e)
+		}
+	})
+}
@@ -0,0 +1,182 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCapture(t *testing.T) {
+	var reported []Error
+	h := Capture(func(w http.ResponseWriter, r *http.Request) error {
+		switch r.URL.Path {
+		case "/warn":
+			return New(Desc{Level: WARNING, Text: "warn"})
+		case "/partial":
+			w.WriteHeader(http.StatusBadGateway)
+			io.WriteString(w, "upstream said no")
+			return New("partial")
+		case "/plain":
+			return fmt.Errorf("plain")
+		}
+		io.WriteString(w, "ok")
+		return nil
+	}, CaptureOptions{
+		Level:       LEVEL_ERROR,
+		Redact:      []string{"x-api-key"},
+		MaxResponse: 8,
+		Report:      func(e Error) { reported = append(reported, e) },
+	})
+
+	for _, path := range []string{"/", "/warn", "/partial", "/plain"} {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		req.Header.Set("X-Api-Key", "secret")
+		req.Header.Add("Accept", "text/html")
+		req.Header.Add("Accept", "text/plain")
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	if len(reported) != 3 {
+		t.Fatalf(`reported %d errors, want %d`, len(reported), 3)
+	}
+	if em := reported[0].(*errorMessage); em.field(fieldHTTPMethod) != nil {
+		t.Errorf(`warning below the capture level has fields %v`, em.fields)
+	}
+	em := reported[1].(*errorMessage)
+	for k, v := range map[string]interface{}{
+		fieldHTTPMethod:   "GET",
+		fieldHTTPPath:     "/partial",
+		fieldHTTPStatus:   http.StatusBadGateway,
+		fieldHTTPResponse: "upstream",
+	} {
+		if em.field(k) != v {
+			t.Errorf(`field %q = %v, want %v`, k, em.field(k), v)
+		}
+	}
+	headers := em.field(fieldHTTPHeaders).(map[string]string)
+	for k, v := range map[string]string{
+		"Authorization": "[REDACTED]",
+		"X-Api-Key":     "[REDACTED]",
+		"Accept":        "text/html, text/plain",
+	} {
+		if headers[k] != v {
+			t.Errorf(`header %q = %q, want %q`, k, headers[k], v)
+		}
+	}
+	if em := reported[2].(*errorMessage); em.text != "plain" || em.field(fieldHTTPStatus) != http.StatusInternalServerError {
+		t.Errorf(`plain error captured as %q with status %v`, em.text, em.field(fieldHTTPStatus))
+	}
+}
+
+func TestCaptureShared(t *testing.T) {
+	shared := New(Desc{Code: 0x42, Text: "unavailable"})
+	var mu sync.Mutex
+	paths := map[string]bool{}
+	h := Capture(func(w http.ResponseWriter, r *http.Request) error {
+		return shared
+	}, CaptureOptions{Report: func(e Error) {
+		mu.Lock()
+		paths[Fields(e)[fieldHTTPPath].(string)] = true
+		mu.Unlock()
+	}})
+	var wg sync.WaitGroup
+	for _, path := range []string{"/a", "/b"} {
+		wg.Add(1)
+		go func(path string) {
+			defer wg.Done()
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+		}(path)
+	}
+	wg.Wait()
+	if len(paths) != 2 || Fields(shared)[fieldHTTPPath] != nil {
+		t.Errorf(`reported paths %v, shared error annotated with %v`, paths, Fields(shared))
+	}
+}
+
+func TestCaptureWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Capture(func(w http.ResponseWriter, r *http.Request) error {
+		if u, ok := w.(interface{ Unwrap() http.ResponseWriter }); !ok || u.Unwrap() != rec {
+			t.Errorf(`Unwrap() does not return the underlying writer`)
+		}
+		f, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatalf(`writer is not an http.Flusher`)
+		}
+		io.WriteString(w, "partial")
+		f.Flush()
+		if _, _, err := w.(http.Hijacker).Hijack(); err != http.ErrNotSupported {
+			t.Errorf(`Hijack() error = %v, want http.ErrNotSupported`, err)
+		}
+		return nil
+	}, CaptureOptions{}).ServeHTTP(rec, httptest.NewRequest("GET", "/stream", nil))
+	if !rec.Flushed {
+		t.Errorf(`Flush not forwarded`)
+	}
+}
+
+func TestToErrorReporting(t *testing.T) {
+	var err Error
+	h := Capture(func(w http.ResponseWriter, r *http.Request) error {
+		return New("abc").AddInfo("debug.stack")
+	}, CaptureOptions{Report: func(e Error) { err = e }})
+	req := httptest.NewRequest("POST", "/users", nil)
+	req.Header.Set("User-Agent", "test")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	SetOrigin("svc", "")
+	defer SetOrigin("", "")
+
+	payload, e := ToErrorReporting(err, "", "1.0")
+	if e != nil {
+		t.Fatalf(`ToErrorReporting(err) error %v`, e)
+	}
+	var ev struct {
+		EventTime      string
+		ServiceContext struct{ Service, Version string }
+		Message        string
+		Context        struct {
+			HTTPRequest struct {
+				Method, URL, UserAgent string
+				ResponseStatusCode     int
+			}
+		}
+	}
+	json.Unmarshal(payload, &ev)
+	if ev.ServiceContext.Service != "" || ev.ServiceContext.Version != "1.0" {
+		t.Errorf(`serviceContext = %+v`, ev.ServiceContext)
+	}
+	if !strings.HasPrefix(ev.Message, "abc\n\ngoroutine ") || !strings.Contains(ev.Message, "TestToErrorReporting") {
+		t.Errorf(`message = %q`, ev.Message)
+	}
+	if r := ev.Context.HTTPRequest; r.Method != "POST" || r.URL != "/users" || r.UserAgent != "test" || r.ResponseStatusCode != 500 {
+		t.Errorf(`context.httpRequest = %+v`, r)
+	}
+
+	payload, _ = ToErrorReporting(New("xyz"), "", "")
+	json.Unmarshal(payload, &ev)
+	if ev.ServiceContext.Service != "svc" || !strings.Contains(ev.Message, "TestToErrorReporting") {
+		t.Errorf(`ToErrorReporting(New("xyz")) = %s`, payload)
+	}
+}
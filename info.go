@@ -0,0 +1,143 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import "strings"
+
+// infoKeyPrefix is the prefix of the keys that used to hold the Info elements
+// of errors among their fields, followed by their index. These keys remain
+// reserved, and hidden from the field accessors.
+const infoKeyPrefix = "info."
+
+// isInfoKey reports whether k is a reserved key of the form "info.N".
+func isInfoKey(k string) bool {
+	if !strings.HasPrefix(k, infoKeyPrefix) || len(k) == len(infoKeyPrefix) {
+		return false
+	}
+	for _, c := range k[len(infoKeyPrefix):] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// infoList returns the Info elements of em, in order. The result must not
+// be modified.
+func (em *errorMessage) infoList() []string {
+	return em.info
+}
+
+// appendInfo adds s to the Info elements of em.
+func (em *errorMessage) appendInfo(s ...string) {
+	em.info = append(em.info, s...)
+}
+
+// setInfo replaces the Info elements of em by s.
+func (em *errorMessage) setInfo(s []string) {
+	em.info = append([]string(nil), s...)
+}
+
+// userFields returns a copy of the fields of em, without those with a
+// reserved key, or nil if there are none.
+func (em *errorMessage) userFields() map[string]interface{} {
+	var fields map[string]interface{}
+	for k, v := range em.fields {
+		if !isInfoKey(k) {
+			if fields == nil {
+				fields = make(map[string]interface{}, len(em.fields))
+			}
+			fields[k] = v
+		}
+	}
+	return fields
+}
+
+// Fields returns a copy of the fields of err, or nil if it has none. Fields
+// are the primary store of the details of errors; Info elements are kept
+// apart, and only returned by Info.
+func Fields(err error) map[string]interface{} {
+	if em := messageOf(err); em != nil {
+		return em.userFields()
+	}
+	return nil
+}
+
+// Field returns the value of the field key of err, without copying the
+// others. The last result is false if err has no such field.
+func Field(err error, key string) (interface{}, bool) {
+	em := messageOf(err)
+	if em == nil || isInfoKey(key) {
+		return nil, false
+	}
+	v, ok := em.fields[key]
+	return v, ok
+}
+
+// WithField sets the field key of err to value, and returns err. Keys made of
+// "info." followed by digits are reserved.
+func WithField(err Error, key string, value interface{}) Error {
+	if r, ok := err.(readOnly); ok {
+		r.deny("WithField")
+		return r
+	}
+	if em := messageOf(err); em != nil && !isInfoKey(key) {
+		em.setField(key, value)
+	}
+	return err
+}
+
+// MigrateInfo turns the Info elements of err of the form "key: value" or
+// "key=value", where key is a word possibly containing dots or dashes, into
+// fields, unless err already has a field with that key, and returns err. It
+// helps moving code adding details with AddInfo to fields incrementally.
+func MigrateInfo(err Error) Error {
+	if r, ok := err.(readOnly); ok {
+		r.deny("MigrateInfo")
+		return r
+	}
+	em := messageOf(err)
+	if em == nil {
+		return err
+	}
+	var kept []string
+	for _, s := range em.infoList() {
+		if k, v, ok := splitInfo(s); ok && em.field(k) == nil {
+			em.setField(k, v)
+		} else {
+			kept = append(kept, s)
+		}
+	}
+	em.setInfo(kept)
+	return err
+}
+
+// splitInfo splits an Info element of the form "key: value" or "key=value".
+func splitInfo(s string) (key, value string, ok bool) {
+	i := strings.IndexAny(s, ":=")
+	if i <= 0 || strings.ContainsRune(s, '\n') {
+		return "", "", false
+	}
+	key = s[:i]
+	for j, c := range key {
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || j > 0 && (c >= '0' && c <= '9' || c == '.' || c == '-')) {
+			return "", "", false
+		}
+	}
+	if isInfoKey(key) {
+		return "", "", false
+	}
+	return key, strings.TrimSpace(s[i+1:]), true
+}
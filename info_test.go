@@ -0,0 +1,84 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestFieldsAndInfo(t *testing.T) {
+	lines := []string{"a", "b", "debug.stack"}
+	err := New(Desc{Text: "abc", Info: lines, Fields: map[string]interface{}{"user_id": 7}})
+	if lines[2] != "debug.stack" {
+		t.Errorf(`New modified Desc.Info: %q`, lines)
+	}
+	for i := 0; i < 10; i++ {
+		err.AddInfo(strconv.Itoa(i))
+	}
+	info := err.Info()
+	wantInfo := 13
+	if minimalBuild {
+		wantInfo = 12
+	}
+	if len(info) != wantInfo || info[0] != "a" || info[wantInfo-10] != "0" || info[wantInfo-1] != "9" {
+		t.Errorf(`err.Info() = %q`, info)
+	}
+	fields := Fields(WithField(err, "request_id", "r-1"))
+	if len(fields) != 2 || fields["user_id"] != 7 || fields["request_id"] != "r-1" {
+		t.Errorf(`Fields(err) = %v`, fields)
+	}
+	if v, ok := Field(err, "user_id"); !ok || v != 7 {
+		t.Errorf(`Field(err, "user_id") = %v, %v`, v, ok)
+	}
+	if _, ok := Field(err, "info.0"); ok {
+		t.Errorf(`Field(err, "info.0") returned an Info element`)
+	}
+	if WithField(err, "info.3", "x"); err.Info()[3] != info[3] {
+		t.Errorf(`WithField overwrote an Info element`)
+	}
+	info[0] = "x"
+	if err.Info()[0] != "a" {
+		t.Errorf(`modifying the result of Info changed err`)
+	}
+	err.(*errorMessage).clone().appendInfo("c")
+	if len(err.Info()) != wantInfo {
+		t.Errorf(`adding Info to a copy changed err.Info() to %q`, err.Info())
+	}
+	if Fields(New("abc")) != nil {
+		t.Errorf(`Fields(New("abc")) = %v`, Fields(New("abc")))
+	}
+
+	err = New(Desc{Text: "abc", Info: []string{"bucket: photos", "retry=3", "free text", "user_id: 8"}})
+	WithField(err, "user_id", 7)
+	MigrateInfo(err)
+	if info := err.Info(); len(info) != 2 || info[0] != "free text" || info[1] != "user_id: 8" {
+		t.Errorf(`err.Info() after MigrateInfo = %q`, info)
+	}
+	if fields := Fields(err); fields["bucket"] != "photos" || fields["retry"] != "3" || fields["user_id"] != 7 {
+		t.Errorf(`Fields(err) after MigrateInfo = %v`, fields)
+	}
+
+	var denied int
+	remove := AddObserver(func(event int8, err Error) {
+		if event == EVENT_READ_ONLY {
+			denied++
+		}
+	})
+	defer remove()
+	if WithField(ReadOnly(err), "k", "v"); denied != 1 || Fields(err)["k"] != nil {
+		t.Errorf(`WithField(ReadOnly(err)) was not denied`)
+	}
+}
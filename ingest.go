@@ -0,0 +1,248 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fieldIngestRemote is the field key recording the address of the client an
+// error was submitted by, see IngestHandler.
+const fieldIngestRemote = "ingest.remote_addr"
+
+// fieldIngestLevel is the field key recording the level an ingested error was
+// submitted with, when it was lowered to ERROR.
+const fieldIngestLevel = "ingest.level"
+
+// IngestOptions configures the handler returned by IngestHandler.
+type IngestOptions struct {
+	// Authorize, if not nil, reports whether r comes from a trusted client;
+	// other requests are refused with 403 Forbidden.
+	Authorize func(r *http.Request) bool
+	// MaxBody is the maximum size of a request body (default 1 MiB).
+	MaxBody int64
+	// MaxDecompressed is the maximum total size of the compressed errors of a
+	// request once decompressed (default 8 times MaxBody).
+	MaxDecompressed int64
+	// Rate is the number of errors accepted per second, on average, and Burst
+	// the number accepted at once (default Rate, at least 1), hence the
+	// maximum size of a batch: larger batches are refused with 413 Request
+	// Entity Too Large, and must be split by the client. Zero means no limit.
+	Rate  float64
+	Burst int
+	// Sanitize configures the sanitization of the errors accepted.
+	Sanitize SanitizeOptions
+	// Redact lists the fields whose values are replaced by "[REDACTED]".
+	Redact []string
+	// Report is called with every error accepted.
+	Report func(Error)
+}
+
+// IngestHandler returns an http.Handler accepting errors serialized by this
+// package (as by ToCloudEvent, without the envelope) from remote clients,
+// such as mobile or edge agents. A POST request carries one error, or a JSON
+// array of them. They are all refused with 400 Bad Request if one of them is
+// malformed, or has fields violating the registered schemas, see
+// RegisterFieldSchema; JSON numbers and strings are first converted to the
+// integers and times the schemas call for. Requests beyond the rate limit are
+// refused with 429 Too Many Requests, before being decoded. Accepted errors
+// are sanitized and redacted, get the field "ingest.remote_addr", and are
+// passed to opts.Report; the response is 202 Accepted. Remote clients cannot
+// trigger the handling of PANIC and FATAL conditions: such errors are lowered
+// to ERROR, with their original level in the field "ingest.level".
+func IngestHandler(opts IngestOptions) http.Handler {
+	if opts.MaxBody <= 0 {
+		opts.MaxBody = 1 << 20
+	}
+	if opts.MaxDecompressed <= 0 {
+		opts.MaxDecompressed = 8 * opts.MaxBody
+	}
+	var limiter *tokenBucket
+	if opts.Rate > 0 {
+		limiter = newTokenBucket(opts.Rate, opts.Burst)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		if opts.Authorize != nil && !opts.Authorize(r) {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		body, err := ioutil.ReadAll(io.LimitReader(r.Body, opts.MaxBody+1))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if int64(len(body)) > opts.MaxBody {
+			http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+			return
+		}
+		items, err := splitBatch(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if limiter != nil {
+			if len(items) > int(limiter.burst) {
+				http.Error(w, fmt.Sprintf("batch of %d errors exceeds the limit of %d, split it", len(items), int(limiter.burst)), http.StatusRequestEntityTooLarge)
+				return
+			}
+			if wait := limiter.take(len(items)); wait > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+		}
+		es, err := ingest(items, opts.MaxDecompressed)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, e := range es {
+			e = Sanitize(e, opts.Sanitize)
+			if em := ownMessage(e); em != nil {
+				redact(em, opts.Redact)
+				em.setField(fieldIngestRemote, r.RemoteAddr)
+			}
+			if opts.Report != nil {
+				opts.Report(e)
+			}
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
+
+// splitBatch returns the serialized errors of body, one error or a JSON array
+// of them, without decoding them.
+func splitBatch(body []byte) ([]json.RawMessage, error) {
+	items := []json.RawMessage{body}
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &items); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+// ingest decodes and checks the serialized errors items, whose compressed
+// content must not exceed maxDecompressed bytes in total once decompressed.
+func ingest(items []json.RawMessage, maxDecompressed int64) ([]Error, error) {
+	budget := maxDecompressed
+	es := make([]Error, 0, len(items))
+	for i, data := range items {
+		w, err := decodeWireLimit(data, &budget)
+		if err != nil {
+			return nil, fmt.Errorf("error #%d: %v", i, err)
+		}
+		if w.Text == "" || !validLevelName(w.Level) {
+			return nil, fmt.Errorf("error #%d: missing text or invalid level %q", i, w.Level)
+		}
+		em := fromWire(w).(*errorMessage)
+		if em.level > ERROR {
+			em.setField(fieldIngestLevel, levelName(em.level))
+			em.level = ERROR
+		}
+		normalizeFields(em)
+		if violations := fieldViolations(em); len(violations) > 0 {
+			return nil, fmt.Errorf("error #%d: %s", i, strings.Join(violations, "; "))
+		}
+		es = append(es, em)
+	}
+	return es, nil
+}
+
+// validLevelName reports whether name is the name of a level.
+func validLevelName(name string) bool {
+	for l := minLevel; l <= maxLevel; l++ {
+		if strings.EqualFold(name, levelName(l)) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeFields converts the fields of em decoded from JSON to the types
+// of their registered schemas, where possible: integral numbers to int64,
+// and RFC 3339 strings to time.Time.
+func normalizeFields(em *errorMessage) {
+	schemas.RLock()
+	defer schemas.RUnlock()
+	for k, v := range em.fields {
+		s := schemas.byName[k]
+		if s == nil {
+			continue
+		}
+		switch v := v.(type) {
+		case float64:
+			if s.Type == FIELD_INT && v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+				em.fields[k] = int64(v)
+			}
+		case string:
+			if s.Type == FIELD_TIME {
+				if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+					em.fields[k] = t
+				}
+			}
+		}
+	}
+}
+
+// tokenBucket limits the rate of events.
+type tokenBucket struct {
+	sync.Mutex
+	rate, burst, tokens float64
+	last                time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	b := float64(burst)
+	if b <= 0 {
+		b = math.Max(rate, 1)
+	}
+	return &tokenBucket{rate: rate, burst: b, tokens: b}
+}
+
+// take takes n tokens if available, and otherwise returns how long to wait
+// for them.
+func (b *tokenBucket) take(n int) time.Duration {
+	b.Lock()
+	defer b.Unlock()
+	t := now()
+	if !b.last.IsZero() {
+		b.tokens = math.Min(b.burst, b.tokens+t.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = t
+	if missing := float64(n) - b.tokens; missing > 0 {
+		return time.Duration(missing / b.rate * float64(time.Second))
+	}
+	b.tokens -= float64(n)
+	return 0
+}
@@ -0,0 +1,99 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIngestHandler(t *testing.T) {
+	defer SetClock(nil)
+	defer func() { schemas.byName, schemas.aliases = nil, nil }()
+	SetClock(fixedClock(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)))
+	RegisterFieldSchema(FieldSchema{Name: "attempt", Type: FIELD_INT})
+	var got []Error
+	h := IngestHandler(IngestOptions{
+		Authorize: func(r *http.Request) bool { return r.Header.Get("X-Agent") == "edge" },
+		Rate:      1,
+		Burst:     2,
+		Redact:    []string{"token"},
+		Report:    func(e Error) { got = append(got, e) },
+	})
+	post := func(method, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/errors", strings.NewReader(body))
+		r.Header.Set("X-Agent", "edge")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	start := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	for i, c := range []struct {
+		at           time.Duration
+		method, body string
+		status       int
+	}{
+		{0, "GET", "", http.StatusMethodNotAllowed},
+		{0, "POST", `{"level":"ERROR"}`, http.StatusBadRequest},
+		{10 * time.Second, "POST", `{"schema_version":1,"level":"ERROR","text":"x","fields":{"attempt":"two"}}`, http.StatusBadRequest},
+		{20 * time.Second, "POST", `[{"level":"ERROR","text":"x"},{"schema_version":99,"text":"y"}]`, http.StatusBadRequest},
+		{30 * time.Second, "POST", `{"schema_version":1,"level":"WARNING","code":7,"text":"sync\nfailed","fields":{"attempt":2,"token":"s3cr3t"}}`, http.StatusAccepted},
+		{30 * time.Second, "POST", `[{"level":"ERROR","text":"a"},{"level":"ERROR","text":"b"},{"level":"ERROR","text":"c"}]`, http.StatusRequestEntityTooLarge},
+		{30 * time.Second, "POST", `[{"level":"ERROR","text":"a"},{"level":"ERROR","text":"b"}]`, http.StatusTooManyRequests},
+		{40 * time.Second, "POST", `{"level":"FATAL","text":"remote crash"}`, http.StatusAccepted},
+	} {
+		SetClock(fixedClock(start.Add(c.at)))
+		if w := post(c.method, c.body); w.Code != c.status {
+			t.Errorf(`#%d: status %d, want %d: %s`, i, w.Code, c.status, w.Body)
+		}
+	}
+	if len(got) != 2 {
+		t.Fatalf(`%d errors reported, want 2`, len(got))
+	}
+	if got[1].Level() != ERROR || Fields(got[1])[fieldIngestLevel] != "FATAL" {
+		t.Errorf(`FATAL ingested as %s, fields %v`, levelName(got[1].Level()), Fields(got[1]))
+	}
+	e := got[0].(*errorMessage)
+	if e.Code() != 7 || e.Level() != WARNING || e.Text() != `sync\nfailed` {
+		t.Errorf(`got[0] = %#v`, e)
+	}
+	if e.fields["attempt"] != int64(2) || e.fields["token"] != "[REDACTED]" || e.fields[fieldIngestRemote] != "192.0.2.1:1234" {
+		t.Errorf(`got[0].fields = %v`, e.fields)
+	}
+
+	r := httptest.NewRequest("POST", "/errors", strings.NewReader(`{"level":"ERROR","text":"x"}`))
+	w := httptest.NewRecorder()
+	if h.ServeHTTP(w, r); w.Code != http.StatusForbidden {
+		t.Errorf(`unauthorized: status %d`, w.Code)
+	}
+
+	z, _ := Gzip.Compress([]byte(`{"level":"ERROR","text":"` + strings.Repeat("x", 1<<16) + `"}`))
+	bomb, _ := json.Marshal(map[string]compressedPayload{"compressed": {"gzip", z}})
+	h = IngestHandler(IngestOptions{MaxBody: 4096, Report: func(e Error) { got = append(got, e) }})
+	r = httptest.NewRequest("POST", "/errors", bytes.NewReader(bomb))
+	w = httptest.NewRecorder()
+	if h.ServeHTTP(w, r); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "size limit") {
+		t.Errorf(`decompression bomb: status %d: %s`, w.Code, w.Body)
+	}
+}
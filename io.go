@@ -0,0 +1,167 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"io"
+	"net"
+	"time"
+)
+
+// retryableIO reports whether an operation failing with err may succeed if
+// tried again.
+func retryableIO(err error) bool {
+	if err == io.ErrShortWrite {
+		return true
+	}
+	b, _ := causeBehavior(err)
+	return b.timeout || b.temporary
+}
+
+// fromTemplate returns a new Error built from desc, which is anything
+// accepted by New. A Desc is copied first, so that it can be reused.
+func fromTemplate(desc interface{}) Error {
+	switch d := desc.(type) {
+	case Desc:
+		d.Info = append([]string(nil), d.Info...)
+		return New(d)
+	case *Desc:
+		c := *d
+		c.Info = append([]string(nil), d.Info...)
+		return New(c)
+	}
+	return New(desc)
+}
+
+// ioError returns an Error built from desc describing the failure of op with
+// err, after n bytes have been transferred in total, and wrapping err.
+func ioError(desc interface{}, op string, err error, n int64) Error {
+	e := fromTemplate(desc).AddInfo(err.Error())
+	em := ownMessage(e)
+	if em == nil {
+		return e
+	}
+	em.cause = err
+	em.setField(fieldIOOp, op)
+	em.setField(fieldIOBytes, n)
+	em.setField(fieldRetryable, retryableIO(err))
+	return e
+}
+
+type reader struct {
+	r    io.Reader
+	desc interface{}
+	n    int64
+}
+
+// Reader returns an io.Reader reading from r, which converts read failures
+// into Errors built from desc (anything accepted by New). The Errors wrap the
+// original error, returned by their Unwrap method, and carry its text in
+// their Info, as well as the total number of bytes read and whether the
+// failure is retryable. io.EOF is returned as is.
+func Reader(r io.Reader, desc interface{}) io.Reader {
+	return &reader{r: r, desc: desc}
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	if err != nil && err != io.EOF {
+		return n, ioError(r.desc, "read", err, r.n)
+	}
+	return n, err
+}
+
+type writer struct {
+	w    io.Writer
+	desc interface{}
+	n    int64
+}
+
+// Writer returns an io.Writer writing to w, which converts write failures,
+// including short writes, into Errors built from desc the same way as Reader.
+func Writer(w io.Writer, desc interface{}) io.Writer {
+	return &writer{w: w, desc: desc}
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	if err == nil && n < len(p) {
+		err = io.ErrShortWrite
+	}
+	if err != nil {
+		return n, ioError(w.desc, "write", err, w.n)
+	}
+	return n, nil
+}
+
+// ClassifyIO returns an Error describing err, the failure of an I/O operation
+// started at start with the given deadline (which may be zero). If err, or
+// an error in its chain, is a timeout, the Error records the elapsed time and
+// the time allowed by the deadline. The phase of the operation (e.g. "dial",
+// "read" or "write") is recorded when err exposes it, as *net.OpError does.
+// If err already is an Error, a copy of it is annotated; otherwise the
+// returned Error wraps err. ClassifyIO returns nil if err is nil.
+func ClassifyIO(err error, start, deadline time.Time) Error {
+	if err == nil {
+		return nil
+	}
+	var em *errorMessage
+	e, ok := err.(Error)
+	if ok {
+		e, em = copyMessage(e)
+	} else {
+		em = newMessage(err.Error())
+		em.cause = err
+		e = wrapped(em, err)
+	}
+	if em == nil {
+		return e
+	}
+	if op := ioPhase(err); op != "" {
+		em.setField(fieldIOOp, op)
+	}
+	if b, _ := causeBehavior(err); b.timeout {
+		em.setField(fieldTimeout, true)
+		em.setField(fieldIOElapsed, now().Sub(start))
+		if !deadline.IsZero() {
+			em.setField(fieldIODeadline, deadline.Sub(start))
+		}
+	}
+	em.setField(fieldRetryable, retryableIO(err))
+	return e
+}
+
+// ioPhase returns the operation reported by the first *net.OpError in the
+// chain of err, or an empty string if there is none.
+func ioPhase(err error) string {
+	for err != nil {
+		if oe, ok := err.(*net.OpError); ok {
+			return oe.Op
+		}
+		u, ok := err.(interface {
+			Unwrap() error
+		})
+		if !ok {
+			break
+		}
+		err = u.Unwrap()
+	}
+	return ""
+}
@@ -0,0 +1,130 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+type shortWriter struct{}
+
+func (shortWriter) Write(p []byte) (int, error) {
+	return len(p) / 2, nil
+}
+
+func TestReader(t *testing.T) {
+	desc := &Desc{Code: 7, Text: "reading config"}
+	b, err := ioutil.ReadAll(Reader(strings.NewReader("abc"), desc))
+	if string(b) != "abc" || err != nil {
+		t.Errorf(`ReadAll(Reader("abc")) = %q, %v, want %q, <nil>`, b, err, "abc")
+	}
+
+	_, err = ioutil.ReadAll(Reader(iotest.TimeoutReader(iotest.OneByteReader(strings.NewReader("abc"))), desc))
+	e, ok := err.(Error)
+	if !ok {
+		t.Fatalf(`ReadAll(Reader(timeout)) error = %#v, want an Error`, err)
+	}
+	if e.Code() != 7 || e.Text() != "reading config" || len(e.Info()) != 1 || e.Info()[0] != iotest.ErrTimeout.Error() {
+		t.Errorf(`ReadAll(Reader(timeout)) error = %v, info %q`, e, e.Info())
+	}
+	if c := e.(interface{ Unwrap() error }).Unwrap(); c != iotest.ErrTimeout {
+		t.Errorf(`ReadAll(Reader(timeout)) error wraps %v`, c)
+	}
+	if n := e.(*errorMessage).field(fieldIOBytes); n != int64(1) {
+		t.Errorf(`bytes read = %v, want %d`, n, 1)
+	}
+	if Retryable(e) {
+		t.Errorf(`Retryable(%q) = true, want false`, e.Info()[0])
+	}
+	if len(desc.Info) != 0 {
+		t.Errorf(`Reader modified its descriptor: %q`, desc.Info)
+	}
+
+	_, err = Reader(iotest.ErrReader(timeoutError{}), "abc").Read(make([]byte, 1))
+	if !Retryable(err) {
+		t.Errorf(`Retryable(timeout) = false, want true`)
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	if n, err := Writer(&buf, "abc").Write([]byte("xyz")); n != 3 || err != nil {
+		t.Errorf(`Writer(&buf).Write("xyz") = %d, %v, want 3, <nil>`, n, err)
+	}
+	n, err := io.WriteString(Writer(shortWriter{}, "abc"), "xyz")
+	if n != 1 || !Retryable(err) {
+		t.Errorf(`Writer(shortWriter).Write("xyz") = %d, %v, want 1 and a retryable error`, n, err)
+	}
+	if e, ok := err.(Error); !ok || e.Info()[0] != io.ErrShortWrite.Error() {
+		t.Errorf(`Writer(shortWriter).Write("xyz") error = %#v, want an Error about a short write`, err)
+	}
+}
+
+func TestClassifyIO(t *testing.T) {
+	if ClassifyIO(nil, time.Time{}, time.Time{}) != nil {
+		t.Errorf(`ClassifyIO(nil) != nil`)
+	}
+	start := time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC)
+	SetClock(fixedClock(start.Add(3 * time.Second)))
+	defer SetClock(nil)
+	err := &url.Error{Op: "Get", URL: "http://example.com", Err: &net.OpError{Op: "dial", Net: "tcp", Err: timeoutError{}}}
+	em := messageOf(ClassifyIO(err, start, start.Add(2*time.Second)))
+	for k, v := range map[string]interface{}{
+		fieldIOOp:       "dial",
+		fieldTimeout:    true,
+		fieldIOElapsed:  3 * time.Second,
+		fieldIODeadline: 2 * time.Second,
+		fieldRetryable:  true,
+	} {
+		if em.field(k) != v {
+			t.Errorf(`ClassifyIO(...) field %q = %v, want %v`, k, em.field(k), v)
+		}
+	}
+
+	if em.cause != err {
+		t.Errorf(`ClassifyIO(err) does not wrap err`)
+	}
+	if em := messageOf(ClassifyIO(plainWrapper{timeoutError{}}, start, time.Time{})); em.field(fieldTimeout) != true {
+		t.Errorf(`ClassifyIO(...) missed a timeout in the chain of the error`)
+	}
+	if _, ok := ClassifyIO(timeoutError{}, start, time.Time{}).(interface{ Timeout() bool }); !ok {
+		t.Errorf(`ClassifyIO(timeout) lost the Timeout method`)
+	}
+
+	e := New("abc")
+	c := ClassifyIO(e, start, time.Time{})
+	if c == e || len(Fields(e)) != 0 {
+		t.Errorf(`ClassifyIO(e) annotated e in place`)
+	}
+	if c.Text() != "abc" || messageOf(c).field(fieldRetryable) != false || messageOf(c).field(fieldTimeout) != nil {
+		t.Errorf(`ClassifyIO(e) fields = %v`, Fields(c))
+	}
+	e = Wrap(timeoutError{}, "dialing")
+	c = ClassifyIO(e, start, time.Time{})
+	if _, ok := c.(netWrapper); !ok || len(Fields(e)) != 0 || messageOf(c).field(fieldTimeout) != true {
+		t.Errorf(`ClassifyIO(net error) = %#v`, c)
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.23
+// +build go1.23
+
+package errors
+
+import "iter"
+
+// Chain returns an iterator over err and the errors it wraps, through their
+// Unwrap methods, depth first, with the members of joined errors in order.
+func Chain(err error) iter.Seq[error] {
+	return func(yield func(error) bool) {
+		walkChain(err, yield)
+	}
+}
+
+// walkChain calls yield with err and the errors it wraps, and reports whether
+// yield always returned true.
+func walkChain(err error, yield func(error) bool) bool {
+	for err != nil {
+		if !yield(err) {
+			return false
+		}
+		switch u := err.(type) {
+		case interface{ Unwrap() error }:
+			err = u.Unwrap()
+		case interface{ Unwrap() []error }:
+			for _, m := range u.Unwrap() {
+				if !walkChain(m, yield) {
+					return false
+				}
+			}
+			return true
+		default:
+			return true
+		}
+	}
+	return true
+}
+
+// FromJoinedSeq returns an iterator over the members of err, as returned by
+// FromJoined, converting them one at a time.
+func FromJoinedSeq(err error) iter.Seq[Error] {
+	return func(yield func(Error) bool) {
+		walkJoined(err, yield)
+	}
+}
+
+// walkJoined calls yield with the members of err, and reports whether yield
+// always returned true.
+func walkJoined(err error, yield func(Error) bool) bool {
+	if err == nil {
+		return true
+	}
+	if j, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, m := range j.Unwrap() {
+			if !walkJoined(m, yield) {
+				return false
+			}
+		}
+		return true
+	}
+	return yield(from(err))
+}
+
+// FramesSeq returns an iterator over the frames returned by Frames for err,
+// parsing them as they are consumed.
+func FramesSeq(err error) iter.Seq[Frame] {
+	return func(yield func(Frame) bool) {
+		walkFrames(err, yield)
+	}
+}
+
+// All returns an iterator over the indexes and errors of es.
+func (es Errors) All() iter.Seq2[int, Error] {
+	return func(yield func(int, Error) bool) {
+		for i, e := range es {
+			if !yield(i, e) {
+				return
+			}
+		}
+	}
+}
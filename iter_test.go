@@ -0,0 +1,67 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.23
+// +build go1.23
+
+package errors
+
+import (
+	stderrors "errors"
+	"fmt"
+	"testing"
+)
+
+func TestIterators(t *testing.T) {
+	a, b := New("a"), fmt.Errorf("b")
+	err := Wrap(stderrors.Join(a, Wrap(b, "c")), "d")
+	var texts []string
+	for e := range Chain(err) {
+		texts = append(texts, e.Error())
+	}
+	if len(texts) != 5 || texts[0] != err.Error() || texts[2] != a.Error() || texts[4] != "b" {
+		t.Errorf(`Chain(err) yielded %q`, texts)
+	}
+	n := 0
+	for range Chain(err) {
+		if n++; n == 2 {
+			break
+		}
+	}
+
+	var members []string
+	for e := range FromJoinedSeq(stderrors.Join(a, stderrors.Join(b, nil))) {
+		members = append(members, e.Text())
+	}
+	if len(members) != 2 || members[0] != "a" || members[1] != "b" {
+		t.Errorf(`FromJoinedSeq yielded %q`, members)
+	}
+
+	e := New(Desc{Text: "query failed", Info: []string{sampleStack}})
+	var frames []Frame
+	for f := range FramesSeq(e) {
+		if frames = append(frames, f); len(frames) == 2 {
+			break
+		}
+	}
+	if all := Frames(e); len(frames) != 2 || frames[0] != all[0] || frames[1] != all[1] {
+		t.Errorf(`FramesSeq(e) yielded %v`, frames)
+	}
+
+	for i, e := range (Errors{a, New("x")}).All() {
+		if i == 0 && e != a {
+			t.Errorf(`Errors.All() yielded %v at 0`, e)
+		}
+	}
+}
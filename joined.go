@@ -0,0 +1,37 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+// FromJoined returns the members of err if it joins several errors, as
+// returned by errors.Join (i.e. if it has an Unwrap() []error method),
+// flattening nested joins, or else err alone. Members are converted like
+// by Classify, without classifiers, so that they keep their own code and
+// level instead of being flattened into a single text. It returns nil if err
+// is nil.
+func FromJoined(err error) Errors {
+	if err == nil {
+		return nil
+	}
+	if j, ok := err.(interface {
+		Unwrap() []error
+	}); ok {
+		var es Errors
+		for _, m := range j.Unwrap() {
+			es = append(es, FromJoined(m)...)
+		}
+		return es
+	}
+	return Errors{from(err)}
+}
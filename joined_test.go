@@ -0,0 +1,45 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.20
+// +build go1.20
+
+package errors
+
+import (
+	stderrors "errors"
+	"fmt"
+	"testing"
+)
+
+func TestFromJoined(t *testing.T) {
+	if FromJoined(nil) != nil {
+		t.Errorf(`FromJoined(nil) != nil`)
+	}
+	a := New(Desc{Code: 1, Level: WARNING, Text: "a"})
+	b := New(Desc{Code: 2, Level: FATAL, Text: "b"})
+	es := FromJoined(stderrors.Join(a, stderrors.Join(b, fmt.Errorf("c")), nil))
+	if len(es) != 3 || es[0] != a || es[1] != b || es[2].Text() != "c" || es[2].Level() != ERROR {
+		t.Errorf(`FromJoined(nested joins) = %v`, es)
+	}
+	if es := FromJoined(a); len(es) != 1 || es[0] != a {
+		t.Errorf(`FromJoined(a) = %v`, es)
+	}
+	if es := FromJoined(Errors{a, b}.Join()); len(es) != 2 || es[0] != a || es[1] != b {
+		t.Errorf(`FromJoined(Errors{a, b}.Join()) = %v`, es)
+	}
+	if Errors(nil).Join() != nil {
+		t.Errorf(`Errors(nil).Join() != nil`)
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DecodeLimits configures the strict decoding of serialized errors, see
+// FromJSONStrict. Zero values select the defaults.
+type DecodeLimits struct {
+	// MaxSize is the maximum size of the serialized form, both as given and
+	// once decompressed (default 1MiB).
+	MaxSize int
+	// MaxFields is the maximum number of fields of each error (default 256).
+	MaxFields int
+	// MaxBranches is the maximum number of errors in the cause tree
+	// (default 256).
+	MaxBranches int
+	// RegisteredCodes rejects the codes not registered with RegisterCode.
+	RegisteredCodes bool
+}
+
+// MarshalJSON implements json.Marshaler, encoding em in the serialized form
+// of this package, see SchemaVersion.
+func (em *errorMessage) MarshalJSON() ([]byte, error) {
+	return marshalWire(em)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, decoding the serialized form of
+// an error, of any supported schema version, possibly compressed.
+func (em *errorMessage) UnmarshalJSON(data []byte) error {
+	w, err := decodeWire(data)
+	if err != nil {
+		return err
+	}
+	*em = *fromWire(w).(*errorMessage)
+	return nil
+}
+
+// FromJSON returns the Error serialized as data, e.g. by json.Marshal in
+// another process, with its level, code, domain, texts, Info, fields, time
+// and cause tree, including the chain of errors wrapped with Wrap, returned
+// by Unwrap. Wrapped errors not implementing Error are restored as Errors
+// with the same text.
+func FromJSON(data []byte) (Error, error) {
+	w, err := decodeWire(data)
+	if err != nil {
+		return nil, err
+	}
+	return fromWire(w), nil
+}
+
+// FromJSONStrict is like FromJSON, but validates the serialized error against
+// limits and the registered field schemas, see RegisterFieldSchema, instead
+// of accepting whatever another process sent. Every error of the cause tree
+// must have a valid level and a text, and a registered code if required.
+// A failure is returned as an Error with the code ERR_DECODE, listing the
+// violations in its text and in the field "decode.violations".
+func FromJSONStrict(data []byte, limits DecodeLimits) (Error, error) {
+	if limits.MaxSize <= 0 {
+		limits.MaxSize = 1 << 20
+	}
+	if limits.MaxFields <= 0 {
+		limits.MaxFields = 256
+	}
+	if limits.MaxBranches <= 0 {
+		limits.MaxBranches = 256
+	}
+	if len(data) > limits.MaxSize {
+		return nil, decodeViolations([]string{fmt.Sprintf("size %d exceeds %d bytes", len(data), limits.MaxSize)})
+	}
+	budget := int64(limits.MaxSize)
+	w, err := decodeWireLimit(data, &budget)
+	if err == errDecompressedSize {
+		return nil, decodeViolations([]string{fmt.Sprintf("decompressed size exceeds %d bytes", limits.MaxSize)})
+	}
+	if err != nil {
+		return nil, WrapDecode(err, data)
+	}
+	branches := 0
+	violations := checkWire(w, "", limits, &branches)
+	if branches > limits.MaxBranches {
+		violations = append(violations, fmt.Sprintf("cause tree of %d errors exceeds %d", branches, limits.MaxBranches))
+	}
+	if len(violations) > 0 {
+		return nil, decodeViolations(violations)
+	}
+	return fromWire(w), nil
+}
+
+// checkWire returns the violations of limits and field schemas by w, and
+// those of its branches and cause, prefixed with path; it counts them.
+func checkWire(w *wireError, path string, limits DecodeLimits, branches *int) (violations []string) {
+	if !validLevelName(w.Level) {
+		violations = append(violations, fmt.Sprintf("%slevel %q is invalid", path, w.Level))
+	}
+	if w.Text == "" {
+		violations = append(violations, path+"text is missing")
+	}
+	if limits.RegisteredCodes && w.Code != 0 && CodeName(w.Code) == "" {
+		violations = append(violations, fmt.Sprintf("%scode %s is not registered", path, hexCode(w.Code)))
+	}
+	if len(w.Fields) > limits.MaxFields {
+		violations = append(violations, fmt.Sprintf("%s%d fields exceed %d", path, len(w.Fields), limits.MaxFields))
+	}
+	em := &errorMessage{code: w.Code, fields: w.Fields}
+	normalizeFields(em)
+	for _, v := range fieldViolations(em) {
+		violations = append(violations, path+v)
+	}
+	sort.Strings(violations)
+	names := make([]string, 0, len(w.Branches))
+	for name := range w.Branches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if b := w.Branches[name]; b != nil {
+			*branches++
+			violations = append(violations, checkWire(b, path+"branches."+name+": ", limits, branches)...)
+		}
+	}
+	if w.Cause != nil {
+		*branches++
+		violations = append(violations, checkWire(w.Cause, path+"cause: ", limits, branches)...)
+	}
+	return violations
+}
+
+// decodeViolations returns an Error with the code ERR_DECODE listing the
+// violations found while decoding a serialized error.
+func decodeViolations(violations []string) Error {
+	em := newMessage("invalid serialized error: " + strings.Join(violations, "; "))
+	em.code = ERR_DECODE
+	em.setField(fieldDecodeFormat, "errors")
+	em.setField(fieldDecodeViolations, violations)
+	return Default().finish(em)
+}
@@ -0,0 +1,119 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSON(t *testing.T) {
+	defer SetClock(nil)
+	SetClock(fixedClock(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)))
+	e := New(Desc{Code: 0x42, Level: WARNING, Text: "disk full", Info: []string{"volume /data"}})
+	WithField(e, "free", "0B")
+
+	data, err := json.Marshal(struct{ Err Error }{e})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v struct{ Err json.RawMessage }
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatal(err)
+	}
+	got, err := FromJSON(v.Err)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Code() != 0x42 || got.Level() != WARNING || got.Text() != "disk full" ||
+		!reflect.DeepEqual(got.Info(), e.Info()) || Fields(got)["free"] != "0B" || !Time(got).Equal(Time(e)) {
+		t.Errorf(`FromJSON(json.Marshal(e)) = %v, level %d, info %q, fields %v`, got, got.Level(), got.Info(), Fields(got))
+	}
+
+	var em errorMessage
+	if err := json.Unmarshal(v.Err, &em); err != nil || em.Text() != "disk full" {
+		t.Errorf(`json.Unmarshal into errorMessage = %v, %v`, em.Text(), err)
+	}
+	if _, err := FromJSON([]byte(`{"schema_version":99}`)); err == nil {
+		t.Errorf(`FromJSON(unsupported version) did not fail`)
+	}
+
+	wrapped := Wrap(Wrap(fmt.Errorf("connection reset"), Desc{Code: 0x43, Text: "reading"}), "loading")
+	data, err = json.Marshal(wrapped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err = FromJSON(data); err != nil || got.Error() != wrapped.Error() {
+		t.Fatalf(`FromJSON(wrapped) = %v, %v`, got, err)
+	}
+	var chain []string
+	for c := error(got); c != nil; {
+		chain = append(chain, c.Error())
+		u, ok := c.(interface{ Unwrap() error })
+		if !ok {
+			break
+		}
+		c = u.Unwrap()
+	}
+	if want := []string{"loading: reading: connection reset (code: 0x0043)", "reading: connection reset (code: 0x0043)", "connection reset"}; !reflect.DeepEqual(chain, want) {
+		t.Errorf(`chain of FromJSON(wrapped) = %q, want %q`, chain, want)
+	}
+}
+
+func TestFromJSONStrict(t *testing.T) {
+	defer func() { schemas.byName, schemas.aliases = nil, nil }()
+	RegisterFieldSchema(FieldSchema{Name: "retries", Type: FIELD_INT})
+	RegisterCode(0x42, "DISK_FULL")
+	defer RegisterCode(0x42, "")
+
+	if e, err := FromJSONStrict([]byte(`{"level":"ERROR","code":66,"text":"disk full","fields":{"retries":3}}`), DecodeLimits{RegisteredCodes: true}); err != nil || e.Code() != 0x42 {
+		t.Errorf(`FromJSONStrict(valid) = %v, %v`, e, err)
+	} else if v, _ := Field(e, "retries"); v != int64(3) {
+		t.Errorf(`FromJSONStrict(valid) field retries = %#v`, v)
+	}
+
+	_, err := FromJSONStrict([]byte(`{"level":"SEVERE","code":67,"text":"x","fields":{"retries":"many"},`+
+		`"branches":{"db":{"level":"ERROR","text":""}},"cause":{"level":"ERROR","text":""}}`), DecodeLimits{RegisteredCodes: true})
+	want := []string{
+		`code 0x0043 is not registered`,
+		`field "retries" is string, not integer`,
+		`level "SEVERE" is invalid`,
+		`branches.db: text is missing`,
+		`cause: text is missing`,
+	}
+	if e, ok := err.(Error); !ok || e.Code() != ERR_DECODE || !reflect.DeepEqual(Fields(e)["decode.violations"], want) {
+		t.Errorf(`FromJSONStrict(invalid) = %v, violations %q`, err, Fields(err)["decode.violations"])
+	}
+	if _, err := FromJSONStrict([]byte(`{"level":"ERROR","text":"`+strings.Repeat("a", 100)+`"}`), DecodeLimits{MaxSize: 64}); err == nil ||
+		!strings.Contains(err.Error(), "exceeds 64 bytes") {
+		t.Errorf(`FromJSONStrict(too large) = %v`, err)
+	}
+	z, _ := Gzip.Compress([]byte(`{"level":"ERROR","text":"` + strings.Repeat("a", 1<<20) + `"}`))
+	bomb, _ := json.Marshal(map[string]compressedPayload{"compressed": {"gzip", z}})
+	if _, err := FromJSONStrict(bomb, DecodeLimits{MaxSize: 1 << 16}); err == nil ||
+		!strings.Contains(err.Error(), "decompressed size exceeds 65536 bytes") {
+		t.Errorf(`FromJSONStrict(%d bytes decompressing to 1MiB) = %v`, len(bomb), err)
+	}
+	if _, err := FromJSONStrict([]byte(`{"level":`), DecodeLimits{}); Fields(err)["decode.format"] != "json" {
+		t.Errorf(`FromJSONStrict(truncated) = %v`, err)
+	}
+}
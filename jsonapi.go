@@ -0,0 +1,163 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ShapeOptions configures the rendering of validation errors by ToJSONAPI
+// and ToOpenAPI.
+type ShapeOptions struct {
+	// FieldKey is the key of the field holding the invalid input, either as
+	// an attribute name or as a JSON pointer (default "field").
+	FieldKey string
+	// Names of the members of the document rendered by ToOpenAPI: the list of
+	// errors (default "errors"), and the code, message and input field of
+	// each (default "code", "message" and "field").
+	Errors, Code, Message, Field string
+}
+
+func (o *ShapeOptions) defaults() {
+	for _, d := range []struct {
+		p   *string
+		def string
+	}{
+		{&o.FieldKey, "field"},
+		{&o.Errors, "errors"},
+		{&o.Code, "code"},
+		{&o.Message, "message"},
+		{&o.Field, "field"},
+	} {
+		if *d.p == "" {
+			*d.p = d.def
+		}
+	}
+}
+
+type jsonAPIError struct {
+	ID     string         `json:"id,omitempty"`
+	Status string         `json:"status,omitempty"`
+	Code   string         `json:"code,omitempty"`
+	Title  string         `json:"title,omitempty"`
+	Detail string         `json:"detail"`
+	Source *jsonAPISource `json:"source,omitempty"`
+}
+
+type jsonAPISource struct {
+	Pointer string `json:"pointer"`
+}
+
+// ToJSONAPI returns errs as a JSON:API error document, for a response with
+// the given HTTP status, if not zero. Each error object has the support
+// reference of the error as id, the name of its code as code, its public text
+// (or the generic text of the status, since the text of errors is internal) as
+// detail, and, if the error has the field named by opts.FieldKey, a source
+// pointer to the invalid attribute.
+func ToJSONAPI(status int, opts ShapeOptions, errs ...error) ([]byte, error) {
+	opts.defaults()
+	doc := struct {
+		Errors []jsonAPIError `json:"errors"`
+	}{make([]jsonAPIError, 0, len(errs))}
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		o := jsonAPIError{
+			ID:     Reference(err),
+			Code:   shapeCode(err),
+			Detail: shapeMessage(err, status),
+		}
+		if status != 0 {
+			o.Status = strconv.Itoa(status)
+		}
+		if f := shapeField(err, opts.FieldKey); f != "" {
+			if !strings.HasPrefix(f, "/") {
+				f = "/data/attributes/" + f
+			}
+			o.Source = &jsonAPISource{f}
+		}
+		doc.Errors = append(doc.Errors, o)
+	}
+	return json.Marshal(doc)
+}
+
+// ToOpenAPI returns errs as a JSON document of the shape described by opts,
+// typically the one of the error responses in an OpenAPI description: an
+// object holding a list of objects, each with the code, message and invalid
+// input field of an error. The message is the public text of the error, or
+// the generic text of the status 500 if it has none.
+func ToOpenAPI(opts ShapeOptions, errs ...error) ([]byte, error) {
+	opts.defaults()
+	list := make([]map[string]string, 0, len(errs))
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		o := map[string]string{opts.Message: shapeMessage(err, 0)}
+		if c := shapeCode(err); c != "" {
+			o[opts.Code] = c
+		}
+		if f := shapeField(err, opts.FieldKey); f != "" {
+			o[opts.Field] = f
+		}
+		list = append(list, o)
+	}
+	return json.Marshal(map[string]interface{}{opts.Errors: list})
+}
+
+// shapeCode returns the name of the code of err, its hexadecimal value if
+// unregistered, or an empty string if err has no code.
+func shapeCode(err error) string {
+	e, ok := err.(Error)
+	if !ok || e.Code() == 0 {
+		return ""
+	}
+	if name := CodeName(e.Code()); name != "" {
+		return name
+	}
+	return fmt.Sprintf("0x%04x", e.Code())
+}
+
+// shapeMessage returns the public text of err, or the generic text of the
+// HTTP status (500 if zero) if it has none: the text of errors is internal.
+func shapeMessage(err error, status int) string {
+	if em := messageOf(err); em != nil {
+		checkInvariants(em, "externalized")
+		if public := em.publicText(); public != "" {
+			return public
+		}
+	}
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+	return http.StatusText(status)
+}
+
+// shapeField returns the string value of the field of err with the given key.
+func shapeField(err error, key string) string {
+	if em := messageOf(err); em != nil {
+		s, _ := em.field(key).(string)
+		return s
+	}
+	return ""
+}
@@ -0,0 +1,46 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestToJSONAPI(t *testing.T) {
+	errs := []error{
+		New(Desc{Code: ERR_INVALID, Text: "empty name in form", PublicText: "name is required", Fields: map[string]interface{}{"field": "name"}}),
+		New(Desc{Code: 0x7f01, Text: "x", PublicText: "Bad address", Fields: map[string]interface{}{"field": "/data/relationships/address"}}),
+		nil,
+		fmt.Errorf("oops"),
+	}
+	data, err := ToJSONAPI(422, ShapeOptions{}, errs...)
+	want := `{"errors":[` +
+		`{"status":"422","code":"0x7fff0004","detail":"name is required","source":{"pointer":"/data/attributes/name"}},` +
+		`{"status":"422","code":"0x7f01","detail":"Bad address","source":{"pointer":"/data/relationships/address"}},` +
+		`{"status":"422","detail":"Unprocessable Entity"}]}`
+	if err != nil || string(data) != want {
+		t.Errorf(`ToJSONAPI(422, ...) = %s, %v, want %s`, data, err, want)
+	}
+
+	data, err = ToOpenAPI(ShapeOptions{Errors: "violations", Message: "msg", Field: "path"}, errs[0], New("db: connection refused"))
+	want = `{"violations":[{"code":"0x7fff0004","msg":"name is required","path":"name"},{"msg":"Internal Server Error"}]}`
+	if err != nil || string(data) != want {
+		t.Errorf(`ToOpenAPI(...) = %s, %v, want %s`, data, err, want)
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.9 && !errors_minimal
+// +build go1.9,!errors_minimal
+
+package errors
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+// stampLabels records on em the pprof labels of ctx, if any and enabled.
+func stampLabels(ctx context.Context, em *errorMessage) {
+	if !goroutinesEnabled() {
+		return
+	}
+	var labels map[string]string
+	pprof.ForLabels(ctx, func(k, v string) bool {
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		labels[k] = v
+		return true
+	})
+	if labels != nil {
+		em.setField(fieldPprofLabels, labels)
+	}
+}
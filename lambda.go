@@ -0,0 +1,99 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// lambdaError is the AWS Lambda function error contract, extended with the
+// classification of the error so that it survives the invocation boundary.
+type lambdaError struct {
+	ErrorType    string                 `json:"errorType"`
+	ErrorMessage string                 `json:"errorMessage"`
+	StackTrace   []string               `json:"stackTrace,omitempty"`
+	Level        string                 `json:"errorLevel,omitempty"`
+	Code         int                    `json:"errorCode,omitempty"`
+	Fields       map[string]interface{} `json:"errorFields,omitempty"`
+}
+
+// ToLambda renders err following the AWS Lambda function error contract: the
+// errorType is the name of its code (its hexadecimal value if unregistered,
+// or "Error" without a code) and the errorMessage is its public text, or its
+// Error() text if it has none. The stackTrace lists the frames of err, see
+// Frames, as "function (file:line)". The level, code and fields of err are
+// added as errorLevel, errorCode and errorFields, so that FromLambda can
+// restore them.
+func ToLambda(err Error) ([]byte, error) {
+	le := lambdaError{
+		ErrorType:    "Error",
+		ErrorMessage: PublicText(err),
+		Level:        levelName(err.Level()),
+		Code:         err.Code(),
+	}
+	if le.Code != 0 {
+		if le.ErrorType = CodeName(le.Code); le.ErrorType == "" {
+			le.ErrorType = fmt.Sprintf("0x%04x", le.Code)
+		}
+	}
+	if le.ErrorMessage == "" {
+		le.ErrorMessage = err.Error()
+	}
+	if em := messageOf(err); em != nil {
+		le.Fields = em.userFields()
+	}
+	for _, f := range Frames(err) {
+		le.StackTrace = append(le.StackTrace, fmt.Sprintf("%s (%s:%d)", f.Function, f.File, f.Line))
+	}
+	return json.Marshal(le)
+}
+
+// FromLambda returns the Error described by a Lambda function error payload.
+// Payloads not produced by ToLambda are accepted too: the code is then looked
+// up by errorType in the code registry, and the level defaults to ERROR.
+func FromLambda(payload []byte) (Error, error) {
+	var le lambdaError
+	if err := json.Unmarshal(payload, &le); err != nil {
+		return nil, err
+	}
+	if le.ErrorType == "" && le.ErrorMessage == "" {
+		return nil, fmt.Errorf("not a Lambda function error")
+	}
+	em := &errorMessage{
+		level:  levelByName(le.Level),
+		code:   le.Code,
+		text:   le.ErrorMessage,
+		fields: le.Fields,
+	}
+	if em.code == 0 {
+		if code, ok := CodeByName(le.ErrorType); ok {
+			em.code = code
+		} else if strings.HasPrefix(le.ErrorType, "0x") {
+			if code, err := strconv.ParseInt(le.ErrorType[2:], 16, 0); err == nil {
+				em.code = int(code)
+			}
+		}
+	}
+	if len(le.StackTrace) > 0 {
+		em.setInfo([]string{strings.Join(le.StackTrace, "\n")})
+	}
+	return em, nil
+}
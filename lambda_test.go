@@ -0,0 +1,82 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestLambda(t *testing.T) {
+	RegisterCode(0x2104, "StorageNotFound")
+	defer RegisterCode(0x2104, "")
+	SetOrigin("svc", "")
+	err := New(Desc{Level: WARNING, Code: 0x2104, Text: "no row in t_users", PublicText: "No such user."})
+	SetOrigin("", "")
+
+	payload, e := ToLambda(err)
+	if e != nil {
+		t.Fatalf(`ToLambda(err) error %v`, e)
+	}
+	var le map[string]interface{}
+	json.Unmarshal(payload, &le)
+	if le["errorType"] != "StorageNotFound" || le["errorMessage"] != "No such user." {
+		t.Errorf(`ToLambda(err) = %s`, payload)
+	}
+	got, e := FromLambda(payload)
+	if e != nil {
+		t.Fatalf(`FromLambda(...) error %v`, e)
+	}
+	if got.Level() != WARNING || got.Code() != 0x2104 || got.Text() != "No such user." {
+		t.Errorf(`FromLambda(ToLambda(err)) = %s %v`, levelName(got.Level()), got)
+	}
+	if s, _ := Origin(got); s != "svc" {
+		t.Errorf(`FromLambda(ToLambda(err)) origin = %q, want %q`, s, "svc")
+	}
+	if le["stackTrace"] != nil {
+		t.Errorf(`ToLambda(err without stack) stackTrace = %v`, le["stackTrace"])
+	}
+
+	payload, _ = ToLambda(New(Desc{Text: "crashed", Info: []string{"debug.stack"}}))
+	var traced lambdaError
+	json.Unmarshal(payload, &traced)
+	if len(traced.StackTrace) == 0 || !strings.Contains(traced.StackTrace[0], "TestLambda (") {
+		t.Errorf(`ToLambda(err with stack) stackTrace = %q`, traced.StackTrace)
+	}
+	if got, _ = FromLambda(payload); len(got.Info()) != 1 || !strings.Contains(got.Info()[0], "TestLambda (") {
+		t.Errorf(`FromLambda(ToLambda(err with stack)) info = %q`, got.Info())
+	}
+
+	for payload, want := range map[string]struct {
+		code int
+		text string
+	}{
+		`{"errorType":"StorageNotFound","errorMessage":"gone"}`:              {0x2104, "gone"},
+		`{"errorType":"0x0017","errorMessage":"abc","stackTrace":["a","b"]}`: {0x17, "abc"},
+		`{"errorType":"*errors.errorString","errorMessage":"plain"}`:         {0, "plain"},
+	} {
+		got, e := FromLambda([]byte(payload))
+		if e != nil || got.Level() != ERROR || got.Code() != want.code || got.Text() != want.text {
+			t.Errorf(`FromLambda(%s) = %v, %v`, payload, got, e)
+		}
+	}
+	if _, e = FromLambda([]byte(`{}`)); e == nil {
+		t.Errorf(`FromLambda({}) did not fail`)
+	}
+}
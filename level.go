@@ -0,0 +1,68 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Level is an error level. The WARNING, ERROR, PANIC and FATAL constants are
+// kept as int8 for compatibility; newer APIs take a Level, see the LEVEL_*
+// constants.
+type Level int8
+
+// Error levels, as Level values.
+const (
+	LEVEL_WARNING = Level(WARNING)
+	LEVEL_ERROR   = Level(ERROR)
+	LEVEL_PANIC   = Level(PANIC)
+	LEVEL_FATAL   = Level(FATAL)
+)
+
+// String returns the name of the level, e.g. "WARNING", or "?" if invalid.
+func (l Level) String() string {
+	return levelName(int8(l))
+}
+
+// Valid reports whether l is one of the defined levels.
+func (l Level) Valid() bool {
+	return int8(l) >= minLevel && int8(l) <= maxLevel
+}
+
+// Less reports whether l is less severe than m.
+func (l Level) Less(m Level) bool {
+	return l < m
+}
+
+// MarshalText implements encoding.TextMarshaler, encoding l by name.
+func (l Level) MarshalText() ([]byte, error) {
+	if !l.Valid() {
+		return nil, New(Desc{Code: ERR_INVALID, Text: "invalid level " + strconv.Itoa(int(l))})
+	}
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, accepting level names in
+// any case.
+func (l *Level) UnmarshalText(text []byte) error {
+	for v := minLevel; v <= maxLevel; v++ {
+		if strings.EqualFold(string(text), levelName(v)) {
+			*l = Level(v)
+			return nil
+		}
+	}
+	return New(Desc{Code: ERR_INVALID, Text: "unknown level " + strconv.Quote(string(text))})
+}
@@ -0,0 +1,39 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import "testing"
+
+func TestLevel(t *testing.T) {
+	if got := LEVEL_PANIC.String(); got != "PANIC" {
+		t.Errorf(`LEVEL_PANIC.String() = %q, want "PANIC"`, got)
+	}
+	if Level(0).Valid() || !LEVEL_WARNING.Valid() {
+		t.Errorf(`Valid() is wrong`)
+	}
+	if _, err := Level(9).MarshalText(); err == nil {
+		t.Errorf(`Level(9).MarshalText() did not fail`)
+	}
+	var l Level
+	if err := l.UnmarshalText([]byte("fatal")); err != nil || l != LEVEL_FATAL {
+		t.Errorf(`UnmarshalText("fatal") = %v, %v, want FATAL, nil`, l, err)
+	}
+	if err := l.UnmarshalText([]byte("debug")); err == nil {
+		t.Errorf(`UnmarshalText("debug") did not fail`)
+	}
+	if !LEVEL_WARNING.Less(LEVEL_ERROR) || !LEVEL_ERROR.Less(LEVEL_FATAL) || LEVEL_FATAL.Less(LEVEL_WARNING) {
+		t.Errorf(`Less does not order levels by severity`)
+	}
+}
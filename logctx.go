@@ -0,0 +1,114 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.7
+// +build go1.7
+
+package errors
+
+import (
+	"context"
+	"sync"
+)
+
+// maxFallback is the number of errors kept by the fallback buffer; the oldest
+// ones are discarded beyond it.
+const maxFallback = 256
+
+// fallback holds the errors LogCtx and ReportCtx gave up on.
+var fallback struct {
+	sync.Mutex
+	errs Errors
+}
+
+// addFallback stores err in the fallback buffer. It must be called with
+// fallback locked.
+func addFallback(err Error) {
+	if len(fallback.errs) >= maxFallback {
+		fallback.errs = fallback.errs[1:]
+	}
+	fallback.errs = append(fallback.errs, err)
+}
+
+// DrainFallback returns the errors LogCtx and ReportCtx could not deliver
+// before their context was done, oldest first, and empties the buffer holding
+// them. The buffer keeps the latest 256 errors only.
+func DrainFallback() Errors {
+	fallback.Lock()
+	defer fallback.Unlock()
+	errs := fallback.errs
+	fallback.errs = nil
+	return errs
+}
+
+// LogCtx sends err to log like its Log method, without waiting beyond the
+// cancellation of ctx: if ctx is done first, err is kept in the fallback
+// buffer, see DrainFallback, unless the logger eventually completes. PANIC
+// and FATAL errors are always logged synchronously.
+func LogCtx(ctx context.Context, err Error, log Logger) Error {
+	if err == nil {
+		return nil
+	}
+	if err.Level() >= PANIC {
+		return err.Log(log)
+	}
+	if ctx.Err() != nil {
+		fallback.Lock()
+		addFallback(err)
+		fallback.Unlock()
+		return err
+	}
+	var logged, abandoned bool // guarded by fallback
+	done := make(chan struct{})
+	go func() {
+		err.Log(log)
+		close(done)
+		fallback.Lock()
+		logged = true
+		if abandoned {
+			for i, e := range fallback.errs {
+				if e == err {
+					fallback.errs = append(fallback.errs[:i], fallback.errs[i+1:]...)
+					break
+				}
+			}
+		}
+		fallback.Unlock()
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		fallback.Lock()
+		if !logged {
+			abandoned = true
+			addFallback(err)
+		}
+		fallback.Unlock()
+	}
+	return err
+}
+
+// ReportCtx is like Report, but stops waiting for room in the queue when ctx
+// is done; err is then kept in the fallback buffer, see DrainFallback.
+func (r *Reporter) ReportCtx(ctx context.Context, err Error) bool {
+	if r.report(err, ctx.Done()) {
+		return true
+	}
+	if ctx.Err() != nil {
+		fallback.Lock()
+		addFallback(err)
+		fallback.Unlock()
+	}
+	return false
+}
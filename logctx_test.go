@@ -0,0 +1,76 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.7
+// +build go1.7
+
+package errors
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// gatedLogger blocks every call until released.
+type gatedLogger struct {
+	mockLogger
+	gate chan struct{}
+}
+
+func (gl *gatedLogger) Print(s ...interface{}) {
+	<-gl.gate
+	gl.mockLogger.Print(s...)
+}
+
+func TestLogCtx(t *testing.T) {
+	DrainFallback()
+	log := &gatedLogger{gate: make(chan struct{})}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := New("abc")
+	LogCtx(ctx, err, log)
+	if errs := DrainFallback(); len(errs) != 1 || errs[0] != err {
+		t.Errorf(`DrainFallback() = %v, want [abc]`, errs)
+	}
+	LogCtx(ctx, New("xyz"), log)
+	close(log.gate)
+	if errs := DrainFallback(); len(errs) != 1 || errs[0].Text() != "xyz" {
+		t.Errorf(`DrainFallback() = %v, want [xyz]`, errs)
+	}
+
+	log = &gatedLogger{gate: make(chan struct{})}
+	close(log.gate)
+	LogCtx(context.Background(), New("abc"), log)
+	if log.log != "abc\n" || len(DrainFallback()) != 0 {
+		t.Errorf(`LogCtx(ctx, err, log) logged %q`, log.log)
+	}
+}
+
+func TestReportCtx(t *testing.T) {
+	DrainFallback()
+	s := &gatedSink{gate: make(chan struct{})}
+	r := NewReporter(s, ReporterOptions{QueueSize: 1, Policy: BLOCK_WITH_TIMEOUT})
+	fill(r, "w0", "w1")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if r.ReportCtx(ctx, New(Desc{Level: FATAL, Text: "f2"})) {
+		t.Errorf(`ReportCtx(ctx, f2) = true, want false`)
+	}
+	close(s.gate)
+	r.Close()
+	if errs := DrainFallback(); len(errs) != 1 || errs[0].Text() != "f2" {
+		t.Errorf(`DrainFallback() = %v, want [f2]`, errs)
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package logrushook bridges github.com/agext/errors and logrus, for code
+// still logging through logrus: its Hook enriches the entries carrying an
+// Error with its code, level, fields and stack trace.
+package logrushook
+
+import (
+	"strings"
+
+	"github.com/agext/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Hook is a logrus.Hook enriching the entries whose error field holds an
+// Error with the fields:
+//   - error_code: its code, if any, by name if registered;
+//   - error_level: its level name;
+//   - error.<key>: each of its fields;
+//   - error_stack: the stack trace in its Info, if any;
+//   - error_info: the rest of its Info, if any.
+//
+// The code and level are resolved over the error chain, see
+// errors.EffectiveCode.
+type Hook struct {
+	// Key is the entry field holding errors (default logrus.ErrorKey).
+	Key string
+}
+
+// Levels returns all levels: the hook applies to every entry.
+func (h *Hook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire enriches entry if it carries an Error.
+func (h *Hook) Fire(entry *logrus.Entry) error {
+	key := h.Key
+	if key == "" {
+		key = logrus.ErrorKey
+	}
+	err, ok := entry.Data[key].(error)
+	if !ok {
+		return nil
+	}
+	e, ok := err.(errors.Error)
+	if !ok {
+		return nil
+	}
+	if code := errors.EffectiveCode(err); code != 0 {
+		if name := errors.CodeName(code); name != "" {
+			entry.Data["error_code"] = name
+		} else {
+			entry.Data["error_code"] = code
+		}
+	}
+	entry.Data["error_level"] = errors.EffectiveLevel(err).String()
+	errors.FindField(err, func(k string, v interface{}) bool {
+		entry.Data["error."+k] = v
+		return false
+	})
+	var info []string
+	for _, s := range e.Info() {
+		if strings.HasPrefix(s, "goroutine ") {
+			entry.Data["error_stack"] = s
+		} else {
+			info = append(info, s)
+		}
+	}
+	if len(info) > 0 {
+		entry.Data["error_info"] = info
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logrushook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/agext/errors"
+	"github.com/sirupsen/logrus"
+)
+
+func TestHook(t *testing.T) {
+	var buf bytes.Buffer
+	log := logrus.New()
+	log.Out = &buf
+	log.Formatter = &logrus.JSONFormatter{DisableTimestamp: true}
+	log.AddHook(&Hook{})
+
+	errors.RegisterCode(0x2104, "NOT_FOUND")
+	defer errors.RegisterCode(0x2104, "")
+	err := errors.New(errors.Desc{
+		Level:  errors.WARNING,
+		Code:   0x2104,
+		Text:   "no such user",
+		Info:   []string{"id=42", "debug.stack"},
+		Fields: map[string]interface{}{"user": "bob"},
+	})
+	log.WithError(err).Warn("lookup failed")
+	var got map[string]interface{}
+	if e := json.Unmarshal(buf.Bytes(), &got); e != nil {
+		t.Fatal(e)
+	}
+	if got["error_code"] != "NOT_FOUND" || got["error_level"] != "WARNING" || got["error.user"] != "bob" {
+		t.Errorf(`entry = %v`, got)
+	}
+	if info, _ := got["error_info"].([]interface{}); len(info) != 1 || info[0] != "id=42" || got["error_stack"] == nil {
+		t.Errorf(`entry info %v, stack %v`, got["error_info"], got["error_stack"])
+	}
+
+	buf.Reset()
+	log.WithError(fmt.Errorf("plain")).Error("failed")
+	if bytes.Contains(buf.Bytes(), []byte("error_level")) {
+		t.Errorf(`plain error entry was enriched: %s`, buf.Bytes())
+	}
+}
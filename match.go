@@ -0,0 +1,73 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import "sync/atomic"
+
+// Matching modes of Is, see SetMatchMode.
+const (
+	// MATCH_CODE matches errors with the same non-zero code and domain, or
+	// created from the same Sentinel.
+	MATCH_CODE int8 = iota
+	// MATCH_SENTINEL only matches errors created from the same Sentinel.
+	MATCH_SENTINEL
+)
+
+var matchMode int32
+
+// SetMatchMode sets how errors of this package match each other for Is,
+// and so for errors.Is: MATCH_CODE (the default) or MATCH_SENTINEL. An error
+// always matches itself.
+func SetMatchMode(mode int8) {
+	if mode == MATCH_CODE || mode == MATCH_SENTINEL {
+		atomic.StoreInt32(&matchMode, int32(mode))
+	}
+}
+
+// Is reports whether em matches target, an error of this package, according
+// to the mode set with SetMatchMode, or a Sentinel em was created from. It
+// lets errors.Is(err, ErrNotFound) succeed for err wrapping a different error
+// value, with the code of ErrNotFound or created from the same Sentinel.
+func (em *errorMessage) Is(target error) bool {
+	if s, ok := target.(*Sentinel); ok {
+		return em.sentinel != nil && em.sentinel == s
+	}
+	t := messageOf(target)
+	if t == nil {
+		return false
+	}
+	if t == em || em.sentinel != nil && em.sentinel == t.sentinel {
+		return true
+	}
+	return int8(atomic.LoadInt32(&matchMode)) == MATCH_CODE &&
+		em.code != 0 && em.code == t.code && em.domain == t.domain
+}
+
+// As sets target, a **Sentinel, to the Sentinel em was created from, if any,
+// and reports whether it did, so that errors.As can find the template of an
+// error in a chain. Other targets are left to errors.As.
+func (em *errorMessage) As(target interface{}) bool {
+	if t, ok := target.(**Sentinel); ok && em.sentinel != nil {
+		*t = em.sentinel
+		return true
+	}
+	return false
+}
+
+// Error returns the text of the template, so that s can be the target of
+// errors.Is and errors.As, see errorMessage.Is and errorMessage.As.
+func (s *Sentinel) Error() string {
+	return s.desc.Text
+}
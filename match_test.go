@@ -0,0 +1,66 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.13
+// +build go1.13
+
+package errors
+
+import (
+	stderrors "errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestIsAs(t *testing.T) {
+	defer SetMatchMode(MATCH_CODE)
+	errNotFound := New(Desc{Code: 0x404, Text: "not found"})
+	errMissing := Define(Desc{Code: 0x405, Text: "missing"})
+
+	e := Wrap(New(Desc{Code: 0x404, Text: "no such user"}), "loading")
+	if !stderrors.Is(e, errNotFound) {
+		t.Errorf(`errors.Is(e, errNotFound) = false`)
+	}
+	if stderrors.Is(New("other"), errNotFound) || stderrors.Is(New("other"), New("other")) {
+		t.Errorf(`errors.Is matched a different error`)
+	}
+	m := fmt.Errorf("fetching: %w", errMissing.New())
+	if !stderrors.Is(m, errMissing.New()) {
+		t.Errorf(`errors.Is(m, errMissing.New()) = false`)
+	}
+	if !stderrors.Is(m, errMissing) || stderrors.Is(e, errMissing) {
+		t.Errorf(`errors.Is(err, Sentinel) mismatch`)
+	}
+	var s *Sentinel
+	if !stderrors.As(m, &s) || s != errMissing {
+		t.Errorf(`errors.As(m, &s) did not find the Sentinel`)
+	}
+
+	if ro := ReadOnly(errMissing.New()); !stderrors.Is(ro, errMissing) || !stderrors.Is(ReadOnly(e), errNotFound) {
+		t.Errorf(`errors.Is did not see through ReadOnly`)
+	}
+	var p *os.PathError
+	if !stderrors.As(ReadOnly(Wrap(&os.PathError{Op: "open", Path: "x", Err: os.ErrNotExist}, "loading")), &p) || p.Path != "x" {
+		t.Errorf(`errors.As did not see through ReadOnly`)
+	}
+
+	SetMatchMode(MATCH_SENTINEL)
+	if stderrors.Is(e, errNotFound) {
+		t.Errorf(`MATCH_SENTINEL: errors.Is matched by code`)
+	}
+	if !stderrors.Is(m, errMissing.New()) || !stderrors.Is(errNotFound, errNotFound) {
+		t.Errorf(`MATCH_SENTINEL: errors.Is did not match`)
+	}
+}
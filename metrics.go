@@ -0,0 +1,222 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.9 && !errors_minimal
+// +build go1.9,!errors_minimal
+
+package errors
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// metricKey identifies a counter of logged errors: by code, or by category
+// in low-cardinality mode.
+type metricKey struct {
+	code     int
+	category string
+	level    int8
+}
+
+// MetricCategory is a named range of codes, counted together in
+// low-cardinality mode, see SetMetricCardinality.
+type MetricCategory struct {
+	Name     string
+	Min, Max int
+}
+
+// CardinalityOptions configures the low-cardinality mode of metrics.
+type CardinalityOptions struct {
+	// Categories lists the ranges of codes counted together; without them,
+	// errors are counted per code.
+	Categories []MetricCategory
+	// Drop makes errors with a code outside all categories uncounted,
+	// instead of being counted in the category "other".
+	Drop bool
+}
+
+// exemplar is the latest traced error counted by a counter.
+type exemplar struct {
+	traceID, spanID string
+	time            time.Time
+}
+
+// metricShards is the number of shards of a counter, spreading concurrent
+// increments over as many cache lines; it is kept small, as every code and
+// level counted has its own counter.
+const metricShards = 4
+
+// cacheLine is the size of a cache line on common processors.
+const cacheLine = 64
+
+// metricShard is a shard of a counter, padded to a cache line.
+type metricShard struct {
+	n uint64
+	_ [cacheLine - 8]byte
+}
+
+// metric is a counter of logged errors, incremented without locking.
+type metric struct {
+	shards   [metricShards]metricShard
+	exemplar atomic.Value // *exemplar
+}
+
+// add increments m, using the next shard in turn.
+func (m *metric) add() {
+	i := atomic.AddUint32(&metrics.next, 1)
+	atomic.AddUint64(&m.shards[i%metricShards].n, 1)
+}
+
+// count returns the value of m.
+func (m *metric) count() uint64 {
+	var n uint64
+	for i := range m.shards {
+		n += atomic.LoadUint64(&m.shards[i].n)
+	}
+	return n
+}
+
+var metrics struct {
+	on          uint32
+	_           [cacheLine - 4]byte
+	next        uint32 // round-robin shard counter, see metric.add
+	_           [cacheLine - 4]byte
+	counters    atomic.Value // *sync.Map of metricKey to *metric
+	cardinality atomic.Value // CardinalityOptions
+}
+
+// SetMetricCardinality sets the low-cardinality mode of metrics, protecting
+// metric backends from the explosion of series caused by dynamically
+// generated codes: errors are then counted per category of codes, errors
+// without code in the category "none", and others are aggregated in the
+// category "other" or dropped, according to opts.
+func SetMetricCardinality(opts CardinalityOptions) {
+	opts.Categories = append([]MetricCategory(nil), opts.Categories...)
+	metrics.cardinality.Store(opts)
+}
+
+// metricKeyOf returns the key of the counter of em, and false if em is not
+// counted.
+func metricKeyOf(em *errorMessage) (metricKey, bool) {
+	opts, _ := metrics.cardinality.Load().(CardinalityOptions)
+	if len(opts.Categories) == 0 {
+		return metricKey{code: em.code, level: em.level}, true
+	}
+	if em.code == 0 {
+		return metricKey{category: "none", level: em.level}, true
+	}
+	for _, c := range opts.Categories {
+		if em.code >= c.Min && em.code <= c.Max {
+			return metricKey{category: c.Name, level: em.level}, true
+		}
+	}
+	return metricKey{category: "other", level: em.level}, !opts.Drop
+}
+
+// SetMetrics sets whether the errors logged by Log are counted per code and
+// level, for WriteMetrics. Turning it off discards the counters. Counting
+// takes no lock, so that it adds little overhead even at high error rates.
+// Errors caused by context.Canceled are not counted, see SetCanceledOptions.
+func SetMetrics(on bool) {
+	var flag uint32
+	if on {
+		flag = 1
+	}
+	metrics.counters.Store(&sync.Map{})
+	atomic.StoreUint32(&metrics.on, flag)
+}
+
+// countMetric counts em as logged, if metrics are on.
+func countMetric(em *errorMessage) {
+	if atomic.LoadUint32(&metrics.on) == 0 || uncountedCanceled(em) {
+		return
+	}
+	k, ok := metricKeyOf(em)
+	if !ok {
+		return
+	}
+	counters := metrics.counters.Load().(*sync.Map)
+	v, ok := counters.Load(k)
+	if !ok {
+		v, _ = counters.LoadOrStore(k, &metric{})
+	}
+	m := v.(*metric)
+	m.add()
+	if traceID, _ := em.field(fieldTraceID).(string); traceID != "" {
+		spanID, _ := em.field(fieldSpanID).(string)
+		m.exemplar.Store(&exemplar{traceID, spanID, em.time})
+	}
+}
+
+// WriteMetrics writes the counters of logged errors to w in the OpenMetrics
+// text format, as the counter "errors_logged" labeled by code (by name if
+// registered, or by category, see SetMetricCardinality) and level, see
+// SetMetrics. Counters of errors created with a
+// trace, see SetTraceExtractor, carry the trace of the latest of them as an
+// exemplar, so that dashboards can link error rates to representative
+// traces.
+func WriteMetrics(w io.Writer) error {
+	type sample struct {
+		code, level string
+		count       uint64
+		exemplar    *exemplar
+	}
+	var samples []sample
+	if counters, ok := metrics.counters.Load().(*sync.Map); ok {
+		counters.Range(func(k, v interface{}) bool {
+			key, m := k.(metricKey), v.(*metric)
+			code := key.category
+			if code == "" {
+				if code = CodeName(key.code); code == "" {
+					code = fmt.Sprintf("0x%04x", key.code)
+				}
+			}
+			x, _ := m.exemplar.Load().(*exemplar)
+			samples = append(samples, sample{code, levelName(key.level), m.count(), x})
+			return true
+		})
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		if samples[i].code != samples[j].code {
+			return samples[i].code < samples[j].code
+		}
+		return samples[i].level < samples[j].level
+	})
+
+	b := bufio.NewWriter(w)
+	b.WriteString("# TYPE errors_logged counter\n# HELP errors_logged Errors logged, per code and level.\n")
+	for _, s := range samples {
+		fmt.Fprintf(b, "errors_logged_total{code=%q,level=%q} %d", s.code, s.level, s.count)
+		if x := s.exemplar; x != nil {
+			fmt.Fprintf(b, " # {trace_id=%q", x.traceID)
+			if x.spanID != "" {
+				fmt.Fprintf(b, ",span_id=%q", x.spanID)
+			}
+			b.WriteString("} 1")
+			if !x.time.IsZero() {
+				b.WriteString(" " + strconv.FormatFloat(float64(x.time.UnixNano())/1e9, 'f', 3, 64))
+			}
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("# EOF\n")
+	return b.Flush()
+}
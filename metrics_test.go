@@ -0,0 +1,134 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.9 && !errors_minimal
+// +build go1.9,!errors_minimal
+
+package errors
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestMetrics(t *testing.T) {
+	defer SetMetrics(false)
+	defer SetTraceExtractor(nil)
+	defer SetClock(nil)
+	SetClock(fixedClock(time.Unix(1700000000, 500e6)))
+	SetMetrics(true)
+	RegisterCode(0x2400, "RATE_LIMITED")
+	defer RegisterCode(0x2400, "")
+
+	log := &mockLogger{}
+	New(Desc{Code: 0x2400, Level: WARNING, Text: "slow down"}).Log(log)
+	New(Desc{Code: 0x2400, Level: WARNING, Text: "slow down"}).Log(log)
+	SetTraceExtractor(func(ctx context.Context) (string, string) {
+		return "4bf92f3577b34da6", "00f067aa0ba902b7"
+	})
+	NewCtx(context.Background(), Desc{Code: 0x2400, Level: WARNING, Text: "slow down"}).Log(log)
+	NewCtx(context.Background(), Desc{Code: 7, Text: "failed"}).Log(log)
+
+	var buf bytes.Buffer
+	if err := WriteMetrics(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := `# TYPE errors_logged counter
+# HELP errors_logged Errors logged, per code and level.
+errors_logged_total{code="0x0007",level="ERROR"} 1 # {trace_id="4bf92f3577b34da6",span_id="00f067aa0ba902b7"} 1 1700000000.500
+errors_logged_total{code="RATE_LIMITED",level="WARNING"} 3 # {trace_id="4bf92f3577b34da6",span_id="00f067aa0ba902b7"} 1 1700000000.500
+# EOF
+`
+	if buf.String() != want {
+		t.Errorf(`WriteMetrics wrote:\n%s\nwant:\n%s`, buf.String(), want)
+	}
+
+	// increments are spread over the shards even with a fixed clock
+	var m metric
+	for i := 0; i < metricShards; i++ {
+		m.add()
+	}
+	for i := range m.shards {
+		if m.shards[i].n != 1 {
+			t.Errorf(`shard %d counted %d increments, want 1`, i, m.shards[i].n)
+		}
+	}
+}
+
+func TestMetricCardinality(t *testing.T) {
+	defer SetMetrics(false)
+	defer SetMetricCardinality(CardinalityOptions{})
+	SetMetrics(true)
+	SetMetricCardinality(CardinalityOptions{Categories: []MetricCategory{
+		{"storage", 0x100, 0x1ff},
+		{"network", 0x200, 0x2ff},
+	}})
+	log := &mockLogger{}
+	for _, code := range []int{0x101, 0x1fe, 0x250, 0x9001, 0x9002, 0} {
+		New(Desc{Code: code, Text: "failed"}).Log(log)
+	}
+	var buf bytes.Buffer
+	WriteMetrics(&buf)
+	want := `# TYPE errors_logged counter
+# HELP errors_logged Errors logged, per code and level.
+errors_logged_total{code="network",level="ERROR"} 1
+errors_logged_total{code="none",level="ERROR"} 1
+errors_logged_total{code="other",level="ERROR"} 2
+errors_logged_total{code="storage",level="ERROR"} 2
+# EOF
+`
+	if buf.String() != want {
+		t.Errorf(`WriteMetrics wrote:\n%s\nwant:\n%s`, buf.String(), want)
+	}
+
+	SetMetrics(true)
+	SetMetricCardinality(CardinalityOptions{Categories: []MetricCategory{{"storage", 0x100, 0x1ff}}, Drop: true})
+	New(Desc{Code: 0x9003, Text: "failed"}).Log(log)
+	buf.Reset()
+	if WriteMetrics(&buf); bytes.Contains(buf.Bytes(), []byte("other")) {
+		t.Errorf(`uncategorized code counted with Drop:\n%s`, buf.String())
+	}
+}
+
+func BenchmarkCountMetricContended(b *testing.B) {
+	defer SetMetrics(false)
+	SetMetrics(true)
+	b.RunParallel(func(pb *testing.PB) {
+		// same counter, from errors created at different times
+		em := newMessage("contended")
+		em.time = time.Now()
+		for pb.Next() {
+			countMetric(em)
+		}
+	})
+}
+
+func BenchmarkCountMetricSpread(b *testing.B) {
+	defer SetMetrics(false)
+	SetMetrics(true)
+	ems := make([]*errorMessage, 64)
+	for i := range ems {
+		ems[i] = newMessage("spread " + strconv.Itoa(i))
+		ems[i].code = i
+		ems[i].time = time.Unix(0, int64(i))
+	}
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			countMetric(ems[i%len(ems)])
+		}
+	})
+}
@@ -0,0 +1,125 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"hash/fnv"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Code namespaces: the codes from NAMESPACE_FIRST on are reserved for
+// namespaces of NAMESPACE_SIZE codes each, assigned to packages by Namespace.
+const (
+	NAMESPACE_FIRST = 0x1000000
+	NAMESPACE_SIZE  = 0x100
+
+	namespaceCount = 0x10000
+)
+
+// Namespace is the code namespace of a package, identified by its import
+// path.
+type Namespace string
+
+var namespaces struct {
+	sync.Mutex
+	pinned map[string]int
+	bases  map[string]int // base of each package, once computed
+	users  map[int]string // first package given each base
+}
+
+// CallerNamespace returns the code namespace of the package calling it, e.g.
+// for a library to derive its codes from without coordinating with others:
+//
+//	var ns = errors.CallerNamespace()
+//	var ErrQuota = errors.New(errors.Desc{Code: ns.Code(1), Text: "quota exceeded"})
+func CallerNamespace() Namespace {
+	pc, _, _, ok := runtime.Caller(1)
+	if !ok {
+		return ""
+	}
+	return Namespace(packageOf(runtime.FuncForPC(pc).Name()))
+}
+
+// PinNamespace sets the base code of the namespace of the package with the
+// given import path to base, instead of the one derived from the path, e.g.
+// to resolve a collision or keep codes already published. A zero base
+// removes the pin. Pins apply to the codes computed afterwards.
+func PinNamespace(pkg string, base int) {
+	namespaces.Lock()
+	if base == 0 {
+		delete(namespaces.pinned, pkg)
+	} else {
+		if namespaces.pinned == nil {
+			namespaces.pinned = make(map[string]int)
+		}
+		namespaces.pinned[pkg] = base
+	}
+	delete(namespaces.bases, pkg)
+	namespaces.Unlock()
+}
+
+// Base returns the first code of ns: the one pinned with PinNamespace, if
+// any, or one derived from a stable hash of the package path. As distinct
+// paths may hash alike, observers are notified with EVENT_NAMESPACE_COLLISION
+// the first time two packages get the same base.
+func (ns Namespace) Base() int {
+	namespaces.Lock()
+	if base, ok := namespaces.bases[string(ns)]; ok {
+		namespaces.Unlock()
+		return base
+	}
+	base, ok := namespaces.pinned[string(ns)]
+	if !ok {
+		h := fnv.New32a()
+		h.Write([]byte(ns))
+		base = NAMESPACE_FIRST + int(h.Sum32()%namespaceCount)*NAMESPACE_SIZE
+	}
+	if namespaces.bases == nil {
+		namespaces.bases = make(map[string]int)
+		namespaces.users = make(map[int]string)
+	}
+	namespaces.bases[string(ns)] = base
+	other, used := namespaces.users[base]
+	if !used {
+		namespaces.users[base] = string(ns)
+	}
+	namespaces.Unlock()
+	if used && other != string(ns) {
+		notify(EVENT_NAMESPACE_COLLISION, New(Desc{
+			Code: ERR_EXISTS,
+			Text: "packages " + other + " and " + string(ns) + " share the code namespace 0x" + strconv.FormatInt(int64(base), 16),
+		}))
+	}
+	return base
+}
+
+// Code returns the code number n (modulo NAMESPACE_SIZE) of ns.
+func (ns Namespace) Code(n int) int {
+	return ns.Base() + n%NAMESPACE_SIZE
+}
+
+// packageOf returns the import path of the package of the function with the
+// given fully qualified name, where dots in the last path element are
+// escaped.
+func packageOf(function string) string {
+	i := strings.LastIndex(function, "/") + 1
+	if j := strings.Index(function[i:], "."); j >= 0 {
+		function = function[:i+j]
+	}
+	return strings.Replace(function, "%2e", ".", -1)
+}
@@ -0,0 +1,62 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import "testing"
+
+func TestNamespace(t *testing.T) {
+	var collisions []Error
+	remove := AddObserver(func(event int8, err Error) {
+		if event == EVENT_NAMESPACE_COLLISION {
+			collisions = append(collisions, err)
+		}
+	})
+	defer remove()
+
+	ns := CallerNamespace()
+	if ns != "github.com/agext/errors" {
+		t.Errorf(`CallerNamespace() = %q`, ns)
+	}
+	base := ns.Base()
+	if base < NAMESPACE_FIRST || (base-NAMESPACE_FIRST)%NAMESPACE_SIZE != 0 || Namespace(ns).Base() != base {
+		t.Errorf(`ns.Base() = %#x`, base)
+	}
+	if ns.Code(3) != base+3 || ns.Code(NAMESPACE_SIZE+3) != base+3 {
+		t.Errorf(`ns.Code(3) = %#x, want %#x`, ns.Code(3), base+3)
+	}
+
+	defer PinNamespace("example.com/other", 0)
+	PinNamespace("example.com/other", base)
+	Namespace("example.com/other").Code(1)
+	Namespace("example.com/other").Code(2)
+	if len(collisions) != 1 || collisions[0].Code() != ERR_EXISTS {
+		t.Errorf(`collisions = %v, want one`, collisions)
+	}
+	PinNamespace("example.com/other", 0x4200)
+	if got := Namespace("example.com/other").Code(1); got != 0x4201 {
+		t.Errorf(`pinned Code(1) = %#x, want 0x4201`, got)
+	}
+
+	for fn, want := range map[string]string{
+		"main.main":                      "main",
+		"github.com/a/b.(*T).M":          "github.com/a/b",
+		"github.com/a/b.v2/c.init.func1": "github.com/a/b.v2/c",
+		"gopkg.in/yaml%2ev2.Unmarshal":   "gopkg.in/yaml.v2",
+	} {
+		if got := packageOf(fn); got != want {
+			t.Errorf(`packageOf(%q) = %q, want %q`, fn, got, want)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.20 && !errors_minimal
+// +build go1.20,!errors_minimal
+
+package errors
+
+import (
+	stderrors "errors"
+	"testing"
+)
+
+func TestNewf(t *testing.T) {
+	if e := Newf("%d items", 3); e.Text() != "3 items" || e.Level() != ERROR || e.(interface{ Unwrap() error }).Unwrap() != nil {
+		t.Errorf(`Newf("%%d items", 3) = %v`, e)
+	}
+	cause := New(Desc{Code: 0x42, Level: WARNING, Text: "timeout"})
+	e := Errorf("fetching %s: %w", "/a", cause)
+	if e.Text() != "fetching /a: "+cause.Error() || e.Code() != 0x42 || e.Level() != WARNING || !stderrors.Is(e, cause) {
+		t.Errorf(`Errorf(%%w) = %v, level %d`, e, e.Level())
+	}
+	other := New("other")
+	if e := Errorf("%w and %w", cause, other); !stderrors.Is(e, cause) || !stderrors.Is(e, other) || e.Code() != 0 {
+		t.Errorf(`Errorf(%%w and %%w) = %v, code %d`, e, e.Code())
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import "sync"
+
+// Events observers are notified about.
+const (
+	// EVENT_UNREGISTERED_CODE is sent when an error with an unregistered code
+	// is first rendered in CODES_STRICT style.
+	EVENT_UNREGISTERED_CODE int8 = iota + 1
+	// EVENT_READ_ONLY is sent when trying to modify an error returned by
+	// ReadOnly; the error passed describes the attempt, with the code
+	// ERR_IMMUTABLE.
+	EVENT_READ_ONLY
+	// EVENT_SHADOW_DIVERGENCE is sent when shadow classification rules yield
+	// a different result than the live ones, see SetShadowClassifiers.
+	EVENT_SHADOW_DIVERGENCE
+	// EVENT_NAMESPACE_COLLISION is sent when two packages are given the same
+	// code namespace, see Namespace; the error passed describes the
+	// collision, with the code ERR_EXISTS.
+	EVENT_NAMESPACE_COLLISION
+	// EVENT_INVARIANT_VIOLATION is sent when an error misses attributes
+	// required by SetStrict; the error passed describes the violation, with
+	// the code ERR_INVALID.
+	EVENT_INVARIANT_VIOLATION
+	// EVENT_FIELD_SCHEMA is sent when a field of an error does not match the
+	// registered schemas, see RegisterFieldSchema; the error passed describes
+	// the violation, with the code ERR_INVALID.
+	EVENT_FIELD_SCHEMA
+	// EVENT_UNHANDLED is sent when an error is garbage-collected without
+	// having been handled, see SetUnhandledDetection.
+	EVENT_UNHANDLED
+	// EVENT_CONTEXT_CANCELED is sent when a context watched by WatchContext
+	// is canceled.
+	EVENT_CONTEXT_CANCELED
+	// EVENT_MEMORY_BUDGET is sent when the memory retained by live errors
+	// goes over the budget set by SetMemoryBudget.
+	EVENT_MEMORY_BUDGET
+	// EVENT_PANIC is sent when a panic is recovered by HandlePanic, with the
+	// FATAL error describing it, before it is logged.
+	EVENT_PANIC
+)
+
+// Observer is a function notified about events concerning an Error.
+type Observer func(event int8, err Error)
+
+var observers struct {
+	sync.RWMutex
+	list []*Observer
+}
+
+// AddObserver registers o to be notified about events. The returned function
+// unregisters it.
+func AddObserver(o Observer) (remove func()) {
+	p := &o
+	observers.Lock()
+	observers.list = append(observers.list, p)
+	observers.Unlock()
+	return func() {
+		observers.Lock()
+		defer observers.Unlock()
+		for i, q := range observers.list {
+			if q == p {
+				list := make([]*Observer, 0, len(observers.list)-1)
+				observers.list = append(append(list, observers.list[:i]...), observers.list[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// notify sends event about err to all registered observers.
+func notify(event int8, err Error) {
+	observers.RLock()
+	list := observers.list
+	observers.RUnlock()
+	for _, o := range list {
+		(*o)(event, err)
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// GenerateOpenAPISchemas returns the OpenAPI components describing the error
+// responses of a service, for the codes registered with RegisterCode, see
+// OpenAPISchemas.
+func GenerateOpenAPISchemas() ([]byte, error) {
+	return OpenAPISchemas(ExportCatalog())
+}
+
+// OpenAPISchemas returns, as JSON, the OpenAPI components describing the
+// error responses of a service with the codes in c, in the shape rendered by
+// ToOpenAPI with the default options:
+//   - the schemas "Error", the document, and "ErrorCode", enumerating the
+//     code names;
+//   - for each HTTP status in the "http" mappings of the codes, a response
+//     "Error<status>" with an example per code, using its public text;
+//     codes without such mapping are given with status 500.
+func OpenAPISchemas(c Catalog) ([]byte, error) {
+	type example struct {
+		Summary string      `json:"summary,omitempty"`
+		Value   interface{} `json:"value"`
+	}
+	codeList := make([]int, 0, len(c.Codes))
+	for code := range c.Codes {
+		codeList = append(codeList, code)
+	}
+	sort.Ints(codeList)
+
+	names := make([]string, 0, len(codeList))
+	examples := make(map[string]map[string]example)
+	for _, code := range codeList {
+		entry := c.Codes[code]
+		name := entry.Name
+		if name == "" {
+			name = fmt.Sprintf("0x%04x", code)
+		}
+		names = append(names, name)
+		status := entry.Mappings["http"]
+		if _, err := strconv.Atoi(status); err != nil {
+			status = "500"
+		}
+		if examples[status] == nil {
+			examples[status] = make(map[string]example)
+		}
+		msg := entry.Text
+		if msg == "" {
+			msg = name
+		}
+		examples[status][name] = example{
+			Summary: entry.Text,
+			Value: map[string]interface{}{
+				"errors": []map[string]string{{"code": name, "message": msg}},
+			},
+		}
+	}
+
+	responses := make(map[string]interface{}, len(examples))
+	for status, ex := range examples {
+		responses["Error"+status] = map[string]interface{}{
+			"description": "Error response with status " + status,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{
+					"schema":   map[string]string{"$ref": "#/components/schemas/Error"},
+					"examples": ex,
+				},
+			},
+		}
+	}
+	codeSchema := map[string]interface{}{"type": "string"}
+	if len(names) > 0 {
+		codeSchema["enum"] = names
+	}
+	return json.Marshal(map[string]interface{}{
+		"schemas": map[string]interface{}{
+			"ErrorCode": codeSchema,
+			"Error": map[string]interface{}{
+				"type":     "object",
+				"required": []string{"errors"},
+				"properties": map[string]interface{}{
+					"errors": map[string]interface{}{
+						"type": "array",
+						"items": map[string]interface{}{
+							"type":     "object",
+							"required": []string{"message"},
+							"properties": map[string]interface{}{
+								"code":    map[string]string{"$ref": "#/components/schemas/ErrorCode"},
+								"message": map[string]string{"type": "string"},
+								"field":   map[string]string{"type": "string"},
+							},
+						},
+					},
+				},
+			},
+		},
+		"responses": responses,
+	})
+}
@@ -0,0 +1,69 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestOpenAPISchemas(t *testing.T) {
+	data, err := OpenAPISchemas(Catalog{Codes: map[int]CatalogEntry{
+		0x10: {Name: "NOT_FOUND", Text: "The resource does not exist.", Mappings: map[string]string{"http": "404"}},
+		0x11: {Name: "GONE", Mappings: map[string]string{"http": "404"}},
+		0x20: {Name: "INTERNAL"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Schemas struct {
+			ErrorCode struct {
+				Enum []string `json:"enum"`
+			}
+		} `json:"schemas"`
+		Responses map[string]struct {
+			Content map[string]struct {
+				Examples map[string]struct {
+					Value interface{} `json:"value"`
+				} `json:"examples"`
+			} `json:"content"`
+		} `json:"responses"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"NOT_FOUND", "GONE", "INTERNAL"}; !reflect.DeepEqual(doc.Schemas.ErrorCode.Enum, want) {
+		t.Errorf(`ErrorCode enum = %q, want %q`, doc.Schemas.ErrorCode.Enum, want)
+	}
+	ex404 := doc.Responses["Error404"].Content["application/json"].Examples
+	ex500 := doc.Responses["Error500"].Content["application/json"].Examples
+	if len(doc.Responses) != 2 || len(ex404) != 2 || len(ex500) != 1 {
+		t.Fatalf(`responses = %s`, data)
+	}
+	// examples match what ToOpenAPI renders
+	RegisterCode(0x10, "NOT_FOUND")
+	defer RegisterCode(0x10, "")
+	rendered, _ := ToOpenAPI(ShapeOptions{}, New(Desc{Code: 0x10, Text: "no user 7", PublicText: "The resource does not exist."}))
+	var want interface{}
+	json.Unmarshal(rendered, &want)
+	if got := ex404["NOT_FOUND"].Value; !reflect.DeepEqual(got, want) {
+		t.Errorf(`example %v, ToOpenAPI renders %v`, got, want)
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import "sync"
+
+// Field keys used to record the origin of an error.
+const (
+	fieldOriginService  = "origin.service"
+	fieldOriginInstance = "origin.instance"
+)
+
+var origin struct {
+	sync.RWMutex
+	service, instance string
+}
+
+// SetOrigin sets the service and instance names recorded on every Error
+// created from now on, so that the component which minted an error can still
+// be identified after it has crossed service boundaries. Passing empty strings
+// stops the recording.
+func SetOrigin(service, instance string) {
+	origin.Lock()
+	origin.service, origin.instance = service, instance
+	origin.Unlock()
+}
+
+// Origin returns the service and instance names recorded on err when it was
+// created, or empty strings if none were recorded.
+func Origin(err error) (service, instance string) {
+	if em := messageOf(err); em != nil {
+		service, _ = em.field(fieldOriginService).(string)
+		instance, _ = em.field(fieldOriginInstance).(string)
+	}
+	return
+}
+
+func stampOrigin(em *errorMessage) {
+	origin.RLock()
+	service, instance := origin.service, origin.instance
+	origin.RUnlock()
+	if service != "" {
+		em.setField(fieldOriginService, service)
+	}
+	if instance != "" {
+		em.setField(fieldOriginInstance, instance)
+	}
+}
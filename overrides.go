@@ -0,0 +1,103 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Overrides holds settings applied temporarily by WithOverrides. Nil fields
+// leave the corresponding settings unchanged.
+type Overrides struct {
+	// Stacks overrides SetStacks.
+	Stacks *bool
+	// Verbosity overrides SetVerbosity.
+	Verbosity *int
+	// LogLevel overrides SetLogLevel.
+	LogLevel *Level
+	// Severity overrides SetSeverityFn.
+	Severity SeverityFn
+}
+
+var overrides struct {
+	sync.RWMutex
+	active      int32 // number of goroutines with overrides, read atomically
+	byGoroutine map[uint64]*Overrides
+}
+
+// WithOverrides calls fn with the settings in o applied to the errors
+// created and logged by the calling goroutine, and only them, e.g. to debug
+// a single request or test without affecting the rest of the program. Calls
+// may be nested, the innermost overrides taking precedence. While overrides
+// are in effect, creating and logging errors is slower in all goroutines, as
+// they look up their overrides by goroutine ID. Goroutines
+// cannot be told apart in errors_minimal builds, where the overrides apply to
+// all of them.
+func WithOverrides(o Overrides, fn func()) {
+	id := goroutineID()
+	overrides.Lock()
+	prev := overrides.byGoroutine[id]
+	if prev != nil {
+		o = prev.merge(o)
+	} else {
+		atomic.AddInt32(&overrides.active, 1)
+	}
+	if overrides.byGoroutine == nil {
+		overrides.byGoroutine = make(map[uint64]*Overrides)
+	}
+	overrides.byGoroutine[id] = &o
+	overrides.Unlock()
+	defer func() {
+		overrides.Lock()
+		if prev != nil {
+			overrides.byGoroutine[id] = prev
+		} else {
+			delete(overrides.byGoroutine, id)
+			atomic.AddInt32(&overrides.active, -1)
+		}
+		overrides.Unlock()
+	}()
+	fn()
+}
+
+// merge returns the settings of o overridden by those of inner.
+func (o *Overrides) merge(inner Overrides) Overrides {
+	m := *o
+	if inner.Stacks != nil {
+		m.Stacks = inner.Stacks
+	}
+	if inner.Verbosity != nil {
+		m.Verbosity = inner.Verbosity
+	}
+	if inner.LogLevel != nil {
+		m.LogLevel = inner.LogLevel
+	}
+	if inner.Severity != nil {
+		m.Severity = inner.Severity
+	}
+	return m
+}
+
+// currentOverrides returns the overrides of the calling goroutine, if any.
+func currentOverrides() *Overrides {
+	if atomic.LoadInt32(&overrides.active) == 0 {
+		return nil
+	}
+	id := goroutineID()
+	overrides.RLock()
+	defer overrides.RUnlock()
+	return overrides.byGoroutine[id]
+}
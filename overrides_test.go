@@ -0,0 +1,60 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWithOverrides(t *testing.T) {
+	defer SetStacks(true)
+	SetStacks(false)
+	on, fields := true, VERBOSITY_FIELDS
+	desc := func() Desc { return Desc{Text: "abc", Info: []string{"debug.stack"}} }
+	WithOverrides(Overrides{Stacks: &on}, func() {
+		if err := New(desc()); len(err.Info()) != 1 || !strings.Contains(err.Info()[0], "TestWithOverrides") {
+			t.Errorf(`err.Info() = %q, want a stack trace`, err.Info())
+		}
+		done := make(chan Error)
+		go func() { done <- New(desc()) }()
+		if err := <-done; len(err.Info()) != 0 {
+			t.Errorf(`err.Info() in another goroutine = %q`, err.Info())
+		}
+
+		WithOverrides(Overrides{
+			Verbosity: &fields,
+			Severity:  func(Error) Level { return LEVEL_WARNING },
+		}, func() {
+			err := New(Desc{Level: ERROR, Text: "abc", Info: []string{"debug.stack"}})
+			if err.Level() != WARNING || len(err.Info()) != 1 {
+				t.Errorf(`nested: err = %d, %q`, err.Level(), err.Info())
+			}
+			log := &mockLogger{}
+			if err.(*errorMessage).setField("k", "v"); !strings.Contains(err.Log(log).Error(), "abc") || !strings.Contains(log.log, "\tk=v") {
+				t.Errorf(`log.log = %q`, log.log)
+			}
+		})
+		if err := New(Desc{Level: ERROR, Text: "abc"}); err.Level() != ERROR {
+			t.Errorf(`severity override outlived its scope`)
+		}
+	})
+	if err := New(desc()); len(err.Info()) != 0 || currentOverrides() != nil {
+		t.Errorf(`overrides outlived their scope: err.Info() = %q`, err.Info())
+	}
+}
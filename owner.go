@@ -0,0 +1,103 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Field key used to record the owner of an error.
+const fieldOwner = "owner"
+
+var owners struct {
+	sync.RWMutex
+	rules map[string]string
+}
+
+// RegisterOwner assigns the code under the package path prefix to owner, e.g.
+// the team responsible for it. Every Error created afterwards records the
+// owner of the innermost frame of its creation stack that is covered by a
+// rule; when several rules cover a frame, the longest prefix wins. An empty
+// owner removes the rule for prefix.
+func RegisterOwner(prefix, owner string) {
+	owners.Lock()
+	if owner == "" {
+		delete(owners.rules, prefix)
+	} else {
+		if owners.rules == nil {
+			owners.rules = make(map[string]string)
+		}
+		owners.rules[prefix] = owner
+	}
+	owners.Unlock()
+}
+
+// Owner returns the owner recorded on err when it was created, or an empty
+// string if none was recorded.
+func Owner(err error) string {
+	if em := messageOf(err); em != nil {
+		owner, _ := em.field(fieldOwner).(string)
+		return owner
+	}
+	return ""
+}
+
+// ownerOf returns the owner of the function with the given fully qualified
+// name, or an empty string if no rule covers it.
+func ownerOf(function string) string {
+	owner, best := "", -1
+	for prefix, o := range owners.rules {
+		if len(prefix) > best && strings.HasPrefix(function, prefix) {
+			// the prefix must end on a path element boundary
+			if rest := function[len(prefix):]; rest == "" || rest[0] == '.' || rest[0] == '/' {
+				owner, best = o, len(prefix)
+			}
+		}
+	}
+	return owner
+}
+
+// ownPackage is the import path of this package, whose frames are never
+// attributed an owner.
+var ownPackage = func() string {
+	pc, _, _, _ := runtime.Caller(0)
+	return packageOf(runtime.FuncForPC(pc).Name())
+}()
+
+// stampOwner records on em the owner of the innermost frame of the calling
+// stack covered by a rule, skipping the frames of this package (but not those
+// of its tests, which are callers like any other).
+func stampOwner(em *errorMessage) {
+	owners.RLock()
+	defer owners.RUnlock()
+	if len(owners.rules) == 0 {
+		return
+	}
+	pc := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pc[:runtime.Callers(3, pc)])
+	for more := true; more; {
+		var frame runtime.Frame
+		frame, more = frames.Next()
+		if packageOf(frame.Function) == ownPackage && !strings.HasSuffix(frame.File, "_test.go") {
+			continue
+		}
+		if owner := ownerOf(frame.Function); owner != "" {
+			em.setField(fieldOwner, owner)
+			return
+		}
+	}
+}
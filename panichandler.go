@@ -0,0 +1,102 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"fmt"
+	"sync"
+)
+
+var panicHandler struct {
+	sync.RWMutex
+	log   Logger
+	sinks []Sink
+}
+
+// InstallPanicHandler makes HandlePanic, and the goroutines started with Go,
+// turn otherwise unhandled panics into FATAL errors, reported to sinks and
+// logged to log, which applies the FATAL policy, see SetFatalPolicy: the
+// process exits, or panics again, or goes on for FATAL_LOG. A nil log
+// uninstalls the handler, letting panics through.
+func InstallPanicHandler(log Logger, sinks ...Sink) {
+	panicHandler.Lock()
+	panicHandler.log, panicHandler.sinks = log, append([]Sink(nil), sinks...)
+	panicHandler.Unlock()
+}
+
+// HandlePanic handles the panic in progress, if any, as set by
+// InstallPanicHandler. It must be deferred directly at the top of main and of
+// goroutine functions not started with Go:
+//
+//	func main() {
+//		defer errors.HandlePanic()
+//		...
+//	}
+//
+// Without a handler installed, HandlePanic does not recover: the panic goes
+// on from its original site.
+func HandlePanic() {
+	panicHandler.RLock()
+	log, sinks := panicHandler.log, panicHandler.sinks
+	panicHandler.RUnlock()
+	if log == nil {
+		return
+	}
+	if v := recover(); v != nil {
+		handlePanic(v, log, sinks)
+	}
+}
+
+// Go runs fn in a new goroutine, handling its panics like HandlePanic.
+func Go(fn func()) {
+	go func() {
+		defer HandlePanic()
+		fn()
+	}()
+}
+
+// handlePanic handles a panic with the value v, logging it to log and
+// reporting it to sinks.
+func handlePanic(v interface{}, log Logger, sinks []Sink) {
+	e := panicError(v)
+	notify(EVENT_PANIC, e)
+	for _, s := range sinks {
+		s.Report(e)
+	}
+	e.Log(log)
+}
+
+// panicError returns the FATAL Error describing a panic with the value v,
+// with the stack traces at the time of the panic. An error panicked with is
+// wrapped, and keeps its code; it is not changed, as it may be shared.
+func panicError(v interface{}) Error {
+	desc := Desc{Level: FATAL, Text: "unhandled panic", Info: []string{"debug.stack"}}
+	err, ok := v.(error)
+	if !ok {
+		desc.Code = ERR_SYSTEM
+		desc.Text += fmt.Sprintf(": %v", v)
+		return New(desc)
+	}
+	if p, ok := err.(panicValue); ok {
+		err = p.errorMessage
+	}
+	if e, ok := err.(Error); !ok || e.Code() == 0 {
+		desc.Code = ERR_SYSTEM
+	}
+	return Wrap(err, desc)
+}
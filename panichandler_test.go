@@ -0,0 +1,78 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"runtime/debug"
+	"strings"
+	"testing"
+)
+
+func TestPanicHandler(t *testing.T) {
+	var events []string
+	remove := AddObserver(func(event int8, err Error) {
+		if event == EVENT_PANIC {
+			events = append(events, err.Text())
+		}
+	})
+	defer remove()
+	reported := make(chan Error, 1)
+	log := &mockLogger{}
+	InstallPanicHandler(log, SinkFunc(func(e Error) error {
+		reported <- e
+		return nil
+	}))
+	defer InstallPanicHandler(nil)
+
+	func() {
+		defer HandlePanic()
+		panic("boom")
+	}()
+	e := <-reported
+	if e.Level() != FATAL || e.Code() != ERR_SYSTEM || e.Text() != "unhandled panic: boom" || hasStack(e.Info()) == minimalBuild {
+		t.Errorf(`panic("boom") reported as %v, level %d`, e, e.Level())
+	}
+	if !strings.HasPrefix(log.log, "[FATAL] ") || len(events) != 1 {
+		t.Errorf(`logged %q, events %q`, log.log, events)
+	}
+
+	shared := New(Desc{Code: 0x42, Text: "disk full"})
+	Go(func() {
+		Panic(shared)
+	})
+	if e := <-reported; e.Level() != FATAL || e.Code() != 0x42 || e.(interface{ Unwrap() error }).Unwrap() != shared {
+		t.Errorf(`Panic(err) in Go reported as %v, level %d`, e, e.Level())
+	}
+	if shared.Level() != ERROR || len(shared.Info()) != 0 {
+		t.Errorf(`error panicked with changed to level %d, Info %q`, shared.Level(), shared.Info())
+	}
+
+	InstallPanicHandler(nil)
+	defer func() {
+		if v := recover(); v != "through" {
+			t.Errorf(`recovered %v without handler`, v)
+		}
+		if st := string(debug.Stack()); strings.Contains(st, "HandlePanic") {
+			t.Errorf("panic without handler raised again by HandlePanic:\n%s", st)
+		}
+	}()
+	func() {
+		defer HandlePanic()
+		panic("through")
+	}()
+}
@@ -0,0 +1,55 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+// Configuration profiles, see UseProfile.
+const (
+	// DEVELOPMENT captures stacks, logs all details, and flags unregistered
+	// codes to observers.
+	DEVELOPMENT int8 = iota + 1
+	// PRODUCTION drops stacks, logs text and codes only, with registered
+	// codes rendered symbolically.
+	PRODUCTION
+	// TEST is like DEVELOPMENT, but logging a FATAL error panics instead of
+	// exiting, so that tests can recover.
+	TEST
+)
+
+// UseProfile configures the package coherently for a kind of environment:
+// DEVELOPMENT, PRODUCTION or TEST. It sets the stack capture, the verbosity,
+// the log level, the code style and the FATAL policy; observers are left as
+// they are. Individual settings can be changed afterwards.
+func UseProfile(profile int8) {
+	switch profile {
+	case DEVELOPMENT:
+		SetStacks(true)
+		SetVerbosity(VERBOSITY_FIELDS)
+		SetLogLevel(LEVEL_WARNING)
+		SetCodeStyle(CODES_STRICT)
+		SetFatalPolicy(FATAL_EXIT)
+	case PRODUCTION:
+		SetStacks(false)
+		SetVerbosity(VERBOSITY_TEXT)
+		SetLogLevel(LEVEL_WARNING)
+		SetCodeStyle(CODES_SYMBOLIC)
+		SetFatalPolicy(FATAL_EXIT)
+	case TEST:
+		SetStacks(true)
+		SetVerbosity(VERBOSITY_FIELDS)
+		SetLogLevel(LEVEL_WARNING)
+		SetCodeStyle(CODES_STRICT)
+		SetFatalPolicy(FATAL_PANIC)
+	}
+}
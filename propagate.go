@@ -0,0 +1,75 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.7
+// +build go1.7
+
+package errors
+
+import (
+	"context"
+	"sync"
+)
+
+// errorKey is the context key of the error stored by ContextWithError.
+type errorKey struct{}
+
+var propagation struct {
+	sync.RWMutex
+	keys []string
+}
+
+// SetPropagatedFields sets the keys of the fields, e.g. "request_id" and
+// "tenant", copied by NewCtx from the error stored in the context (see
+// ContextWithError) into the errors it creates, unless they are set already.
+// Calling it without arguments stops propagation.
+func SetPropagatedFields(keys ...string) {
+	propagation.Lock()
+	propagation.keys = append([]string(nil), keys...)
+	propagation.Unlock()
+}
+
+// ContextWithError returns a copy of ctx holding err, typically an error
+// carrying the fields of a request scope, to be propagated to the errors
+// created downstream, see SetPropagatedFields.
+func ContextWithError(ctx context.Context, err Error) context.Context {
+	return context.WithValue(ctx, errorKey{}, err)
+}
+
+// ErrorFromContext returns the error stored in ctx by ContextWithError, or
+// nil.
+func ErrorFromContext(ctx context.Context) Error {
+	err, _ := ctx.Value(errorKey{}).(Error)
+	return err
+}
+
+// propagateFields copies the propagated fields of the error stored in ctx to
+// em.
+func propagateFields(ctx context.Context, em *errorMessage) {
+	propagation.RLock()
+	keys := propagation.keys
+	propagation.RUnlock()
+	if len(keys) == 0 {
+		return
+	}
+	src := messageOf(ErrorFromContext(ctx))
+	if src == nil || src == em {
+		return
+	}
+	for _, k := range keys {
+		if v := src.field(k); v != nil && em.field(k) == nil {
+			em.setField(k, v)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.7
+// +build go1.7
+
+package errors
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPropagatedFields(t *testing.T) {
+	defer SetPropagatedFields()
+	field := func(e Error, key string) interface{} {
+		v, _ := Field(e, key)
+		return v
+	}
+	scope := New(Desc{Text: "request scope", Fields: map[string]interface{}{"request_id": "r-9", "tenant": "acme", "user": "bob"}})
+	ctx := ContextWithError(context.Background(), scope)
+	if ErrorFromContext(ctx) != scope || ErrorFromContext(context.Background()) != nil {
+		t.Errorf(`ErrorFromContext does not return the stored error`)
+	}
+
+	if e := NewCtx(ctx, "before"); field(e, "request_id") != nil {
+		t.Errorf(`fields propagated without SetPropagatedFields`)
+	}
+	SetPropagatedFields("request_id", "tenant", "missing")
+	e := NewCtx(ctx, Desc{Text: "downstream", Fields: map[string]interface{}{"tenant": "other"}})
+	if field(e, "request_id") != "r-9" || field(e, "tenant") != "other" || field(e, "user") != nil || field(e, "missing") != nil {
+		t.Errorf(`fields of NewCtx error: request_id %v, tenant %v, user %v`, field(e, "request_id"), field(e, "tenant"), field(e, "user"))
+	}
+}
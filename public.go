@@ -0,0 +1,54 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+// PublicText returns the text of err meant to be shown to end users, or an
+// empty string if none has been set. Unlike the main text, it should never
+// contain internal details. The support reference of err, if any, is appended.
+func PublicText(err error) string {
+	em := messageOf(err)
+	if em == nil {
+		return ""
+	}
+	checkInvariants(em, "externalized")
+	public := em.publicText()
+	if ref, _ := em.field(fieldReference).(string); ref != "" {
+		if public == "" {
+			return "Reference: " + ref
+		}
+		return public + " (reference: " + ref + ")"
+	}
+	return public
+}
+
+// publicText returns the public text of em, as rendered by its renderer if
+// any, see RegisterRenderer.
+func (em *errorMessage) publicText() string {
+	if s, ok := render(em, RENDER_PUBLIC); ok {
+		return s
+	}
+	return em.public
+}
+
+// SetPublicText sets the text of err meant to be shown to end users.
+func SetPublicText(err Error, text string) Error {
+	switch e := err.(type) {
+	case *errorMessage:
+		e.public = text
+	case readOnly:
+		e.deny("SetPublicText")
+	}
+	return err
+}
@@ -0,0 +1,82 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"regexp"
+	"sort"
+)
+
+// InfoMatching returns the Info elements of err and of the errors it wraps
+// that match re, in order, from the outermost error.
+func InfoMatching(err error, re *regexp.Regexp) []string {
+	var res []string
+	eachMessage(err, func(em *errorMessage) bool {
+		for _, s := range em.infoList() {
+			if re.MatchString(s) {
+				res = append(res, s)
+			}
+		}
+		return true
+	})
+	return res
+}
+
+// FindField returns the first field, in key order, for which pred returns
+// true, of err or else of the outermost error it wraps having one. The last
+// result is false if there is no such field.
+func FindField(err error, pred func(k string, v interface{}) bool) (key string, value interface{}, found bool) {
+	eachMessage(err, func(em *errorMessage) bool {
+		keys := make([]string, 0, len(em.fields))
+		for k := range em.fields {
+			if !isInfoKey(k) {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if pred(k, em.fields[k]) {
+				key, value, found = k, em.fields[k], true
+				return false
+			}
+		}
+		return true
+	})
+	return key, value, found
+}
+
+// eachMessage calls fn with the errors of this package in the chain of err,
+// through Unwrap methods, from the outermost, until it returns false.
+func eachMessage(err error, fn func(em *errorMessage) bool) {
+	for err != nil {
+		if em := messageOf(err); em != nil {
+			if !fn(em) {
+				return
+			}
+			err = em.cause
+			continue
+		}
+		u, ok := err.(interface {
+			Unwrap() error
+		})
+		if !ok {
+			return
+		}
+		err = u.Unwrap()
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.13 && !errors_minimal
+// +build go1.13,!errors_minimal
+
+package errors
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+)
+
+func TestQuery(t *testing.T) {
+	err := New("abc").AddInfo("user 17", "SQLSTATE 23505", "SQLSTATE 40001")
+	re := regexp.MustCompile(`^SQLSTATE \d+$`)
+	if got := InfoMatching(err, re); len(got) != 2 || got[0] != "SQLSTATE 23505" || got[1] != "SQLSTATE 40001" {
+		t.Errorf(`InfoMatching(err, %q) = %q`, re, got)
+	}
+	if got := InfoMatching(fmt.Errorf("SQLSTATE 23505"), re); got != nil {
+		t.Errorf(`InfoMatching(fmt.Errorf(...), %q) = %q, want nil`, re, got)
+	}
+
+	SetOrigin("svc", "")
+	err = New("abc")
+	SetOrigin("", "")
+	k, v, ok := FindField(err, func(k string, v interface{}) bool { return v == "svc" })
+	if !ok || k != fieldOriginService || v != "svc" {
+		t.Errorf(`FindField(err, v == "svc") = %q, %v, %t, want %q, %q, true`, k, v, ok, fieldOriginService, "svc")
+	}
+	if _, _, ok = FindField(err, func(string, interface{}) bool { return false }); ok {
+		t.Errorf(`FindField(err, false) found a field`)
+	}
+
+	inner := WithField(New("insert failed").AddInfo("SQLSTATE 23505"), "table", "users")
+	outer := fmt.Errorf("saving: %w", Wrap(WithField(inner, "attempt", 2), "saving user"))
+	if got := InfoMatching(outer, re); len(got) != 1 || got[0] != "SQLSTATE 23505" {
+		t.Errorf(`InfoMatching(wrapped, %q) = %q`, re, got)
+	}
+	if k, v, ok := FindField(outer, func(k string, _ interface{}) bool { return k == "table" }); !ok || k != "table" || v != "users" {
+		t.Errorf(`FindField(wrapped, "table") = %q, %v, %t`, k, v, ok)
+	}
+}
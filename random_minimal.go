@@ -0,0 +1,39 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build errors_minimal
+// +build errors_minimal
+
+package errors
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// random is the source of randomBytes, avoiding crypto/rand and its
+// dependencies on fmt and reflection.
+var random = struct {
+	sync.Mutex
+	*rand.Rand
+}{Rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
+
+// randomBytes fills b with pseudo-random bytes; they are not suitable for
+// security purposes.
+func randomBytes(b []byte) {
+	random.Lock()
+	random.Read(b)
+	random.Unlock()
+}
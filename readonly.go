@@ -0,0 +1,105 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import "strconv"
+
+// readOnly is a view of an Error whose setters have no effect.
+type readOnly struct {
+	e Error
+}
+
+// ReadOnly returns a view of err whose setters have no effect, suitable for
+// handing to code that must not tamper with its classification, such as
+// plugins or user-supplied callbacks. Every attempt to modify it is reported
+// to observers with EVENT_READ_ONLY.
+func ReadOnly(err Error) Error {
+	if err == nil {
+		return nil
+	}
+	if r, ok := err.(readOnly); ok {
+		return r
+	}
+	return readOnly{err}
+}
+
+// deny reports an attempt to modify r with the method op.
+func (r readOnly) deny(op string) {
+	notify(EVENT_READ_ONLY, New(Desc{
+		Code: ERR_IMMUTABLE,
+		Text: op + " called on read-only error " + strconv.Quote(r.e.Error()),
+	}))
+}
+
+// Error returns the text of the viewed error.
+func (r readOnly) Error() string {
+	return r.e.Error()
+}
+
+// Level returns the error level.
+func (r readOnly) Level() int8 {
+	return r.e.Level()
+}
+
+// Code returns the error code.
+func (r readOnly) Code() int {
+	return r.e.Code()
+}
+
+// Text returns the error text.
+func (r readOnly) Text() string {
+	return r.e.Text()
+}
+
+// SetLevel has no effect on a read-only view.
+func (r readOnly) SetLevel(int8) Error {
+	r.deny("SetLevel")
+	return r
+}
+
+// SetCode has no effect on a read-only view.
+func (r readOnly) SetCode(int) Error {
+	r.deny("SetCode")
+	return r
+}
+
+// SetText has no effect on a read-only view.
+func (r readOnly) SetText(string) Error {
+	r.deny("SetText")
+	return r
+}
+
+// AddInfo has no effect on a read-only view.
+func (r readOnly) AddInfo(...string) Error {
+	r.deny("AddInfo")
+	return r
+}
+
+// Info returns a copy of the error info.
+func (r readOnly) Info() []string {
+	return append([]string(nil), r.e.Info()...)
+}
+
+// Unwrap returns the viewed error, so that errors.Is and errors.As see
+// through the view.
+func (r readOnly) Unwrap() error {
+	return r.e
+}
+
+// Log sends the viewed error to the provided log.
+func (r readOnly) Log(log Logger) Error {
+	r.e.Log(log)
+	return r
+}
@@ -0,0 +1,108 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Field key used to record the support reference of an error.
+const fieldReference = "reference"
+
+// CounterStore provides named, monotonically increasing counters. It is used
+// to number error occurrences, see AssignReference.
+type CounterStore interface {
+	// Next increments the counter with the given key and returns its new value.
+	Next(key string) (uint64, error)
+}
+
+var references struct {
+	sync.RWMutex
+	store CounterStore
+}
+
+// SetReferenceStore sets the store used by AssignReference; nil disables
+// support references (the default).
+func SetReferenceStore(s CounterStore) {
+	references.Lock()
+	references.store = s
+	references.Unlock()
+}
+
+// AssignReference gives err a short reference to quote when contacting
+// support, made of its code name (or hexadecimal code) and its occurrence
+// number for that code, like "E-STORAGE-1042". The reference is appended to
+// the public text of err. AssignReference returns the reference, which is
+// kept if err already had one; it returns an empty string if no store is set
+// or the store fails.
+func AssignReference(err Error) string {
+	em := ownMessage(err)
+	if em == nil {
+		return Reference(err)
+	}
+	if ref, _ := em.field(fieldReference).(string); ref != "" {
+		return ref
+	}
+	references.RLock()
+	store := references.store
+	references.RUnlock()
+	if store == nil {
+		return ""
+	}
+	name := CodeName(em.code)
+	if name == "" && em.code != 0 {
+		name = zeroPad(strings.ToUpper(strconv.FormatInt(int64(em.code), 16)), 4)
+	}
+	n, e := store.Next(name)
+	if e != nil {
+		return ""
+	}
+	ref := "E-" + strconv.FormatUint(n, 10)
+	if name != "" {
+		ref = "E-" + name + "-" + strconv.FormatUint(n, 10)
+	}
+	em.setField(fieldReference, ref)
+	return ref
+}
+
+// Reference returns the support reference assigned to err, or an empty string.
+func Reference(err error) string {
+	if em := messageOf(err); em != nil {
+		ref, _ := em.field(fieldReference).(string)
+		return ref
+	}
+	return ""
+}
+
+// memoryCounters is a CounterStore keeping its counters in memory.
+type memoryCounters struct {
+	sync.Mutex
+	counters map[string]uint64
+}
+
+// NewMemoryCounterStore returns a CounterStore keeping its counters in memory,
+// starting from zero in every process.
+func NewMemoryCounterStore() CounterStore {
+	return &memoryCounters{counters: make(map[string]uint64)}
+}
+
+func (m *memoryCounters) Next(key string) (uint64, error) {
+	m.Lock()
+	defer m.Unlock()
+	m.counters[key]++
+	return m.counters[key], nil
+}
@@ -0,0 +1,84 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// fileCounters is a CounterStore persisting its counters to a JSON file.
+type fileCounters struct {
+	memoryCounters
+	path string
+}
+
+// NewFileCounterStore returns a CounterStore persisting its counters to the
+// JSON file at path, which is created if it does not exist. The file is
+// rewritten atomically after every increment, so the store suits moderate
+// error rates only.
+func NewFileCounterStore(path string) (CounterStore, error) {
+	f := &fileCounters{memoryCounters{counters: make(map[string]uint64)}, path}
+	data, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if len(data) > 0 {
+		if err = json.Unmarshal(data, &f.counters); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+func (f *fileCounters) Next(key string) (uint64, error) {
+	f.Lock()
+	defer f.Unlock()
+	f.counters[key]++
+	data, err := json.Marshal(f.counters)
+	if err == nil {
+		err = writeFileAtomic(f.path, data)
+	}
+	if err != nil {
+		f.counters[key]--
+		return 0, err
+	}
+	return f.counters[key], nil
+}
+
+// writeFileAtomic replaces the content of the file at path with data, through
+// a temporary file renamed over it.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if e := tmp.Close(); err == nil {
+		err = e
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
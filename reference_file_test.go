@@ -0,0 +1,47 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileCounterStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "errors")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "counters.json")
+	for i := uint64(1); i <= 2; i++ {
+		s, err := NewFileCounterStore(path)
+		if err != nil {
+			t.Fatalf(`NewFileCounterStore(path) error %v`, err)
+		}
+		if n, err := s.Next("X"); n != i || err != nil {
+			t.Errorf(`Next("X") = %d, %v, want %d, <nil>`, n, err, i)
+		}
+	}
+	ioutil.WriteFile(path, []byte("garbage"), 0644)
+	if _, err = NewFileCounterStore(path); err == nil {
+		t.Errorf(`NewFileCounterStore(garbage) did not fail`)
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import "testing"
+
+func TestAssignReference(t *testing.T) {
+	if ref := AssignReference(New("abc")); ref != "" {
+		t.Errorf(`AssignReference(...) = %q without store, want ""`, ref)
+	}
+	RegisterCode(0x2104, "STORAGE")
+	defer RegisterCode(0x2104, "")
+	SetReferenceStore(NewMemoryCounterStore())
+	defer SetReferenceStore(nil)
+
+	err := New(Desc{Code: 0x2104, PublicText: "No such user."})
+	for _, want := range []string{"E-STORAGE-1", "E-STORAGE-1"} {
+		if ref := AssignReference(err); ref != want {
+			t.Errorf(`AssignReference(err) = %q, want %q`, ref, want)
+		}
+	}
+	if PublicText(err) != "No such user. (reference: E-STORAGE-1)" {
+		t.Errorf(`PublicText(err) = %q`, PublicText(err))
+	}
+	for err, want := range map[Error]string{
+		New(Desc{Code: 0x2104}): "E-STORAGE-2",
+		New(Desc{Code: 0x17}):   "E-0017-1",
+		New("abc"):              "E-1",
+	} {
+		if ref := AssignReference(err); ref != want || Reference(err) != want {
+			t.Errorf(`AssignReference(%v) = %q, want %q`, err, ref, want)
+		}
+	}
+	if PublicText(ReadOnly(err)) != "No such user. (reference: E-STORAGE-1)" {
+		t.Errorf(`PublicText(ReadOnly(err)) = %q`, PublicText(ReadOnly(err)))
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// Formats in which errors are rendered, see RegisterRenderer.
+const (
+	// RENDER_TEXT is the rendering returned by Error.
+	RENDER_TEXT int8 = iota
+	// RENDER_REPORT is the full report, rendered with the verb %+v.
+	RENDER_REPORT
+	// RENDER_PUBLIC is the text shown to end users, by PublicText and the
+	// HTTP responses.
+	RENDER_PUBLIC
+)
+
+// Renderer writes to w the rendering of err in the given format, from
+// RENDER_TEXT to RENDER_PUBLIC. Writing nothing leaves the default rendering.
+// Calling the methods of err, including Error, gives the default results.
+type Renderer func(err Error, w io.Writer, format int8)
+
+var renderers struct {
+	sync.RWMutex
+	count  int32 // read atomically
+	byCode map[int]Renderer
+}
+
+// RegisterRenderer registers r as the renderer of the errors with the given
+// code, e.g. for quota errors to show the usage; nil removes the
+// registration.
+func RegisterRenderer(code int, r Renderer) {
+	renderers.Lock()
+	if r == nil {
+		delete(renderers.byCode, code)
+	} else {
+		if renderers.byCode == nil {
+			renderers.byCode = make(map[int]Renderer)
+		}
+		renderers.byCode[code] = r
+	}
+	atomic.StoreInt32(&renderers.count, int32(len(renderers.byCode)))
+	renderers.Unlock()
+}
+
+// render returns the rendering of em in format by its registered renderer,
+// if any and if it writes something.
+func render(em *errorMessage, format int8) (string, bool) {
+	if atomic.LoadInt32(&renderers.count) == 0 {
+		return "", false
+	}
+	renderers.RLock()
+	r := renderers.byCode[em.code]
+	renderers.RUnlock()
+	if r == nil {
+		return "", false
+	}
+	var b bytes.Buffer
+	r(defaultRendering{em}, &b, format)
+	return b.String(), b.Len() > 0
+}
+
+// defaultRendering is the view of an error passed to its renderer, rendered
+// by default.
+type defaultRendering struct {
+	*errorMessage
+}
+
+// sealed lets messageOf reach the wrapped errorMessage.
+func (d defaultRendering) sealed() Error {
+	return d.errorMessage
+}
+
+// Error returns the default rendering of d.
+func (d defaultRendering) Error() string {
+	return d.plainError()
+}
@@ -0,0 +1,59 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRenderer(t *testing.T) {
+	defer RegisterRenderer(0x51, nil)
+	RegisterRenderer(0x51, func(err Error, w io.Writer, format int8) {
+		used, _ := Fields(err)["used"].(int)
+		bar := strings.Repeat("#", used/10) + strings.Repeat(".", 10-used/10)
+		switch format {
+		case RENDER_TEXT:
+			fmt.Fprintf(w, "%s [%s] %d%%", err.Text(), bar, used)
+		case RENDER_PUBLIC:
+			fmt.Fprintf(w, "You have used %d%% of your quota.", used)
+		}
+	})
+	err := WithField(New(Desc{Code: 0x51, Text: "quota exceeded", PublicText: "Quota exceeded."}), "used", 80)
+	if got := err.Error(); got != "quota exceeded [########..] 80%" {
+		t.Errorf(`err.Error() = %q`, got)
+	}
+	if got := fmt.Sprintf("%v", err); got != err.Error() {
+		t.Errorf(`fmt.Sprintf("%%v", err) = %q`, got)
+	}
+	if got := fmt.Sprintf("%+v", err); !strings.HasPrefix(got, "quota exceeded [########..] 80%\n\tused=80") && !minimalBuild {
+		t.Errorf(`fmt.Sprintf("%%+v", err) = %q`, got)
+	}
+	if got := PublicText(err); got != "You have used 80% of your quota." {
+		t.Errorf(`PublicText(err) = %q`, got)
+	}
+	if got := New(Desc{Code: 0x52, Text: "other"}).Error(); got != "other (code: 0x0052)" {
+		t.Errorf(`Error() without renderer = %q`, got)
+	}
+
+	RegisterRenderer(0x51, func(err Error, w io.Writer, format int8) {
+		fmt.Fprintf(w, "custom: %v", err)
+	})
+	if got := err.Error(); got != "custom: quota exceeded (code: 0x0051)" {
+		t.Errorf(`err.Error() with a renderer calling Error = %q`, got)
+	}
+}
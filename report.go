@@ -0,0 +1,98 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import "strings"
+
+// Report returns the full report of err: its text and code, its info with
+// collapsed stack traces (see SetFrameCollapse), its cause tree and fields,
+// followed by the same for each error in its chain of causes. Log uses it for
+// PANIC and FATAL conditions, whatever the verbosity, and Panic for the
+// message printed by the runtime. The renderer registered for the code of
+// err, if any, is used instead, see RegisterRenderer.
+func Report(err error) string {
+	if em := messageOf(err); em != nil {
+		if s, ok := render(em, RENDER_REPORT); ok {
+			return s
+		}
+	}
+	return defaultReport(err, true)
+}
+
+// defaultReport implements Report, rendering the text of the errors with
+// their registered renderers if custom is set.
+func defaultReport(err error, custom bool) string {
+	config.RLock()
+	collapse := !config.noCollapse
+	config.RUnlock()
+	var lines []string
+	for prefix := ""; err != nil; prefix = "caused by: " {
+		if em := messageOf(err); em != nil {
+			text := em.plainError()
+			if custom {
+				text = em.Error()
+			}
+			lines = em.detailLines(append(lines, prefix+text), collapse, true)
+		} else {
+			lines = append(lines, prefix+err.Error())
+		}
+		err = unwrap(err)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// report implements Report for em.
+func (em *errorMessage) report() string {
+	return Report(em)
+}
+
+// unwrap returns the error wrapped by err, if any.
+func unwrap(err error) error {
+	if em := messageOf(err); em != nil {
+		return em.cause
+	}
+	if u, ok := err.(interface {
+		Unwrap() error
+	}); ok {
+		return u.Unwrap()
+	}
+	return nil
+}
+
+// Panic panics with err, converted to an Error if needed, so that the message
+// printed by the runtime if the panic is not recovered is the full report of
+// err (see Report) instead of its text. The recovered value is an Error.
+func Panic(err error) {
+	e := from(err)
+	if em := messageOf(e); em != nil {
+		panic(panicValue{em})
+	}
+	panic(e)
+}
+
+// panicValue is the Error Panic panics with.
+type panicValue struct {
+	*errorMessage
+}
+
+// sealed lets messageOf reach the wrapped errorMessage.
+func (p panicValue) sealed() Error {
+	return p.errorMessage
+}
+
+// Error returns the full report of p, as it is printed by the runtime.
+func (p panicValue) Error() string {
+	return p.report()
+}
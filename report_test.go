@@ -0,0 +1,58 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestReport(t *testing.T) {
+	defer SetStacks(true)
+	SetStacks(false)
+	cause := New(Desc{Code: 0x21, Text: "connection refused"})
+	cause.(*errorMessage).setField("host", "db1")
+	err := Wrap(cause, "loading user")
+	want := "loading user: connection refused (code: 0x0021)\n" +
+		"caused by: connection refused (code: 0x0021)\n\thost=db1"
+	if got := Report(err); got != want {
+		t.Errorf(`Report(err) = %q, want %q`, got, want)
+	}
+	if got := fmt.Sprintf("%+v", err); got != want && !minimalBuild {
+		t.Errorf(`fmt.Sprintf("%%+v", err) = %q, want %q`, got, want)
+	}
+	if got := fmt.Sprintf("%v", err); got != err.Error() {
+		t.Errorf(`fmt.Sprintf("%%v", err) = %q`, got)
+	}
+	if got := Report(fmt.Errorf("outer: %w", cause)); got != "outer: connection refused (code: 0x0021)\n"+want[strings.Index(want, "caused"):] {
+		t.Errorf(`Report(fmt.Errorf(...)) = %q`, got)
+	}
+
+	log := &mockLogger{}
+	New(Desc{Level: PANIC, Text: "corrupt index", Info: []string{"segment 7"}}).Log(log)
+	if want := "[PANIC] corrupt index\n\tsegment 7\n"; log.log != want {
+		t.Errorf(`log.log = %q, want %q`, log.log, want)
+	}
+
+	defer func() {
+		r := recover()
+		e, ok := r.(Error)
+		if !ok || e.Code() != 0x21 || e.Error() != want {
+			t.Errorf(`recover() = %#v`, r)
+		}
+	}()
+	Panic(err)
+}
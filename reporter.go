@@ -0,0 +1,298 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"sync"
+	"time"
+)
+
+// Policies applied by a Reporter when its queue is full.
+const (
+	// DROP_OLDEST evicts the oldest queued error to make room.
+	DROP_OLDEST int8 = iota
+	// DROP_BELOW_LEVEL drops the incoming error if its level is below the
+	// configured one, and otherwise evicts the oldest queued error below it.
+	DROP_BELOW_LEVEL
+	// BLOCK_WITH_TIMEOUT waits for room up to the configured timeout, then
+	// drops the incoming error.
+	BLOCK_WITH_TIMEOUT
+)
+
+// Sink is the destination of the errors sent to a Reporter.
+type Sink interface {
+	Report(Error) error
+}
+
+// SinkFunc adapts a function to the Sink interface.
+type SinkFunc func(Error) error
+
+// Report calls f(err).
+func (f SinkFunc) Report(err Error) error {
+	return f(err)
+}
+
+// Spill stores the errors a Reporter failed to deliver, for them to be
+// delivered later, see ReporterOptions.
+type Spill interface {
+	// Push stores err.
+	Push(err Error) error
+	// Peek returns the oldest error stored, or nil if there is none.
+	Peek() (Error, error)
+	// Pop removes the oldest error stored.
+	Pop() error
+}
+
+// ReporterOptions configures a Reporter.
+type ReporterOptions struct {
+	// QueueSize is the maximum number of queued errors (default 256).
+	QueueSize int
+	// Policy is the policy applied when the queue is full.
+	Policy int8
+	// Level is the threshold used by DROP_BELOW_LEVEL.
+	Level Level
+	// Timeout is the longest time BLOCK_WITH_TIMEOUT waits for room; zero
+	// means waiting indefinitely.
+	Timeout time.Duration
+	// Spill, if not nil, stores the errors the sink fails to accept, such as
+	// a FileSpill surviving restarts. They are delivered again, oldest first,
+	// after the sink accepts an error, every RetryInterval while no errors
+	// are reported (default 10s), and when the Reporter starts and is closed.
+	Spill         Spill
+	RetryInterval time.Duration
+}
+
+// ReporterStats holds the counters of a Reporter.
+type ReporterStats struct {
+	Queued   int    // errors currently queued
+	Reported uint64 // errors accepted by the sink
+	Failed   uint64 // errors rejected by the sink
+	Dropped  uint64 // errors dropped because of a full queue
+	Spilled  uint64 // errors rejected by the sink and stored in the spill
+}
+
+// Reporter sends errors to a Sink asynchronously, through a bounded queue, so
+// that a slow sink cannot stall the code reporting errors. FATAL errors are
+// never dropped: when the queue is full they wait for room, whatever the
+// policy.
+type Reporter struct {
+	sink  Sink
+	opts  ReporterOptions
+	mu    sync.Mutex
+	queue []Error
+	freed chan struct{} // closed (and replaced) whenever room is made
+	ready chan struct{}
+	done  chan struct{}
+	stats ReporterStats
+	close bool
+}
+
+// NewReporter returns a Reporter sending errors to sink, and starts its
+// delivery goroutine. Close must be called to stop it.
+func NewReporter(sink Sink, opts ReporterOptions) *Reporter {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 256
+	}
+	if opts.RetryInterval <= 0 {
+		opts.RetryInterval = 10 * time.Second
+	}
+	r := &Reporter{
+		sink:  sink,
+		opts:  opts,
+		freed: make(chan struct{}),
+		ready: make(chan struct{}, 1),
+		done:  make(chan struct{}),
+	}
+	go r.run()
+	return r
+}
+
+// Report queues err for delivery. It returns false if err has been dropped,
+// or if the Reporter is closed.
+func (r *Reporter) Report(err Error) bool {
+	return r.report(err, nil)
+}
+
+// report implements Report; waiting for room is abandoned, and err dropped,
+// when cancel is closed.
+func (r *Reporter) report(err Error, cancel <-chan struct{}) bool {
+	Handled(err)
+	var deadline <-chan time.Time
+	r.mu.Lock()
+	for !r.close && len(r.queue) >= r.opts.QueueSize {
+		if err.Level() != FATAL {
+			switch r.opts.Policy {
+			case DROP_OLDEST:
+				if r.evict(LEVEL_FATAL) {
+					continue
+				}
+			case DROP_BELOW_LEVEL:
+				if Level(err.Level()).Less(r.opts.Level) {
+					r.stats.Dropped++
+					r.mu.Unlock()
+					return false
+				}
+				if r.evict(r.opts.Level) {
+					continue
+				}
+			case BLOCK_WITH_TIMEOUT:
+				if deadline == nil && r.opts.Timeout > 0 {
+					deadline = time.After(r.opts.Timeout)
+				}
+			}
+		}
+		freed := r.freed
+		r.mu.Unlock()
+		select {
+		case <-freed:
+		case <-cancel:
+			r.mu.Lock()
+			r.stats.Dropped++
+			r.mu.Unlock()
+			return false
+		case <-deadline:
+			r.mu.Lock()
+			r.stats.Dropped++
+			r.mu.Unlock()
+			return false
+		}
+		r.mu.Lock()
+	}
+	if r.close {
+		r.mu.Unlock()
+		return false
+	}
+	r.queue = append(r.queue, err)
+	select {
+	case r.ready <- struct{}{}:
+	default:
+	}
+	r.mu.Unlock()
+	return true
+}
+
+// evict removes the oldest queued error with a level below level, and reports
+// whether there was one. It must be called with r.mu held.
+func (r *Reporter) evict(level Level) bool {
+	for i, e := range r.queue {
+		if Level(e.Level()).Less(level) {
+			r.queue = append(r.queue[:i], r.queue[i+1:]...)
+			r.stats.Dropped++
+			return true
+		}
+	}
+	return false
+}
+
+// Stats returns the current counters of the Reporter.
+func (r *Reporter) Stats() ReporterStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.stats
+	s.Queued = len(r.queue)
+	return s
+}
+
+// Close stops accepting errors, waits until the queued ones are delivered,
+// and stops the delivery goroutine.
+func (r *Reporter) Close() {
+	r.mu.Lock()
+	if !r.close {
+		r.close = true
+		close(r.freed)
+		close(r.ready)
+	}
+	r.mu.Unlock()
+	<-r.done
+}
+
+func (r *Reporter) run() {
+	defer close(r.done)
+	// spilled errors may remain from a previous run
+	backlog := r.opts.Spill != nil
+	for {
+		r.mu.Lock()
+		if len(r.queue) == 0 {
+			closed := r.close
+			r.mu.Unlock()
+			if backlog {
+				backlog = !r.replay()
+			}
+			if closed {
+				return
+			}
+			if !backlog {
+				<-r.ready
+				continue
+			}
+			select {
+			case <-r.ready:
+			case <-time.After(r.opts.RetryInterval):
+			}
+			continue
+		}
+		err := r.queue[0]
+		r.queue[0] = nil
+		r.queue = r.queue[1:]
+		if !r.close {
+			close(r.freed)
+			r.freed = make(chan struct{})
+		}
+		r.mu.Unlock()
+
+		failed := r.sink.Report(err) != nil
+		spilled := failed && r.opts.Spill != nil && r.opts.Spill.Push(err) == nil
+		r.mu.Lock()
+		if failed {
+			r.stats.Failed++
+		} else {
+			r.stats.Reported++
+		}
+		if spilled {
+			r.stats.Spilled++
+		}
+		r.mu.Unlock()
+		if spilled {
+			backlog = true
+		} else if !failed && backlog {
+			backlog = !r.replay()
+		}
+	}
+}
+
+// replay delivers the errors stored in the spill, until the sink rejects one,
+// and reports whether the spill has been emptied. Errors which cannot be read
+// from the spill are skipped.
+func (r *Reporter) replay() bool {
+	for {
+		err, e := r.opts.Spill.Peek()
+		if e == nil && err == nil {
+			return true
+		}
+		if e == nil && r.sink.Report(err) != nil {
+			return false
+		}
+		if r.opts.Spill.Pop() != nil {
+			return false
+		}
+		r.mu.Lock()
+		if e == nil {
+			r.stats.Reported++
+		} else {
+			r.stats.Failed++
+		}
+		r.mu.Unlock()
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gatedSink blocks every delivery until released, and records what it got.
+type gatedSink struct {
+	gate chan struct{}
+	mu   sync.Mutex
+	got  []string
+}
+
+func (s *gatedSink) Report(err Error) error {
+	<-s.gate
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.got = append(s.got, err.Text())
+	if err.Code() != 0 {
+		return fmt.Errorf("rejected")
+	}
+	return nil
+}
+
+// fill reports warnings, waiting for the first one to be picked up for
+// delivery before reporting the others.
+func fill(r *Reporter, texts ...string) {
+	for i, t := range texts {
+		r.Report(New(Desc{Level: WARNING, Text: t}))
+		for i == 0 && r.Stats().Queued > 0 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+}
+
+func TestReporterDropOldest(t *testing.T) {
+	s := &gatedSink{gate: make(chan struct{})}
+	r := NewReporter(s, ReporterOptions{QueueSize: 2, Policy: DROP_OLDEST})
+	fill(r, "w0", "w1", "w2")
+	if !r.Report(New("e3")) {
+		t.Errorf(`Report(e3) = false, want true`)
+	}
+	close(s.gate)
+	r.Close()
+	if fmt.Sprint(s.got) != "[w0 w2 e3]" {
+		t.Errorf(`delivered %v, want [w0 w2 e3]`, s.got)
+	}
+	if st := r.Stats(); st.Dropped != 1 || st.Reported != 3 || st.Queued != 0 {
+		t.Errorf(`Stats() = %+v`, st)
+	}
+	if r.Report(New("late")) {
+		t.Errorf(`Report() after Close() = true, want false`)
+	}
+}
+
+func TestReporterDropBelowLevel(t *testing.T) {
+	s := &gatedSink{gate: make(chan struct{})}
+	r := NewReporter(s, ReporterOptions{QueueSize: 2, Policy: DROP_BELOW_LEVEL, Level: LEVEL_ERROR})
+	fill(r, "w0", "w1", "w2")
+	if r.Report(New(Desc{Level: WARNING, Text: "w3"})) {
+		t.Errorf(`Report(w3) = true, want false`)
+	}
+	if !r.Report(New(Desc{Level: PANIC, Text: "p4", Code: 1})) {
+		t.Errorf(`Report(p4) = false, want true`)
+	}
+	close(s.gate)
+	r.Close()
+	if fmt.Sprint(s.got) != "[w0 w2 p4]" {
+		t.Errorf(`delivered %v, want [w0 w2 p4]`, s.got)
+	}
+	if st := r.Stats(); st.Dropped != 2 || st.Reported != 2 || st.Failed != 1 {
+		t.Errorf(`Stats() = %+v`, st)
+	}
+}
+
+func TestReporterBlockWithTimeout(t *testing.T) {
+	s := &gatedSink{gate: make(chan struct{})}
+	r := NewReporter(s, ReporterOptions{QueueSize: 1, Policy: BLOCK_WITH_TIMEOUT, Timeout: 10 * time.Millisecond})
+	fill(r, "w0", "w1")
+	if r.Report(New("e2")) {
+		t.Errorf(`Report(e2) = true, want false`)
+	}
+	fatal := make(chan bool)
+	go func() {
+		fatal <- r.Report(New(Desc{Level: FATAL, Text: "f3"}))
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(s.gate)
+	if !<-fatal {
+		t.Errorf(`Report(f3) = false, want true`)
+	}
+	r.Close()
+	if fmt.Sprint(s.got) != "[w0 w1 f3]" {
+		t.Errorf(`delivered %v, want [w0 w1 f3]`, s.got)
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import "sync"
+
+// Strategies resolving the effective code and level of an error chain, see
+// SetResolution.
+const (
+	// RESOLVE_OUTERMOST takes them from the outermost Error in the chain.
+	RESOLVE_OUTERMOST int8 = iota
+	// RESOLVE_INNERMOST takes them from the innermost one.
+	RESOLVE_INNERMOST
+	// RESOLVE_MAX_SEVERITY takes them from the most severe one, the
+	// outermost of those of the same level.
+	RESOLVE_MAX_SEVERITY
+)
+
+var resolution struct {
+	sync.RWMutex
+	strategy int8
+}
+
+// SetResolution sets the strategy used by EffectiveCode and EffectiveLevel,
+// from RESOLVE_OUTERMOST (the default) to RESOLVE_MAX_SEVERITY.
+func SetResolution(strategy int8) {
+	if strategy >= RESOLVE_OUTERMOST && strategy <= RESOLVE_MAX_SEVERITY {
+		resolution.Lock()
+		resolution.strategy = strategy
+		resolution.Unlock()
+	}
+}
+
+// EffectiveCode returns the code of the chain of err, i.e. err and the errors
+// it wraps (through an Unwrap method), according to the strategy set with
+// SetResolution; layers without a code are skipped. It returns zero if no
+// layer has a code.
+func EffectiveCode(err error) int {
+	if e := resolve(err, true); e != nil {
+		return e.Code()
+	}
+	return 0
+}
+
+// EffectiveLevel returns the level of the chain of err, like EffectiveCode.
+// It returns ERROR if the chain holds no Error, and zero if err is nil.
+func EffectiveLevel(err error) Level {
+	if err == nil {
+		return 0
+	}
+	if e := resolve(err, false); e != nil {
+		return Level(e.Level())
+	}
+	return LEVEL_ERROR
+}
+
+// resolve returns the layer of the chain of err selected by the resolution
+// strategy, among those having a code if coded.
+func resolve(err error, coded bool) Error {
+	resolution.RLock()
+	strategy := resolution.strategy
+	resolution.RUnlock()
+	var res Error
+	for err != nil {
+		if e, ok := err.(Error); ok && (!coded || e.Code() != 0) {
+			switch strategy {
+			case RESOLVE_OUTERMOST:
+				return e
+			case RESOLVE_INNERMOST:
+				res = e
+			case RESOLVE_MAX_SEVERITY:
+				if res == nil || e.Level() > res.Level() {
+					res = e
+				}
+			}
+		}
+		u, ok := err.(interface {
+			Unwrap() error
+		})
+		if !ok {
+			break
+		}
+		err = u.Unwrap()
+	}
+	return res
+}
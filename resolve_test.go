@@ -0,0 +1,59 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"fmt"
+	"testing"
+)
+
+type errorLayer interface {
+	Error
+}
+
+// wrapper is an Error wrapping another error.
+type wrapper struct {
+	errorLayer
+	cause error
+}
+
+func (w wrapper) Unwrap() error {
+	return w.cause
+}
+
+func TestResolution(t *testing.T) {
+	defer SetResolution(RESOLVE_OUTERMOST)
+	inner := New(Desc{Level: FATAL, Code: 1, Text: "disk failed"})
+	middle := fmt.Errorf("saving: %v", inner)
+	err := wrapper{New(Desc{Level: WARNING, Text: "request failed"}), wrapper{New(Desc{Code: 2, Text: "write failed"}), inner}}
+
+	for _, c := range []struct {
+		strategy int8
+		code     int
+		level    Level
+	}{
+		{RESOLVE_OUTERMOST, 2, LEVEL_WARNING},
+		{RESOLVE_INNERMOST, 1, LEVEL_FATAL},
+		{RESOLVE_MAX_SEVERITY, 1, LEVEL_FATAL},
+	} {
+		SetResolution(c.strategy)
+		if code, level := EffectiveCode(err), EffectiveLevel(err); code != c.code || level != c.level {
+			t.Errorf(`strategy %d: EffectiveCode(err), EffectiveLevel(err) = %d, %s, want %d, %s`, c.strategy, code, level, c.code, c.level)
+		}
+	}
+	if EffectiveCode(middle) != 0 || EffectiveLevel(middle) != LEVEL_ERROR || EffectiveLevel(nil) != 0 {
+		t.Errorf(`plain errors not resolved as expected`)
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.18
+// +build go1.18
+
+package errors
+
+// Result holds either a value of type T or an Error. It is meant for
+// pipeline-style code where passing (value, error) pairs through channels is
+// awkward.
+type Result[T any] struct {
+	val T
+	err Error
+}
+
+// Ok returns a successful Result holding v.
+func Ok[T any](v T) Result[T] {
+	return Result[T]{val: v}
+}
+
+// Err returns a failed Result holding e. A nil e yields a successful Result
+// holding the zero value of T.
+func Err[T any](e Error) Result[T] {
+	return Result[T]{err: e}
+}
+
+// IsOk reports whether the Result holds a value rather than an error.
+func (r Result[T]) IsOk() bool {
+	return r.err == nil
+}
+
+// Unwrap returns the value and the error held by the Result.
+func (r Result[T]) Unwrap() (T, Error) {
+	return r.val, r.err
+}
+
+// OrElse returns the value held by the Result, or v if it holds an error.
+func (r Result[T]) OrElse(v T) T {
+	if r.err != nil {
+		return v
+	}
+	return r.val
+}
+
+// Map applies f to the value held by r; an error is passed through unchanged.
+func Map[T, U any](r Result[T], f func(T) U) Result[U] {
+	if r.err != nil {
+		return Result[U]{err: r.err}
+	}
+	return Result[U]{val: f(r.val)}
+}
+
+// Values returns the values held by the successful results in rs, and
+// appends the errors held by the others to g.
+func Values[T any](g *Group, rs ...Result[T]) []T {
+	var vals []T
+	for _, r := range rs {
+		if v, err := r.Unwrap(); err != nil {
+			g.Append(err)
+		} else {
+			vals = append(vals, v)
+		}
+	}
+	return vals
+}
@@ -0,0 +1,51 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.18
+// +build go1.18
+
+package errors
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestResult(t *testing.T) {
+	r := Ok(17)
+	if !r.IsOk() {
+		t.Errorf(`Ok(17).IsOk() = false, want true`)
+	}
+	if v, err := r.Unwrap(); v != 17 || err != nil {
+		t.Errorf(`Ok(17).Unwrap() = %d, %v, want 17, <nil>`, v, err)
+	}
+	if v := Map(r, strconv.Itoa).OrElse("?"); v != "17" {
+		t.Errorf(`Map(Ok(17), strconv.Itoa).OrElse("?") = %q, want %q`, v, "17")
+	}
+
+	e := New("abc")
+	r = Err[int](e)
+	if r.IsOk() {
+		t.Errorf(`Err(e).IsOk() = true, want false`)
+	}
+	if v, err := r.Unwrap(); v != 0 || err != e {
+		t.Errorf(`Err(e).Unwrap() = %d, %v, want 0, %v`, v, err, e)
+	}
+	if v := r.OrElse(3); v != 3 {
+		t.Errorf(`Err(e).OrElse(3) = %d, want %d`, v, 3)
+	}
+	if _, err := Map(r, strconv.Itoa).Unwrap(); err != e {
+		t.Errorf(`Map(Err(e), strconv.Itoa) error = %v, want %v`, err, e)
+	}
+}
@@ -0,0 +1,210 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxRollupFingerprints bounds the number of fingerprints remembered by a
+// Rollup for telling new ones apart; past it, no fingerprint is reported as
+// new.
+const maxRollupFingerprints = 100000
+
+// RollupOptions configures a Rollup.
+type RollupOptions struct {
+	// Period is the duration summarized by each summary (default 24 hours).
+	Period time.Duration
+	// Top is the number of most frequent fingerprints listed (default 10).
+	Top int
+	// RegressionFactor is the growth against the previous period making a
+	// fingerprint a regression (default 2).
+	RegressionFactor float64
+	// Emit is called with the summary of each period as it ends.
+	Emit func(RollupSummary)
+}
+
+// RollupEntry describes the occurrences of a fingerprint during a period.
+type RollupEntry struct {
+	Fingerprint string
+	Code        int
+	Level       Level
+	Text        string // of the latest occurrence
+	Count       int
+	Previous    int // count during the previous period
+}
+
+// RollupSummary is the summary of a period produced by a Rollup.
+type RollupSummary struct {
+	Start, End time.Time
+	Total      int
+	// Top lists the most frequent fingerprints, most frequent first.
+	Top []RollupEntry
+	// New lists the fingerprints never seen before.
+	New []RollupEntry
+	// Regressions lists the fingerprints more frequent than during the
+	// previous period by at least RegressionFactor.
+	Regressions []RollupEntry
+}
+
+// Rollup summarizes the errors it receives over periods, for daily reports
+// and the like. It is a Sink, so that it can be fed by a Reporter, or
+// directly by calls to Report; periods are delimited by the package clock,
+// see SetClock.
+type Rollup struct {
+	mu       sync.Mutex
+	opts     RollupOptions
+	start    time.Time
+	current  map[string]*RollupEntry
+	previous map[string]int
+	seen     map[string]bool
+}
+
+// NewRollup returns a Rollup configured by opts, whose first period starts
+// now.
+func NewRollup(opts RollupOptions) *Rollup {
+	if opts.Period <= 0 {
+		opts.Period = 24 * time.Hour
+	}
+	if opts.Top <= 0 {
+		opts.Top = 10
+	}
+	if opts.RegressionFactor <= 0 {
+		opts.RegressionFactor = 2
+	}
+	return &Rollup{opts: opts, start: now(), current: make(map[string]*RollupEntry), seen: make(map[string]bool)}
+}
+
+// Report counts an occurrence of err, after emitting the summary of the
+// periods ended since the previous call, if any. It never fails.
+func (r *Rollup) Report(err Error) error {
+	t := now()
+	fp := Fingerprint(err)
+	r.mu.Lock()
+	var ended []RollupSummary
+	for !t.Before(r.start.Add(r.opts.Period)) {
+		ended = append(ended, r.rotate(r.start.Add(r.opts.Period)))
+	}
+	e := r.current[fp]
+	if e == nil {
+		e = &RollupEntry{Fingerprint: fp, Code: err.Code()}
+		r.current[fp] = e
+	}
+	e.Level, e.Text = Level(err.Level()), err.Text()
+	e.Count++
+	r.mu.Unlock()
+	if r.opts.Emit != nil {
+		for _, s := range ended {
+			r.opts.Emit(s)
+		}
+	}
+	return nil
+}
+
+// Flush ends the current period now, and returns its summary; it is not
+// passed to Emit.
+func (r *Rollup) Flush() RollupSummary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rotate(now())
+}
+
+// byCount sorts rollup entries by count, largest first, then by fingerprint.
+type byCount []RollupEntry
+
+func (es byCount) Len() int      { return len(es) }
+func (es byCount) Swap(i, j int) { es[i], es[j] = es[j], es[i] }
+func (es byCount) Less(i, j int) bool {
+	if es[i].Count != es[j].Count {
+		return es[i].Count > es[j].Count
+	}
+	return es[i].Fingerprint < es[j].Fingerprint
+}
+
+// rotate ends the current period at end, and returns its summary.
+func (r *Rollup) rotate(end time.Time) RollupSummary {
+	s := RollupSummary{Start: r.start, End: end}
+	entries := make([]RollupEntry, 0, len(r.current))
+	previous := make(map[string]int, len(r.current))
+	for fp, e := range r.current {
+		e.Previous = r.previous[fp]
+		entries = append(entries, *e)
+		previous[fp] = e.Count
+		s.Total += e.Count
+	}
+	sort.Sort(byCount(entries))
+	for i, e := range entries {
+		if i < r.opts.Top {
+			s.Top = append(s.Top, e)
+		}
+		if !r.seen[e.Fingerprint] && len(r.seen) < maxRollupFingerprints {
+			r.seen[e.Fingerprint] = true
+			s.New = append(s.New, e)
+		} else if e.Previous > 0 && float64(e.Count) >= float64(e.Previous)*r.opts.RegressionFactor {
+			s.Regressions = append(s.Regressions, e)
+		}
+	}
+	r.start, r.current, r.previous = end, make(map[string]*RollupEntry), previous
+	return s
+}
+
+// Markdown renders s as Markdown, for posting to chat or email.
+func (s RollupSummary) Markdown() string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "## Errors from %s to %s\n\n", s.Start.Format(time.RFC3339), s.End.Format(time.RFC3339))
+	fmt.Fprintf(&b, "%d errors, %d new kinds, %d regressions.\n", s.Total, len(s.New), len(s.Regressions))
+	for _, section := range []struct {
+		title   string
+		entries []RollupEntry
+	}{
+		{"Top errors", s.Top},
+		{"New errors", s.New},
+		{"Regressions", s.Regressions},
+	} {
+		if len(section.entries) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n### %s\n\n| Count | Previous | Level | Code | Error |\n|---:|---:|---|---|---|\n", section.title)
+		for _, e := range section.entries {
+			fmt.Fprintf(&b, "| %d | %d | %s | %s | %s |\n", e.Count, e.Previous, e.Level, rollupCode(e.Code), markdownCell(e.Text))
+		}
+	}
+	return b.String()
+}
+
+// rollupCode renders code for a summary: its name if registered, in
+// hexadecimal otherwise, and empty if zero.
+func rollupCode(code int) string {
+	if code == 0 {
+		return ""
+	}
+	if name := CodeName(code); name != "" {
+		return name
+	}
+	return hexCode(code)
+}
+
+// markdownCell escapes s for a cell of a Markdown table.
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", "\\|", "\n", " ", "\r", " ").Replace(s)
+}
@@ -0,0 +1,68 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRollup(t *testing.T) {
+	defer SetClock(nil)
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	SetClock(fixedClock(day))
+	RegisterCode(0x4404, "NOT_FOUND")
+	defer RegisterCode(0x4404, "")
+
+	var emitted []RollupSummary
+	r := NewRollup(RollupOptions{Top: 2, Emit: func(s RollupSummary) { emitted = append(emitted, s) }})
+	report := func(n int, desc Desc) {
+		for i := 0; i < n; i++ {
+			r.Report(New(desc))
+		}
+	}
+	report(2, Desc{Code: 0x4404, Text: "no such user"})
+	report(1, Desc{Code: 0x4405, Text: "no such order"})
+
+	SetClock(fixedClock(day.Add(25 * time.Hour)))
+	report(5, Desc{Code: 0x4404, Text: "no such user"})
+	report(1, Desc{Code: 0x4406, Level: WARNING, Text: "slow | late"})
+	if len(emitted) != 1 || emitted[0].Total != 3 || len(emitted[0].New) != 2 || !emitted[0].End.Equal(day.Add(24*time.Hour)) {
+		t.Fatalf(`first period summary = %+v`, emitted)
+	}
+
+	s := r.Flush()
+	if s.Total != 6 || len(s.Top) != 2 || s.Top[0].Code != 0x4404 || s.Top[0].Count != 5 || s.Top[0].Previous != 2 {
+		t.Errorf(`Flush() = %+v`, s)
+	}
+	if len(s.New) != 1 || s.New[0].Code != 0x4406 || len(s.Regressions) != 1 || s.Regressions[0].Code != 0x4404 {
+		t.Errorf(`Flush() new %+v, regressions %+v`, s.New, s.Regressions)
+	}
+	md := s.Markdown()
+	for _, want := range []string{
+		"## Errors from 2024-03-02T00:00:00Z to 2024-03-02T01:00:00Z\n",
+		"6 errors, 1 new kinds, 1 regressions.\n",
+		"| 5 | 2 | ERROR | NOT_FOUND | no such user |\n",
+		"| 1 | 0 | WARNING | 0x4406 | slow \\| late |\n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf(`Markdown() lacks %q:\n%s`, want, md)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"bytes"
+	"strconv"
+	"unicode"
+)
+
+// SanitizeOptions configures the sanitization of error texts, see Sanitize.
+type SanitizeOptions struct {
+	// MaxWidth is the maximum number of characters kept of each text; longer
+	// ones are truncated and end with an ellipsis. Zero means no limit.
+	MaxWidth int
+}
+
+// Sanitize returns a copy of err whose text, info, string fields (including
+// the strings held by maps and slices) and cause tree have newlines and other
+// control characters escaped, and are shortened according to opts, so that
+// attacker-controlled strings rendered in logs cannot forge log lines or
+// break line-oriented formats such as NDJSON.
+func Sanitize(err Error, opts SanitizeOptions) Error {
+	if err == nil {
+		return nil
+	}
+	em := messageOf(err)
+	if em == nil {
+		return &errorMessage{level: err.Level(), code: err.Code(), text: opts.clean(err.Text())}
+	}
+	c := em.clone()
+	c.text = opts.clean(em.text)
+	c.public = opts.clean(em.public)
+	for k, v := range em.fields {
+		delete(c.fields, k)
+		if !isInfoKey(k) {
+			k = opts.clean(k)
+		}
+		c.fields[k] = opts.cleanValue(v)
+	}
+	if em.info != nil {
+		c.info = make([]string, len(em.info))
+		for i, s := range em.info {
+			c.info[i] = opts.clean(s)
+		}
+	}
+	c.branches = make([]branch, len(em.branches))
+	for i, b := range em.branches {
+		c.branches[i] = branch{opts.clean(b.name), Sanitize(b.err, opts)}
+	}
+	if _, ok := err.(netWrapper); ok {
+		return netWrapper{c}
+	}
+	return c
+}
+
+// cleanValue returns v, a field value, cleaned if it is a string or holds
+// strings, as do the maps and slices of the field types of the package, such
+// as the request headers recorded by Capture.
+func (opts SanitizeOptions) cleanValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		return opts.clean(v)
+	case []string:
+		c := make([]string, len(v))
+		for i, s := range v {
+			c[i] = opts.clean(s)
+		}
+		return c
+	case map[string]string:
+		c := make(map[string]string, len(v))
+		for k, s := range v {
+			c[opts.clean(k)] = opts.clean(s)
+		}
+		return c
+	case map[string][]string:
+		c := make(map[string][]string, len(v))
+		for k, s := range v {
+			c[opts.clean(k)], _ = opts.cleanValue(s).([]string)
+		}
+		return c
+	case []interface{}:
+		c := make([]interface{}, len(v))
+		for i, e := range v {
+			c[i] = opts.cleanValue(e)
+		}
+		return c
+	case map[string]interface{}:
+		c := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			c[opts.clean(k)] = opts.cleanValue(e)
+		}
+		return c
+	}
+	return v
+}
+
+// clean returns s with control characters escaped, truncated to MaxWidth.
+func (opts SanitizeOptions) clean(s string) string {
+	var b bytes.Buffer
+	n := 0
+	for _, r := range s {
+		if opts.MaxWidth > 0 && n == opts.MaxWidth {
+			b.WriteString("…")
+			break
+		}
+		switch {
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case unicode.IsControl(r) || r == '\u2028' || r == '\u2029':
+			if r < 0x100 {
+				b.WriteString(`\x` + zeroPad(strconv.FormatInt(int64(r), 16), 2))
+			} else {
+				b.WriteString(`\u` + zeroPad(strconv.FormatInt(int64(r), 16), 4))
+			}
+		default:
+			b.WriteRune(r)
+		}
+		n++
+	}
+	return b.String()
+}
+
+// sanitizingLogger is a Logger to which Log sends sanitized errors.
+type sanitizingLogger struct {
+	Logger
+	opts SanitizeOptions
+}
+
+// SanitizeLogger returns a Logger sending to log, through which the Log
+// method of Errors logs them sanitized with opts, see Sanitize.
+func SanitizeLogger(log Logger, opts SanitizeOptions) Logger {
+	return &sanitizingLogger{log, opts}
+}
+
+// SanitizeSink returns a Sink reporting errors to sink sanitized with opts,
+// see Sanitize.
+func SanitizeSink(sink Sink, opts SanitizeOptions) Sink {
+	return SinkFunc(func(err Error) error {
+		return sink.Report(Sanitize(err, opts))
+	})
+}
+
+// redact replaces the values of the fields of em with the given keys by
+// "[REDACTED]".
+func redact(em *errorMessage, keys []string) {
+	for _, k := range keys {
+		if _, ok := em.fields[k]; ok {
+			em.fields[k] = "[REDACTED]"
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import "testing"
+
+func TestSanitize(t *testing.T) {
+	err := New(Desc{
+		Text:   "user \"bob\nERROR forged line\x1b[31m\"",
+		Info:   []string{"a\r\nb"},
+		Fields: map[string]interface{}{"name": "x\u2028y", "n": 1},
+	})
+	s := Sanitize(err, SanitizeOptions{})
+	if want := `user "bob\nERROR forged line\x1b[31m"`; s.Text() != want {
+		t.Errorf(`Sanitize(err).Text() = %q, want %q`, s.Text(), want)
+	}
+	if s.Info()[0] != `a\r\nb` || err.Info()[0] != "a\r\nb" {
+		t.Errorf(`Sanitize(err).Info() = %q, original %q`, s.Info(), err.Info())
+	}
+	if v, _ := Field(s, "name"); v != `x\u2028y` {
+		t.Errorf(`Sanitize(err) field name = %q`, v)
+	}
+	headers := map[string]string{"X-Forged": "a\nERROR b"}
+	e := WithField(New("abc"), "http.headers", headers)
+	done := make(chan struct{})
+	go func() {
+		Handled(e)
+		close(done)
+	}()
+	v, _ := Field(Sanitize(e, SanitizeOptions{}), "http.headers")
+	<-done
+	if h, _ := v.(map[string]string); h["X-Forged"] != `a\nERROR b` || headers["X-Forged"] != "a\nERROR b" {
+		t.Errorf(`Sanitize(err) field http.headers = %q, original %q`, v, headers)
+	}
+	if got := Sanitize(New("héllo world"), SanitizeOptions{MaxWidth: 5}).Text(); got != "héllo…" {
+		t.Errorf(`Sanitize(MaxWidth: 5).Text() = %q, want "héllo…"`, got)
+	}
+
+	log := &mockLogger{}
+	err.Log(SanitizeLogger(log, SanitizeOptions{MaxWidth: 8}))
+	if log.log != `user "bo…`+"\n" {
+		t.Errorf(`Log(SanitizeLogger(log)) logged %q`, log.log)
+	}
+
+	var got Error
+	SanitizeSink(SinkFunc(func(e Error) error { got = e; return nil }), SanitizeOptions{}).Report(err)
+	if got == nil || got.Text() != s.Text() {
+		t.Errorf(`SanitizeSink(sink).Report(err) reported %v`, got)
+	}
+}
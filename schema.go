@@ -0,0 +1,156 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Types of field values, see FieldSchema.
+const (
+	// FIELD_ANY accepts any value.
+	FIELD_ANY int8 = iota
+	// FIELD_STRING accepts strings.
+	FIELD_STRING
+	// FIELD_INT accepts signed and unsigned integers.
+	FIELD_INT
+	// FIELD_FLOAT accepts floating-point numbers.
+	FIELD_FLOAT
+	// FIELD_BOOL accepts booleans.
+	FIELD_BOOL
+	// FIELD_TIME accepts time.Time values.
+	FIELD_TIME
+)
+
+// FieldSchema describes a field, for its use to be consistent across an
+// organization, see RegisterFieldSchema.
+type FieldSchema struct {
+	// Name is the key of the field, e.g. "user_id".
+	Name string
+	// Type is the type of its values, from FIELD_ANY to FIELD_TIME.
+	Type int8
+	// RequiredFor lists the codes of the errors which must have the field.
+	RequiredFor []int
+	// Aliases lists the keys which must not be used instead of Name, e.g.
+	// "userId" and "uid".
+	Aliases []string
+}
+
+var schemas struct {
+	sync.RWMutex
+	byName  map[string]*FieldSchema
+	aliases map[string]string
+}
+
+// RegisterFieldSchema registers s, replacing any schema with the same name.
+// The fields of errors are checked against the registered schemas when the
+// errors are created and when they are externalized (by PublicText, the wire
+// format and the HTTP shapes): observers are notified with
+// EVENT_FIELD_SCHEMA about every field of the wrong type, set under an alias,
+// or missing though required by the code of the error. The error passed
+// describes the violation, with the code ERR_INVALID.
+func RegisterFieldSchema(s FieldSchema) {
+	schemas.Lock()
+	if schemas.byName == nil {
+		schemas.byName = make(map[string]*FieldSchema)
+		schemas.aliases = make(map[string]string)
+	}
+	if old := schemas.byName[s.Name]; old != nil {
+		for _, a := range old.Aliases {
+			delete(schemas.aliases, a)
+		}
+	}
+	schemas.byName[s.Name] = &s
+	for _, a := range s.Aliases {
+		schemas.aliases[a] = s.Name
+	}
+	schemas.Unlock()
+}
+
+// fieldTypeName returns the name of the field type t.
+func fieldTypeName(t int8) string {
+	switch t {
+	case FIELD_STRING:
+		return "string"
+	case FIELD_INT:
+		return "integer"
+	case FIELD_FLOAT:
+		return "float"
+	case FIELD_BOOL:
+		return "bool"
+	case FIELD_TIME:
+		return "time"
+	}
+	return "any"
+}
+
+// fieldTypeOK reports whether v is of the field type t.
+func fieldTypeOK(t int8, v interface{}) bool {
+	switch v.(type) {
+	case string:
+		return t == FIELD_ANY || t == FIELD_STRING
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return t == FIELD_ANY || t == FIELD_INT
+	case float32, float64:
+		return t == FIELD_ANY || t == FIELD_FLOAT
+	case bool:
+		return t == FIELD_ANY || t == FIELD_BOOL
+	case time.Time:
+		return t == FIELD_ANY || t == FIELD_TIME
+	}
+	return t == FIELD_ANY
+}
+
+// checkFields checks the fields of em against the registered schemas, when
+// em is created or externalized, as stated by op.
+func checkFields(em *errorMessage, op string) {
+	for _, s := range fieldViolations(em) {
+		// built directly, not to be checked in turn
+		v := newMessage(op + " error " + strconv.Quote(em.Error()) + ": " + s)
+		v.code = ERR_INVALID
+		notify(EVENT_FIELD_SCHEMA, v)
+	}
+}
+
+// fieldViolations returns the descriptions of the violations of the
+// registered schemas by the fields of em.
+func fieldViolations(em *errorMessage) (violations []string) {
+	schemas.RLock()
+	for k, v := range em.fields {
+		if isInfoKey(k) {
+			continue
+		}
+		if s := schemas.byName[k]; s != nil && !fieldTypeOK(s.Type, v) {
+			violations = append(violations, "field "+strconv.Quote(k)+" is "+typeName(v)+", not "+fieldTypeName(s.Type))
+		} else if name, ok := schemas.aliases[k]; ok {
+			violations = append(violations, "field "+strconv.Quote(k)+" should be "+strconv.Quote(name))
+		}
+	}
+	for _, s := range schemas.byName {
+		if _, ok := em.fields[s.Name]; ok {
+			continue
+		}
+		for _, code := range s.RequiredFor {
+			if code == em.code {
+				violations = append(violations, "field "+strconv.Quote(s.Name)+" is missing")
+				break
+			}
+		}
+	}
+	schemas.RUnlock()
+	return
+}
@@ -0,0 +1,60 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestFieldSchemas(t *testing.T) {
+	var violations []string
+	remove := AddObserver(func(event int8, err Error) {
+		if event == EVENT_FIELD_SCHEMA && err.Code() == ERR_INVALID {
+			violations = append(violations, err.Text())
+		}
+	})
+	defer func() {
+		remove()
+		schemas.byName, schemas.aliases = nil, nil
+	}()
+	RegisterFieldSchema(FieldSchema{Name: "user_id", Type: FIELD_INT, RequiredFor: []int{0x2300}, Aliases: []string{"userId", "uid"}})
+	RegisterFieldSchema(FieldSchema{Name: "region", Type: FIELD_STRING})
+
+	New(Desc{Text: "ok", Fields: map[string]interface{}{"user_id": 7, "region": "eu", "other": 1}})
+	if len(violations) != 0 {
+		t.Errorf(`valid fields reported: %q`, violations)
+	}
+	e := New(Desc{Code: 0x2300, Text: "bad", Fields: map[string]interface{}{"uid": 7, "region": 1}})
+	sort.Strings(violations)
+	want := []string{
+		`created error "bad (code: 0x2300)": field "region" is int, not string`,
+		`created error "bad (code: 0x2300)": field "uid" should be "user_id"`,
+		`created error "bad (code: 0x2300)": field "user_id" is missing`,
+	}
+	if len(violations) != len(want) {
+		t.Fatalf(`violations = %q`, violations)
+	}
+	for i, v := range want {
+		if violations[i] != v {
+			t.Errorf(`violation %d = %q, want %q`, i, violations[i], v)
+		}
+	}
+	violations = nil
+	PublicText(e)
+	if len(violations) != 3 || violations[0][:12] != "externalized" {
+		t.Errorf(`externalization violations = %q`, violations)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+// Sealed holds an Error, and is meant to be embedded in the error types of
+// packages whose errors must all be built by a closed set of constructors,
+// such as the ones generated by errgen: their constructors return an
+// interface with an unexported method, implemented by a type embedding
+// Sealed. The functions of this package see through it.
+type Sealed struct {
+	e Error
+}
+
+// Seal returns err for embedding in a sealed error type.
+func Seal(err Error) Sealed {
+	return Sealed{err}
+}
+
+// sealed returns the held Error; it lets messageOf reach it through the types
+// embedding s.
+func (s Sealed) sealed() Error {
+	return s.e
+}
+
+// Error returns the string representation of the held error.
+func (s Sealed) Error() string {
+	return s.e.Error()
+}
+
+// Level returns the error level.
+func (s Sealed) Level() int8 {
+	return s.e.Level()
+}
+
+// SetLevel sets the error level.
+func (s Sealed) SetLevel(l int8) Error {
+	s.e.SetLevel(l)
+	return s
+}
+
+// Code returns the error code.
+func (s Sealed) Code() int {
+	return s.e.Code()
+}
+
+// SetCode sets the error code.
+func (s Sealed) SetCode(c int) Error {
+	s.e.SetCode(c)
+	return s
+}
+
+// Text returns the error text.
+func (s Sealed) Text() string {
+	return s.e.Text()
+}
+
+// SetText sets the error text.
+func (s Sealed) SetText(t string) Error {
+	s.e.SetText(t)
+	return s
+}
+
+// Info returns the error info.
+func (s Sealed) Info() []string {
+	return s.e.Info()
+}
+
+// AddInfo adds (more) error info.
+func (s Sealed) AddInfo(info ...string) Error {
+	s.e.AddInfo(info...)
+	return s
+}
+
+// Log sends the held error to the provided log.
+func (s Sealed) Log(log Logger) Error {
+	s.e.Log(log)
+	return s
+}
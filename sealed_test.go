@@ -0,0 +1,37 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import "testing"
+
+type catalogued struct {
+	Sealed
+}
+
+func (catalogued) catalogError() {}
+
+func TestSealed(t *testing.T) {
+	var err Error = catalogued{Seal(New(Desc{Code: 0x17, Text: "abc", Fields: map[string]interface{}{"id": 1}}))}
+	if err.Error() != "abc (code: 0x0017)" || err.Code() != 0x17 {
+		t.Errorf(`sealed err = %q`, err)
+	}
+	if v, ok := Field(err, "id"); !ok || v != 1 {
+		t.Errorf(`Field(sealed err, "id") = %v, %t`, v, ok)
+	}
+	err.SetLevel(WARNING)
+	if err.Level() != WARNING {
+		t.Errorf(`sealed err.Level() = %d, want %d`, err.Level(), WARNING)
+	}
+}
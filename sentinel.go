@@ -0,0 +1,84 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Sentinel is an error template defined once, typically as a package
+// variable, and instantiated wherever the error occurs. It keeps track of
+// its occurrences, see Stats.
+type Sentinel struct {
+	desc  Desc
+	text  textTemplate // parsed desc.Text, or nil if it has no actions
+	mu    sync.Mutex
+	stats OccurrenceStats
+	wire  atomic.Value // cached serialized form, see marshalWire
+}
+
+// OccurrenceStats describes the occurrences of a Sentinel.
+type OccurrenceStats struct {
+	Count     uint64
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+// Define returns a Sentinel for errors described by desc. desc.Text may
+// contain {{.Field}} references, resolved against desc.Fields when errors are
+// created; the template is parsed once, here. If it cannot be rendered, the
+// text is kept as is and the template error is added to the Info of the
+// errors.
+func Define(desc Desc) *Sentinel {
+	desc.Info = append([]string(nil), desc.Info...)
+	return &Sentinel{desc: desc, text: parseText(desc.Text)}
+}
+
+// New returns a new Error described by the template, and counts it as an
+// occurrence, at the time given by the package clock.
+func (s *Sentinel) New() Error {
+	d := s.desc
+	d.Info = append([]string(nil), d.Info...)
+	if s.text != nil && len(d.Fields) > 0 {
+		if text, err := s.text(d.Fields); err != nil {
+			d.Info = append(d.Info, err.Error())
+		} else {
+			d.Text = text
+		}
+	}
+	e := Default().finish(newError(&d, 4))
+	if em, ok := e.(*errorMessage); ok {
+		em.sentinel = s
+	}
+	t := Time(e)
+	s.mu.Lock()
+	if s.stats.Count == 0 {
+		s.stats.FirstSeen = t
+	}
+	s.stats.Count++
+	s.stats.LastSeen = t
+	s.mu.Unlock()
+	return e
+}
+
+// Stats returns the number of errors created from s, and the times the first
+// and the last of them were created at.
+func Stats(s *Sentinel) OccurrenceStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
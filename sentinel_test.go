@@ -0,0 +1,44 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSentinel(t *testing.T) {
+	defer SetClock(nil)
+	first := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	last := first.Add(time.Hour)
+	SetClock(fixedClock(first))
+
+	desc := Desc{Code: ERR_NOT_FOUND, Text: "no such user", Info: []string{"x"}}
+	s := Define(desc)
+	desc.Info[0] = "changed"
+	if st := Stats(s); st.Count != 0 || !st.FirstSeen.IsZero() {
+		t.Errorf(`Stats(s) = %+v before any occurrence`, st)
+	}
+	e := s.New()
+	e.AddInfo("y")
+	SetClock(fixedClock(last))
+	e = s.New()
+	if e.Code() != ERR_NOT_FOUND || len(e.Info()) != 1 || e.Info()[0] != "x" {
+		t.Errorf(`s.New() = %q, info %q`, e, e.Info())
+	}
+	if st := Stats(s); st.Count != 2 || !st.FirstSeen.Equal(first) || !st.LastSeen.Equal(last) {
+		t.Errorf(`Stats(s) = %+v`, st)
+	}
+}
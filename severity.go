@@ -0,0 +1,57 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import "sync"
+
+// SeverityFn computes the effective level of err, e.g. to downgrade every
+// error raised in a canary environment. Returning an invalid level, such as
+// zero, leaves the level of err unchanged.
+type SeverityFn func(err Error) Level
+
+var severity struct {
+	sync.RWMutex
+	fn SeverityFn
+}
+
+// SetSeverityFn sets the hook consulted for the effective level of errors
+// when they are created by the constructors of the package, and after they are
+// classified by Classify; nil removes it. When the hook changes the level of
+// an error, the level it had before is recorded, as by ReclassifyByAge.
+func SetSeverityFn(fn SeverityFn) {
+	severity.Lock()
+	severity.fn = fn
+	severity.Unlock()
+}
+
+// applySeverity sets the level of e to the one computed by the severity hook,
+// if any, and returns e.
+func applySeverity(e Error) Error {
+	severity.RLock()
+	fn := severity.fn
+	severity.RUnlock()
+	if o := currentOverrides(); o != nil && o.Severity != nil {
+		fn = o.Severity
+	}
+	em := ownMessage(e)
+	if fn == nil || em == nil || em.informational() {
+		return e
+	}
+	if l := fn(e); l.Valid() && int8(l) != em.level {
+		em.setField(fieldReclassifiedFrom, levelName(em.level))
+		em.level = int8(l)
+	}
+	return e
+}
@@ -0,0 +1,48 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSeverityFn(t *testing.T) {
+	defer SetSeverityFn(nil)
+	SetSeverityFn(func(e Error) Level {
+		if e.Code() == ERR_TIMEOUT {
+			return LEVEL_WARNING
+		}
+		return 0
+	})
+	err := New(Desc{Code: ERR_TIMEOUT, Text: "abc"})
+	if err.Level() != WARNING {
+		t.Errorf(`New(ERR_TIMEOUT).Level() = %d, want %d`, err.Level(), WARNING)
+	}
+	if em := err.(*errorMessage); em.field(fieldReclassifiedFrom) != "ERROR" {
+		t.Errorf(`reclassified.from = %v, want "ERROR"`, em.field(fieldReclassifiedFrom))
+	}
+	if err = New(Desc{Code: ERR_INVALID, Text: "abc"}); err.Level() != ERROR || err.(*errorMessage).fields != nil {
+		t.Errorf(`New(ERR_INVALID) was changed by the hook`)
+	}
+
+	defer func() { classifiers.live = nil }()
+	AddClassifier(func(err error) (int, Level, bool) {
+		return ERR_TIMEOUT, LEVEL_FATAL, true
+	})
+	if err = Classify(errors.New("abc")); err.Level() != WARNING {
+		t.Errorf(`Classify(err).Level() = %d, want %d`, err.Level(), WARNING)
+	}
+}
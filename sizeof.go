@@ -0,0 +1,170 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// Approximate overheads used by SizeOf, for a 64-bit runtime.
+const (
+	sizeMessage   = int(unsafe.Sizeof(errorMessage{}))
+	sizeMapEntry  = 48 // bucket slot of a string key and an interface value
+	sizeInterface = 16
+	sizeWord      = 8
+	maxSizeDepth  = 32 // guards against cyclic causes and values
+)
+
+// SizeOf returns a best-effort estimate of the bytes retained by err: its
+// texts, info, fields (including the stack traces kept as info), branches and
+// causes. Memory shared with other values, such as interned strings or
+// Sentinel descriptors, is counted as well, so the estimate errs on the high
+// side. Errors not created by this package count for the length of their
+// text.
+func SizeOf(err error) int {
+	return sizeOf(err, 0)
+}
+
+func sizeOf(err error, depth int) int {
+	if err == nil || depth > maxSizeDepth {
+		return 0
+	}
+	em := messageOf(err)
+	if em == nil {
+		return sizeInterface + len(err.Error())
+	}
+	n := sizeMessage + len(em.text) + len(em.public) + len(em.fullText) +
+		len(em.wrapSite) + len(em.domain)
+	for k, v := range em.fields {
+		n += sizeMapEntry + len(k) + sizeOfValue(v, depth+1)
+	}
+	n += sizeOfValue(em.info, depth+1)
+	for _, b := range em.branches {
+		n += len(b.name) + sizeInterface + sizeOf(b.err, depth+1)
+	}
+	return n + sizeOf(em.cause, depth+1)
+}
+
+// sizeOfValue estimates the bytes retained by a field value, beyond the
+// interface holding it.
+func sizeOfValue(v interface{}, depth int) int {
+	if depth > maxSizeDepth {
+		return 0
+	}
+	switch v := v.(type) {
+	case nil, bool, int8, uint8, int16, uint16, int32, uint32, float32:
+		return 0
+	case int, int64, uint, uint64, uintptr, float64, time.Duration:
+		return sizeWord
+	case string:
+		return len(v)
+	case []byte:
+		return cap(v)
+	case []string:
+		n := cap(v) * 2 * sizeWord
+		for _, s := range v {
+			n += len(s)
+		}
+		return n
+	case []interface{}:
+		n := cap(v) * sizeInterface
+		for _, e := range v {
+			n += sizeOfValue(e, depth+1)
+		}
+		return n
+	case map[string]interface{}:
+		n := 0
+		for k, e := range v {
+			n += sizeMapEntry + len(k) + sizeOfValue(e, depth+1)
+		}
+		return n
+	case map[string]string:
+		n := 0
+		for k, e := range v {
+			n += sizeMapEntry + len(k) + len(e)
+		}
+		return n
+	case time.Time:
+		return int(unsafe.Sizeof(v))
+	case error:
+		return sizeOf(v, depth)
+	}
+	return sizeInterface
+}
+
+var memory struct {
+	sync.Mutex
+	budget int64
+	live   int64
+	over   bool
+}
+
+// SetMemoryBudget enables the accounting of the memory retained by live
+// errors, as estimated by SizeOf when they are created; zero, the default,
+// disables it. When the total goes over budget, observers are sent
+// EVENT_MEMORY_BUDGET with the error that crossed it, once until the total
+// falls back under budget. Accounting relies on finalizers, so memory is
+// released at the pace of the garbage collector, and the estimate misses
+// annotations added after creation.
+func SetMemoryBudget(budget int64) {
+	if budget < 0 {
+		return
+	}
+	memory.Lock()
+	memory.budget = budget
+	memory.over = false
+	memory.Unlock()
+}
+
+// MemoryInUse returns the estimated bytes retained by live errors, and the
+// budget set by SetMemoryBudget.
+func MemoryInUse() (live, budget int64) {
+	memory.Lock()
+	defer memory.Unlock()
+	return memory.live, memory.budget
+}
+
+// accountMemory adds the size of em to the live total, and returns it; it
+// returns zero if accounting is disabled.
+func accountMemory(em *errorMessage) int64 {
+	memory.Lock()
+	if memory.budget == 0 {
+		memory.Unlock()
+		return 0
+	}
+	size := int64(sizeOf(em, 0))
+	memory.live += size
+	crossed := !memory.over && memory.live > memory.budget
+	if crossed {
+		memory.over = true
+	}
+	memory.Unlock()
+	if crossed {
+		notify(EVENT_MEMORY_BUDGET, em)
+	}
+	return size
+}
+
+// releaseMemory subtracts size from the live total.
+func releaseMemory(size int64) {
+	memory.Lock()
+	memory.live -= size
+	if memory.live <= memory.budget {
+		memory.over = false
+	}
+	memory.Unlock()
+}
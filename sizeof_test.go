@@ -0,0 +1,70 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSizeOf(t *testing.T) {
+	if n := SizeOf(nil); n != 0 {
+		t.Errorf(`SizeOf(nil) = %d, want 0`, n)
+	}
+	base := SizeOf(New("x"))
+	big := New("x")
+	WithField(big, "payload", strings.Repeat("a", 10000))
+	if n := SizeOf(big); n < base+10000 {
+		t.Errorf(`SizeOf with a 10000-byte field = %d, want at least %d`, n, base+10000)
+	}
+	if n := SizeOf(Wrap(big, "context")); n <= SizeOf(big) {
+		t.Errorf(`SizeOf(Wrap(big)) = %d, want more than %d`, n, SizeOf(big))
+	}
+}
+
+func TestMemoryBudget(t *testing.T) {
+	crossed := make(chan string, 10)
+	remove := AddObserver(func(event int8, err Error) {
+		if event == EVENT_MEMORY_BUDGET {
+			crossed <- err.Text()
+		}
+	})
+	defer remove()
+	defer SetMemoryBudget(0)
+	SetMemoryBudget(1 << 20)
+
+	func() {
+		New("small")
+		New(Desc{Text: "large", Info: []string{strings.Repeat("a", 2<<20)}})
+	}()
+	if len(crossed) != 1 || <-crossed != "large" {
+		t.Errorf(`EVENT_MEMORY_BUDGET not sent for "large"`)
+	}
+	deadline := time.After(5 * time.Second)
+	for {
+		runtime.GC()
+		if live, _ := MemoryInUse(); live == 0 {
+			break
+		}
+		select {
+		case <-time.After(10 * time.Millisecond):
+		case <-deadline:
+			live, _ := MemoryInUse()
+			t.Fatalf(`MemoryInUse() = %d after collection, want 0`, live)
+		}
+	}
+}
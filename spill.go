@@ -0,0 +1,250 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"os"
+	"sync"
+)
+
+// Layout of a FileSpill: a header made of a magic number, the offset of the
+// oldest record and its checksum, followed by records made of the length of
+// their payload, its checksum, and the payload: a serialized error.
+const (
+	spillMagic      = "ESP1"
+	spillHeaderSize = 16
+	spillRecordHead = 8
+	spillMaxRecord  = 16 << 20 // maximum size of a payload
+)
+
+// FileSpill is a Spill storing errors in a file, for them to survive network
+// partitions and restarts. Records are checksummed: when the file is opened,
+// a record partially written by a crash, and any following it, are
+// discarded.
+type FileSpill struct {
+	mu   sync.Mutex
+	path string
+	f    *os.File
+	max  int64
+	head int64 // offset of the oldest record
+	size int64 // end of the last record
+}
+
+// OpenFileSpill opens the FileSpill stored at path, creating it if needed.
+// Errors are not stored once the file would exceed maxBytes (unlimited if
+// zero or negative), except FATAL ones, which are never turned away.
+func OpenFileSpill(path string, maxBytes int64) (*FileSpill, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	s := &FileSpill{path: path, f: f, max: maxBytes, head: spillHeaderSize, size: spillHeaderSize}
+	if err = s.load(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// load reads the header of the file, and finds the end of its last intact
+// record.
+func (s *FileSpill) load() error {
+	var h [spillHeaderSize]byte
+	if n, _ := s.f.ReadAt(h[:], 0); n == spillHeaderSize && string(h[:4]) == spillMagic &&
+		crc32.ChecksumIEEE(h[4:12]) == binary.BigEndian.Uint32(h[12:]) {
+		s.head = int64(binary.BigEndian.Uint64(h[4:12]))
+	}
+	fi, err := s.f.Stat()
+	if err != nil {
+		return err
+	}
+	// an unreadable header only causes errors to be delivered again
+	for off := int64(spillHeaderSize); ; {
+		_, n, err := s.read(off, fi.Size())
+		if err != nil {
+			break
+		}
+		off += n
+		s.size = off
+	}
+	if s.head > s.size {
+		s.head = s.size
+	}
+	if err := s.f.Truncate(s.size); err != nil {
+		return err
+	}
+	return s.writeHeader()
+}
+
+// read returns the payload of the record at off, and the size of the record,
+// which must end by end. A record with a length beyond end or larger than
+// spillMaxRecord is corrupt, and not read.
+func (s *FileSpill) read(off, end int64) ([]byte, int64, error) {
+	var h [spillRecordHead]byte
+	if _, err := s.f.ReadAt(h[:], off); err != nil {
+		return nil, 0, err
+	}
+	n := int64(binary.BigEndian.Uint32(h[:4]))
+	if n > spillMaxRecord || off+spillRecordHead+n > end {
+		return nil, 0, io.ErrUnexpectedEOF
+	}
+	payload := make([]byte, n)
+	if _, err := s.f.ReadAt(payload, off+spillRecordHead); err != nil {
+		return nil, 0, err
+	}
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(h[4:]) {
+		return nil, 0, io.ErrUnexpectedEOF
+	}
+	return payload, spillRecordHead + int64(len(payload)), nil
+}
+
+// writeHeader records the offset of the oldest record.
+func (s *FileSpill) writeHeader() error {
+	var h [spillHeaderSize]byte
+	copy(h[:], spillMagic)
+	binary.BigEndian.PutUint64(h[4:12], uint64(s.head))
+	binary.BigEndian.PutUint32(h[12:], crc32.ChecksumIEEE(h[4:12]))
+	_, err := s.f.WriteAt(h[:], 0)
+	return err
+}
+
+// Push stores err, and syncs the file.
+func (s *FileSpill) Push(err Error) error {
+	payload, e := encodeWire(err)
+	if e != nil {
+		return e
+	}
+	if len(payload) > spillMaxRecord {
+		return New(Desc{Code: ERR_RESOURCE, Text: "error too large for spill " + s.path})
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := spillRecordHead + int64(len(payload))
+	if s.max > 0 && s.size-s.head+spillHeaderSize+n > s.max && err.Level() != FATAL {
+		return New(Desc{Code: ERR_RESOURCE, Text: "spill " + s.path + " is full"})
+	}
+	rec := make([]byte, n)
+	binary.BigEndian.PutUint32(rec[:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(rec[4:8], crc32.ChecksumIEEE(payload))
+	copy(rec[spillRecordHead:], payload)
+	if _, e = s.f.WriteAt(rec, s.size); e != nil {
+		return e
+	}
+	if e = s.f.Sync(); e != nil {
+		return e
+	}
+	s.size += n
+	return nil
+}
+
+// Peek returns the oldest error stored, or nil if there is none.
+func (s *FileSpill) Peek() (Error, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.head == s.size {
+		return nil, nil
+	}
+	payload, _, err := s.read(s.head, s.size)
+	if err != nil {
+		return nil, err
+	}
+	w, err := decodeWire(payload)
+	if err != nil {
+		return nil, err
+	}
+	return fromWire(w), nil
+}
+
+// Pop removes the oldest error stored. The file is emptied when no error
+// remains, and compacted when its removed records take more than half of it.
+func (s *FileSpill) Pop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.head == s.size {
+		return nil
+	}
+	var h [spillRecordHead]byte
+	if _, err := s.f.ReadAt(h[:], s.head); err != nil {
+		return err
+	}
+	s.head += spillRecordHead + int64(binary.BigEndian.Uint32(h[:4]))
+	switch {
+	case s.head >= s.size:
+		s.head, s.size = spillHeaderSize, spillHeaderSize
+		if err := s.f.Truncate(s.size); err != nil {
+			return err
+		}
+	case s.head > s.size/2:
+		return s.compact()
+	}
+	return s.writeHeader()
+}
+
+// compact rewrites the file without its removed records, replacing it
+// atomically.
+func (s *FileSpill) compact() error {
+	data := make([]byte, s.size-s.head)
+	if _, err := s.f.ReadAt(data, s.head); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path+".tmp", os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	old, head, size := s.f, s.head, s.size
+	s.f, s.head, s.size = f, spillHeaderSize, spillHeaderSize+int64(len(data))
+	if err = s.writeHeader(); err == nil {
+		if _, err = f.WriteAt(data, spillHeaderSize); err == nil {
+			if err = f.Sync(); err == nil {
+				err = os.Rename(s.path+".tmp", s.path)
+			}
+		}
+	}
+	if err != nil {
+		f.Close()
+		s.f, s.head, s.size = old, head, size
+		return err
+	}
+	return old.Close()
+}
+
+// Len returns the number of errors stored.
+func (s *FileSpill) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for off := s.head; off < s.size; n++ {
+		_, size, err := s.read(off, s.size)
+		if err != nil {
+			break
+		}
+		off += size
+	}
+	return n
+}
+
+// Close closes the file; the errors stored remain for the next
+// OpenFileSpill.
+func (s *FileSpill) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.f.Close()
+}
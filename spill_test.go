@@ -0,0 +1,142 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFileSpill(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spill")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer SetClock(nil)
+	SetClock(fixedClock(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)))
+	path := filepath.Join(dir, "errors.spill")
+	s, err := OpenFileSpill(path, 300)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, text := range []string{"first", "second", "third"} {
+		if err := s.Push(New(text)); err != nil {
+			t.Errorf(`s.Push(%q) = %v`, text, err)
+		}
+	}
+	if err := s.Push(New("too many")); err == nil {
+		t.Errorf(`s.Push beyond the limit succeeded`)
+	}
+	if err := s.Push(New(Desc{Level: FATAL, Text: "fatal"})); err != nil {
+		t.Errorf(`s.Push(FATAL) = %v`, err)
+	}
+	if e, _ := s.Peek(); e == nil || e.Text() != "first" {
+		t.Errorf(`s.Peek() = %v`, e)
+	}
+	s.Pop()
+	s.Close()
+
+	// a corrupt length is not allocated
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	f.Write([]byte{0xff, 0xff, 0xff, 0xf0, 1, 2, 3, 4, 5})
+	f.Close()
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	if s, err = OpenFileSpill(path, 300); err != nil {
+		t.Fatal(err)
+	}
+	runtime.ReadMemStats(&after)
+	if n := after.TotalAlloc - before.TotalAlloc; n > 1<<20 {
+		t.Errorf(`OpenFileSpill allocated %d bytes for a corrupt length`, n)
+	}
+	s.Close()
+
+	// a torn write is discarded on reopening
+	f, _ = os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	f.Write([]byte{0, 0, 1, 0, 1, 2, 3})
+	f.Close()
+	if s, err = OpenFileSpill(path, 300); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if s.Len() != 3 {
+		t.Errorf(`s.Len() = %d, want 3`, s.Len())
+	}
+	var texts []string
+	for e, _ := s.Peek(); e != nil; e, _ = s.Peek() {
+		texts = append(texts, e.Text())
+		s.Pop()
+	}
+	if len(texts) != 3 || texts[0] != "second" || texts[2] != "fatal" {
+		t.Errorf(`texts = %q`, texts)
+	}
+	if fi, _ := os.Stat(path); fi.Size() != spillHeaderSize {
+		t.Errorf(`size after emptying = %d`, fi.Size())
+	}
+}
+
+func TestReporterSpill(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spill")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	spill, err := OpenFileSpill(filepath.Join(dir, "errors.spill"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer spill.Close()
+
+	var mu sync.Mutex
+	down := true
+	var got []string
+	sink := SinkFunc(func(e Error) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if down {
+			return New("unavailable")
+		}
+		got = append(got, e.Text())
+		return nil
+	})
+	r := NewReporter(sink, ReporterOptions{Spill: spill, RetryInterval: time.Millisecond})
+	r.Report(New(Desc{Level: FATAL, Text: "disk failed"}))
+	r.Report(New("cache miss"))
+	for deadline := time.Now().Add(time.Second); r.Stats().Spilled < 2 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	mu.Lock()
+	down = false
+	mu.Unlock()
+	for deadline := time.Now().Add(time.Second); r.Stats().Reported < 2 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	r.Close()
+	if len(got) != 2 || got[0] != "disk failed" || got[1] != "cache miss" {
+		t.Errorf(`got = %q`, got)
+	}
+	if st := r.Stats(); st.Failed != 2 || st.Spilled != 2 || st.Reported != 2 {
+		t.Errorf(`r.Stats() = %+v`, st)
+	}
+}
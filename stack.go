@@ -0,0 +1,64 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+)
+
+// stack returns the trace of all goroutines, without the calldepth innermost
+// frames of the current one.
+func stack(calldepth int) string {
+	if atomic.LoadUint32(&rawStacks) != 0 {
+		return rawStack(calldepth)
+	}
+	calldepth *= 2
+	buffer := make([]byte, 4096)
+	buffer = buffer[:runtime.Stack(buffer, true)]
+	var p1, p2, l int
+	for j, c := range buffer {
+		if c == 10 {
+			if l == 0 {
+				p1 = j + 1
+			} else if l == calldepth {
+				p2 = j + 1
+				break
+			}
+			l++
+		}
+	}
+	if p2 > 0 {
+		return string(buffer[:p1]) + string(buffer[p2:])
+	}
+	return string(buffer)
+}
+
+// goroutineID returns the ID of the current goroutine, as found in the header
+// of its stack trace, or zero if unknown.
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := bytes.TrimPrefix(buf[:runtime.Stack(buf[:], false)], []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		id, _ := strconv.ParseUint(string(b[:i]), 10, 64)
+		return id
+	}
+	return 0
+}
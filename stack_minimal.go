@@ -0,0 +1,34 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build errors_minimal
+// +build errors_minimal
+
+package errors
+
+// stack returns no trace in minimal builds; "debug.stack" elements of Info
+// are dropped, as when stacks are disabled by SetStacks.
+func stack(calldepth int) string {
+	return ""
+}
+
+// goroutineID returns zero in minimal builds.
+func goroutineID() uint64 {
+	return 0
+}
+
+// rawFrames returns nil in minimal builds, which record no raw stack traces.
+func rawFrames(st string) []Frame {
+	return nil
+}
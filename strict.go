@@ -0,0 +1,87 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Policies applied to errors missing required attributes, see SetStrict.
+const (
+	// STRICT_OFF does not check errors.
+	STRICT_OFF int8 = iota
+	// STRICT_NOTIFY notifies observers with EVENT_INVARIANT_VIOLATION.
+	STRICT_NOTIFY
+	// STRICT_LOG also prints the violation with the logger passed to
+	// SetStrict.
+	STRICT_LOG
+	// STRICT_PANIC also panics with the violation, e.g. to fail tests.
+	STRICT_PANIC
+)
+
+var strict struct {
+	sync.RWMutex
+	policy int8
+	log    Logger
+}
+
+// SetStrict sets the policy applied when an error is created or externalized
+// (by PublicText, the wire format and the HTTP shapes) without a registered
+// code, or with a level of ERROR or above but no public text. The violation
+// is described by an error with the code ERR_INVALID. The logger is only
+// used with STRICT_LOG.
+func SetStrict(policy int8, log Logger) {
+	if policy >= STRICT_OFF && policy <= STRICT_PANIC {
+		strict.Lock()
+		strict.policy, strict.log = policy, log
+		strict.Unlock()
+	}
+}
+
+// checkInvariants checks the fields of em and applies the strict policy to
+// it, when it is created or externalized, as stated by op.
+func checkInvariants(em *errorMessage, op string) {
+	checkFields(em, op)
+	strict.RLock()
+	policy, log := strict.policy, strict.log
+	strict.RUnlock()
+	if policy == STRICT_OFF {
+		return
+	}
+	var missing []string
+	if CodeName(em.code) == "" {
+		missing = append(missing, "registered code")
+	}
+	if em.level >= ERROR && em.public == "" {
+		missing = append(missing, "public text")
+	}
+	if len(missing) == 0 {
+		return
+	}
+	// built directly, not to be checked in turn
+	v := newMessage(op + " error " + strconv.Quote(em.Error()) + " without " + strings.Join(missing, " nor "))
+	v.code = ERR_INVALID
+	notify(EVENT_INVARIANT_VIOLATION, v)
+	switch policy {
+	case STRICT_LOG:
+		if log != nil {
+			log.Print(v)
+		}
+	case STRICT_PANIC:
+		panic(v)
+	}
+}
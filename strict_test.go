@@ -0,0 +1,68 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStrict(t *testing.T) {
+	var events []Error
+	remove := AddObserver(func(event int8, err Error) {
+		if event == EVENT_INVARIANT_VIOLATION {
+			events = append(events, err)
+		}
+	})
+	defer remove()
+	defer SetStrict(STRICT_OFF, nil)
+	RegisterCode(0x2200, "QUOTA_EXCEEDED")
+	defer RegisterCode(0x2200, "")
+
+	New("not checked")
+	if len(events) != 0 {
+		t.Errorf(`STRICT_OFF reported %d violations`, len(events))
+	}
+
+	log := &mockLogger{}
+	SetStrict(STRICT_LOG, log)
+	e := New(Desc{Code: 0x2200, Level: WARNING, Text: "quota nearly exceeded"})
+	PublicText(e)
+	if len(events) != 0 {
+		t.Errorf(`valid warning reported: %v`, events)
+	}
+	e = New(Desc{Code: 0x2200, Text: "quota exceeded"})
+	if len(events) != 1 || events[0].Code() != ERR_INVALID || !strings.Contains(events[0].Text(), "without public text") {
+		t.Errorf(`events = %v`, events)
+	}
+	if !strings.Contains(log.log, "quota exceeded") {
+		t.Errorf(`log = %q`, log.log)
+	}
+	SetPublicText(e, "Quota exceeded.")
+	PublicText(e)
+	if len(events) != 1 {
+		t.Errorf(`valid error reported: %v`, events[1:])
+	}
+
+	SetStrict(STRICT_PANIC, nil)
+	defer func() {
+		r, _ := recover().(Error)
+		if r == nil || !strings.Contains(r.Text(), "without registered code nor public text") {
+			t.Errorf(`recovered %v`, r)
+		}
+	}()
+	New("no code")
+	t.Errorf(`STRICT_PANIC did not panic`)
+}
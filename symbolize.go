@@ -0,0 +1,233 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"bytes"
+	"debug/elf"
+	"debug/gosym"
+	"fmt"
+	"os"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// rawStacks is set when raw stack traces are recorded.
+var rawStacks uint32
+
+// buildID is the Go build ID of the running executable, read once.
+var buildID struct {
+	once sync.Once
+	id   string
+}
+
+// SetRawStacks sets whether "debug.stack" elements of Info are replaced by
+// raw stack traces of the current goroutine instead of resolved ones, for
+// stripped binaries or to keep resolution out of production hosts. A raw
+// trace records the build ID of the executable and the program counters of
+// the frames, as offsets relative to a function of this package, so that it
+// remains valid under address randomization. Symbolize resolves it later,
+// against the same binary.
+func SetRawStacks(on bool) {
+	var flag uint32
+	if on {
+		flag = 1
+	}
+	atomic.StoreUint32(&rawStacks, flag)
+}
+
+// symbolAnchor is the function program counters are recorded relative to.
+func symbolAnchor() {}
+
+// anchorName is the name of symbolAnchor in symbol tables.
+var anchorName = runtime.FuncForPC(reflect.ValueOf(symbolAnchor).Pointer()).Name()
+
+// rawStack returns the raw trace of the current goroutine, without the
+// calldepth innermost frames.
+func rawStack(calldepth int) string {
+	pcs := make([]uintptr, 64)
+	pcs = pcs[:runtime.Callers(calldepth+2, pcs)]
+	anchor := anchorEntry()
+	offsets := make([]string, len(pcs))
+	for i, pc := range pcs {
+		offsets[i] = strconv.FormatInt(int64(pc)-int64(anchor), 10)
+	}
+	return rawStackPrefix + "build_id=" + runningBuildID() + " offsets=" + strings.Join(offsets, ",")
+}
+
+// anchorEntry returns the entry address of symbolAnchor in the running
+// executable.
+func anchorEntry() uintptr {
+	return runtime.FuncForPC(reflect.ValueOf(symbolAnchor).Pointer()).Entry()
+}
+
+// runningBuildID returns the Go build ID of the running executable.
+func runningBuildID() string {
+	buildID.once.Do(func() {
+		if path, err := os.Executable(); err == nil {
+			buildID.id, _ = elfBuildID(path)
+		}
+	})
+	return buildID.id
+}
+
+// parseRawStack returns the build ID and the offsets recorded in the raw
+// stack trace st, stripped of rawStackPrefix.
+func parseRawStack(st string) (id string, offsets []int64) {
+	for _, kv := range strings.Fields(st) {
+		if strings.HasPrefix(kv, "build_id=") {
+			id = kv[len("build_id="):]
+		} else if strings.HasPrefix(kv, "offsets=") {
+			for _, o := range strings.Split(kv[len("offsets="):], ",") {
+				if off, err := strconv.ParseInt(o, 10, 64); err == nil {
+					offsets = append(offsets, off)
+				}
+			}
+		}
+	}
+	return
+}
+
+// rawFrames returns the frames of the raw stack trace st, innermost first,
+// resolved in process, or nil if st was not recorded by the running
+// executable.
+func rawFrames(st string) []Frame {
+	id, offsets := parseRawStack(strings.TrimPrefix(st, rawStackPrefix))
+	if id == "" || id != runningBuildID() || len(offsets) == 0 {
+		return nil
+	}
+	anchor := int64(anchorEntry())
+	pcs := make([]uintptr, len(offsets))
+	for i, off := range offsets {
+		pcs[i] = uintptr(anchor + off)
+	}
+	appPackages.RLock()
+	prefixes := appPackages.prefixes
+	appPackages.RUnlock()
+	var frames []Frame
+	it := runtime.CallersFrames(pcs)
+	for {
+		rf, more := it.Next()
+		if rf.Function != "" {
+			f := Frame{Function: rf.Function, File: rf.File, Line: rf.Line, PC: rf.PC}
+			f.Kind = frameKind(f, prefixes)
+			frames = append(frames, f)
+		}
+		if !more {
+			return frames
+		}
+	}
+}
+
+// elfBuildID returns the Go build ID recorded in the ELF binary at path.
+func elfBuildID(path string) (string, error) {
+	f, err := elf.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return goBuildID(f)
+}
+
+func goBuildID(f *elf.File) (string, error) {
+	s := f.Section(".note.go.buildid")
+	if s == nil {
+		return "", fmt.Errorf("no Go build ID")
+	}
+	data, err := s.Data()
+	if err != nil {
+		return "", err
+	}
+	// note header: name size, descriptor size, type; then the name "Go",
+	// padded to 4 bytes, and the descriptor
+	if len(data) < 16 {
+		return "", fmt.Errorf("invalid Go build ID note")
+	}
+	nameSize := f.ByteOrder.Uint32(data)
+	descSize := f.ByteOrder.Uint32(data[4:])
+	start := 12 + (nameSize+3)&^3
+	if uint32(len(data)) < start+descSize {
+		return "", fmt.Errorf("invalid Go build ID note")
+	}
+	return string(data[start : start+descSize]), nil
+}
+
+// Symbolize resolves the raw stack traces in report, e.g. an Info element or
+// a logged error, recorded with SetRawStacks, against the ELF binary at the
+// given path, which must be the one the traces were recorded by, stripped or
+// not. Traces are replaced by frames rendered like by runtime.Stack.
+func Symbolize(report, binaryPath string) (string, error) {
+	f, err := elf.Open(binaryPath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	id, _ := goBuildID(f)
+	table, err := goSymTable(f)
+	if err != nil {
+		return "", err
+	}
+	anchor := table.LookupFunc(anchorName)
+	if anchor == nil {
+		return "", fmt.Errorf("%s not found in %s", anchorName, binaryPath)
+	}
+
+	lines := strings.Split(report, "\n")
+	for i, line := range lines {
+		j := strings.Index(line, rawStackPrefix)
+		if j < 0 {
+			continue
+		}
+		recorded, offsets := parseRawStack(line[j+len(rawStackPrefix):])
+		if recorded != id {
+			return "", fmt.Errorf("build ID mismatch: trace from %q, binary is %q", recorded, id)
+		}
+		var b bytes.Buffer
+		b.WriteString(line[:j] + "goroutine ? [symbolized]:")
+		for _, off := range offsets {
+			// return addresses point after the call instruction
+			pc := uint64(int64(anchor.Entry)+off) - 1
+			file, ln, fn := table.PCToLine(pc)
+			name := "?"
+			if fn != nil {
+				name = fn.Name
+			}
+			fmt.Fprintf(&b, "\n%s%s(...)\n%s\t%s:%d", line[:j], name, line[:j], file, ln)
+		}
+		lines[i] = b.String()
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// goSymTable returns the Go symbol table of f, which remains in stripped
+// binaries.
+func goSymTable(f *elf.File) (*gosym.Table, error) {
+	pcln, text := f.Section(".gopclntab"), f.Section(".text")
+	if pcln == nil || text == nil {
+		return nil, fmt.Errorf("no Go line table")
+	}
+	data, err := pcln.Data()
+	if err != nil {
+		return nil, err
+	}
+	return gosym.NewTable(nil, gosym.NewLineTable(data, text.Addr))
+}
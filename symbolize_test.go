@@ -0,0 +1,67 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux && !errors_minimal
+// +build linux,!errors_minimal
+
+package errors
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSymbolize(t *testing.T) {
+	defer SetRawStacks(false)
+	SetRawStacks(true)
+	info := New(Desc{Text: "failed", Info: []string{"debug.stack"}}).Info()
+	if len(info) != 1 || !strings.HasPrefix(info[0], "rawstack build_id=") {
+		t.Fatalf(`Info() = %q`, info)
+	}
+	bin, err := os.Executable()
+	if err != nil {
+		t.Skip(err)
+	}
+	report := "failed\n\t" + info[0]
+	got, err := Symbolize(report, bin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(got, "\n")
+	if len(lines) < 4 || lines[1] != "\tgoroutine ? [symbolized]:" || lines[2] != "\tgithub.com/agext/errors.TestSymbolize(...)" ||
+		!strings.Contains(lines[3], "symbolize_test.go:") {
+		t.Errorf(`Symbolize(report) = %s`, got)
+	}
+
+	mismatched := strings.Replace(report, "build_id=", "build_id=x", 1)
+	if _, err := Symbolize(mismatched, bin); err == nil {
+		t.Errorf(`Symbolize accepted a trace from another build`)
+	}
+}
+
+func TestRawFrames(t *testing.T) {
+	defer SetRawStacks(false)
+	SetRawStacks(true)
+	e := New(Desc{Text: "failed", Info: []string{"debug.stack"}})
+	frames := Frames(e)
+	if len(frames) == 0 || frames[0].Function != "github.com/agext/errors.TestRawFrames" ||
+		!strings.HasSuffix(frames[0].File, "symbolize_test.go") || frames[0].PC == 0 {
+		t.Errorf(`Frames(raw) = %+v`, frames)
+	}
+	other := New(Desc{Text: "failed", Info: []string{strings.Replace(e.Info()[0], "build_id=", "build_id=x", 1)}})
+	if frames := Frames(other); frames != nil {
+		t.Errorf(`Frames(raw from another build) = %+v`, frames)
+	}
+}
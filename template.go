@@ -0,0 +1,96 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"html/template"
+	"net/http"
+)
+
+// TemplateFuncs returns functions exposing the classification of errors to
+// text/template and html/template templates (the result can be assigned to
+// either FuncMap type):
+//   - errLevel returns the name of the level of an error;
+//   - errCode returns the rendering of the code of an error, or an empty string;
+//   - errPublicText returns the public text of an error, see PublicText;
+//   - errFields returns a copy of the fields of an error.
+//
+// Errors not implementing Error are treated as having the level ERROR and no
+// code, public text or fields.
+func TemplateFuncs() map[string]interface{} {
+	return map[string]interface{}{
+		"errLevel":      tplLevel,
+		"errCode":       tplCode,
+		"errPublicText": PublicText,
+		"errFields":     tplFields,
+	}
+}
+
+func tplLevel(err error) string {
+	if e, ok := err.(Error); ok {
+		return levelName(e.Level())
+	}
+	return levelName(ERROR)
+}
+
+func tplCode(err error) string {
+	if em := messageOf(err); em != nil && em.code != 0 {
+		return formatCode(em)
+	}
+	return ""
+}
+
+func tplFields(err error) map[string]interface{} {
+	fields := make(map[string]interface{})
+	if em := messageOf(err); em != nil {
+		for k, v := range em.userFields() {
+			fields[k] = v
+		}
+	}
+	return fields
+}
+
+// defaultPublicText is shown by WriteErrorPage for errors without a public text.
+const defaultPublicText = "Sorry, something went wrong."
+
+var errorPage = template.Must(template.New("error").Funcs(TemplateFuncs()).Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Status}} {{.StatusText}}</title></head>
+<body>
+<h1>{{.StatusText}}</h1>
+<p>{{with errPublicText .Err}}{{.}}{{else}}` + defaultPublicText + `{{end}}</p>
+{{with errCode .Err}}<p><small>Error code: {{.}}</small></p>{{end}}
+</body>
+</html>
+`))
+
+// WriteErrorPage replies to a request with a minimal HTML page describing err
+// using only its public text and code, with the given status (500 if zero).
+func WriteErrorPage(w http.ResponseWriter, status int, err error) {
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	errorPage.Execute(w, struct {
+		Status     int
+		StatusText string
+		Err        error
+	}{status, http.StatusText(status), err})
+}
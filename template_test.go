@@ -0,0 +1,62 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"bytes"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestTemplateFuncs(t *testing.T) {
+	tpl := template.Must(template.New("").Funcs(TemplateFuncs()).Parse(
+		`{{errLevel .}}|{{errCode .}}|{{errPublicText .}}|{{len (errFields .)}}`))
+	SetOrigin("svc", "")
+	err := New(Desc{Level: WARNING, Code: 0x17, Text: "internal", PublicText: "Try again later."})
+	SetOrigin("", "")
+	for _, c := range []struct {
+		err  error
+		want string
+	}{
+		{err, "WARNING|0x0017|Try again later.|1"},
+		{fmt.Errorf("internal"), "ERROR|||0"},
+		{nil, "ERROR|||0"},
+	} {
+		var buf bytes.Buffer
+		if e := tpl.Execute(&buf, c.err); e != nil || buf.String() != c.want {
+			t.Errorf(`template output = %q, %v, want %q`, buf.String(), e, c.want)
+		}
+	}
+}
+
+func TestWriteErrorPage(t *testing.T) {
+	w := httptest.NewRecorder()
+	WriteErrorPage(w, 0, New(Desc{Code: 0x17, Text: "db password is <hunter2>"}))
+	body := w.Body.String()
+	if w.Code != 500 || strings.Contains(body, "hunter2") || !strings.Contains(body, defaultPublicText) || !strings.Contains(body, "0x0017") {
+		t.Errorf(`WriteErrorPage(...) = %d %q`, w.Code, body)
+	}
+	w = httptest.NewRecorder()
+	WriteErrorPage(w, 404, SetPublicText(New("no row"), "No such <page>."))
+	if body = w.Body.String(); w.Code != 404 || !strings.Contains(body, "No such &lt;page&gt;.") {
+		t.Errorf(`WriteErrorPage(...) = %d %q`, w.Code, body)
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+)
+
+// textTemplate renders the text of a Sentinel against the given fields.
+type textTemplate func(fields map[string]interface{}) (string, error)
+
+// parseText returns a textTemplate resolving the {{.Field}} references text
+// contains, or nil if it has no actions. Only texts defined in code, those of
+// Sentinels, are parsed: the text of other errors may come from the input of
+// the program, and is never executed.
+func parseText(text string) textTemplate {
+	if !strings.Contains(text, "{{") {
+		return nil
+	}
+	t, err := template.New("").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return func(map[string]interface{}) (string, error) {
+			return text, err
+		}
+	}
+	return func(fields map[string]interface{}) (string, error) {
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, fields); err != nil {
+			return text, err
+		}
+		return buf.String(), nil
+	}
+}
@@ -0,0 +1,26 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//go:build errors_minimal
+// +build errors_minimal
+
+package errors
+
+// textTemplate renders the text of a Sentinel against the given fields.
+type textTemplate func(fields map[string]interface{}) (string, error)
+
+// parseText returns nil: templates are not supported in minimal builds, and
+// texts are kept as they are.
+func parseText(text string) textTemplate {
+	return nil
+}
@@ -0,0 +1,57 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import "testing"
+
+func TestSentinelTemplate(t *testing.T) {
+	errQuota := Define(Desc{
+		Text:   "user {{.user}} exceeded quota of {{.quota}} GB",
+		Fields: map[string]interface{}{"user": "bob", "quota": 5},
+	})
+	err := errQuota.New()
+	if got, want := err.Text(), "user bob exceeded quota of 5 GB"; got != want {
+		t.Errorf(`err.Text() = %q, want %q`, got, want)
+	}
+	if _, v, ok := FindField(err, func(k string, _ interface{}) bool { return k == "quota" }); !ok || v != 5 {
+		t.Errorf(`FindField(err, "quota") = %v, %v, want 5, true`, v, ok)
+	}
+	if len(err.Info()) != 0 {
+		t.Errorf(`err.Info() = %q, want none`, err.Info())
+	}
+
+	if err = errQuota.New(); err.Text() != "user bob exceeded quota of 5 GB" {
+		t.Errorf(`err.Text() = %q from a reused template`, err.Text())
+	}
+
+	err = Define(Desc{Text: "no {{.such}} field", Fields: map[string]interface{}{"user": "bob"}}).New()
+	if got, want := err.Text(), "no {{.such}} field"; got != want {
+		t.Errorf(`err.Text() = %q, want %q`, got, want)
+	}
+	if len(err.Info()) != 1 {
+		t.Errorf(`err.Info() = %q, want the template error`, err.Info())
+	}
+
+	if got, want := Define(Desc{Text: "{{.x}}"}).New().Text(), "{{.x}}"; got != want {
+		t.Errorf(`Define(Desc{Text: "{{.x}}"}).New().Text() = %q, want %q`, got, want)
+	}
+	input := `{{.user}}{{range $i := .}}{{end}}`
+	if got := New(Desc{Text: input, Fields: map[string]interface{}{"user": "bob"}}).Text(); got != input {
+		t.Errorf(`New(Desc{Text: input}).Text() = %q, want the text as is`, got)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"strings"
+	"time"
+)
+
+// Prefix of the keys of the fields recording timings.
+const fieldTimingPrefix = "timing."
+
+// Timer starts timing the operation name, and returns a function to be
+// deferred with a pointer to the error result of the enclosing function:
+//
+//	func query() (err error) {
+//		defer errors.Timer("db.query")(&err)
+//		...
+//	}
+//
+// If the result is an Error when the function returns, the time elapsed
+// since Timer was called is recorded on it as the field "timing.<name>".
+// Errors not implementing Error are left untouched. The time is measured
+// with the package clock.
+func Timer(name string) func(errp *error) {
+	start := now()
+	return func(errp *error) {
+		if errp == nil {
+			return
+		}
+		if em := ownMessage(*errp); em != nil {
+			em.setField(fieldTimingPrefix+name, now().Sub(start))
+		}
+	}
+}
+
+// Timings returns the durations recorded on err by Timer, keyed by operation
+// name, or nil if there are none.
+func Timings(err error) map[string]time.Duration {
+	em := messageOf(err)
+	if em == nil {
+		return nil
+	}
+	var res map[string]time.Duration
+	for k, v := range em.fields {
+		if d, ok := v.(time.Duration); ok && strings.HasPrefix(k, fieldTimingPrefix) {
+			if res == nil {
+				res = make(map[string]time.Duration)
+			}
+			res[k[len(fieldTimingPrefix):]] = d
+		}
+	}
+	return res
+}
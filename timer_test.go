@@ -0,0 +1,42 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"io"
+	"testing"
+	"time"
+)
+
+func TestTimer(t *testing.T) {
+	defer SetClock(nil)
+	start := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	SetClock(fixedClock(start))
+	query := func(fail error) (err error) {
+		defer Timer("db.query")(&err)
+		SetClock(fixedClock(start.Add(150 * time.Millisecond)))
+		return fail
+	}
+	err := query(New("timeout"))
+	if got := Timings(err); len(got) != 1 || got["db.query"] != 150*time.Millisecond {
+		t.Errorf(`Timings(err) = %v, want map[db.query:150ms]`, got)
+	}
+	if err = query(io.EOF); err != io.EOF {
+		t.Errorf(`query(io.EOF) = %v`, err)
+	}
+	if err = query(nil); err != nil || Timings(New("abc")) != nil {
+		t.Errorf(`query(nil) = %v`, err)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"strings"
+)
+
+// Field keys used to describe TLS failures, see TLSCause.
+const (
+	fieldTLSReason    = "tls.reason"
+	fieldTLSHost      = "tls.host"
+	fieldTLSSubject   = "tls.subject"
+	fieldTLSIssuer    = "tls.issuer"
+	fieldTLSNotBefore = "tls.not_before"
+	fieldTLSNotAfter  = "tls.not_after"
+	fieldTLSDetail    = "tls.detail"
+)
+
+// TLSCause is a CauseExtractor for the errors of crypto/tls and crypto/x509,
+// to be installed with AddCauseExtractor. It gives them the code ERR_TLS and
+// the field "tls.reason": one of "expired", "not_yet_valid",
+// "hostname_mismatch", "unknown_authority", "invalid_certificate",
+// "handshake" or "not_tls". Certificate errors also get the subject, issuer
+// and validity period of the certificate in the fields "tls.subject",
+// "tls.issuer", "tls.not_before" and "tls.not_after", and are not retryable.
+func TLSCause(err error) (Cause, bool) {
+	c := Cause{Code: ERR_TLS, Level: LEVEL_ERROR, Fields: make(map[string]interface{})}
+	var cert *x509.Certificate
+	switch e := err.(type) {
+	case x509.CertificateInvalidError:
+		cert = e.Cert
+		c.Fields[fieldTLSReason] = "invalid_certificate"
+		if e.Reason == x509.Expired {
+			c.Fields[fieldTLSReason] = "expired"
+			if cert != nil && now().Before(cert.NotBefore) {
+				c.Fields[fieldTLSReason] = "not_yet_valid"
+			}
+		}
+		if e.Detail != "" {
+			c.Fields[fieldTLSDetail] = e.Detail
+		}
+	case x509.HostnameError:
+		cert = e.Certificate
+		c.Fields[fieldTLSReason] = "hostname_mismatch"
+		c.Fields[fieldTLSHost] = e.Host
+	case x509.UnknownAuthorityError:
+		cert = e.Cert
+		c.Fields[fieldTLSReason] = "unknown_authority"
+	case tls.RecordHeaderError:
+		c.Fields[fieldTLSReason] = "not_tls"
+		c.Fields[fieldTLSDetail] = e.Msg
+	default:
+		// alerts, such as "tls: handshake failure", have no exported type
+		// in older releases; they are recognized by their text, unless they
+		// wrap another error
+		if _, isWrapper := err.(interface {
+			Unwrap() error
+		}); isWrapper || !strings.HasPrefix(err.Error(), "tls: ") {
+			return Cause{}, false
+		}
+		c.Fields[fieldTLSReason] = "handshake"
+		c.Fields[fieldTLSDetail] = strings.TrimPrefix(err.Error(), "tls: ")
+		return c, true
+	}
+	c.Fields[fieldRetryable] = false
+	if cert != nil {
+		c.Fields[fieldTLSSubject] = cert.Subject.String()
+		c.Fields[fieldTLSIssuer] = cert.Issuer.String()
+		c.Fields[fieldTLSNotBefore] = cert.NotBefore
+		c.Fields[fieldTLSNotAfter] = cert.NotAfter
+	}
+	return c, true
+}
@@ -0,0 +1,83 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestTLSCause(t *testing.T) {
+	defer func() { extractors.list = nil }()
+	AddCauseExtractor(TLSCause)
+	field := func(e Error, key string) interface{} {
+		_, v, _ := FindField(e, func(k string, _ interface{}) bool { return k == key })
+		return v
+	}
+	cert := &x509.Certificate{
+		Subject:   pkix.Name{CommonName: "api.example.com"},
+		Issuer:    pkix.Name{CommonName: "Example CA"},
+		NotBefore: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:  time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	for i, c := range []struct {
+		err     error
+		reason  string
+		subject interface{}
+	}{
+		{x509.CertificateInvalidError{Cert: cert, Reason: x509.Expired}, "expired", "CN=api.example.com"},
+		{&tls.CertificateVerificationError{Err: x509.HostnameError{Certificate: cert, Host: "db.example.com"}}, "hostname_mismatch", "CN=api.example.com"},
+		{fmt.Errorf("dial: %w", x509.UnknownAuthorityError{Cert: cert}), "unknown_authority", "CN=api.example.com"},
+		{tls.RecordHeaderError{Msg: "first record does not look like a TLS handshake"}, "not_tls", nil},
+		{fmt.Errorf("remote error: %w", tlsAlert("handshake failure")), "handshake", nil},
+		{fmt.Errorf("tls: %w", fmt.Errorf("broken")), "", nil},
+	} {
+		e := Classify(c.err)
+		if reason, _ := field(e, fieldTLSReason).(string); reason != c.reason {
+			t.Errorf(`#%d: field(e, "tls.reason") = %q, want %q`, i, reason, c.reason)
+		}
+		if c.reason != "" && e.Code() != ERR_TLS {
+			t.Errorf(`#%d: e.Code() = %d, want ERR_TLS`, i, e.Code())
+		}
+		if field(e, fieldTLSSubject) != c.subject {
+			t.Errorf(`#%d: field(e, "tls.subject") = %v, want %v`, i, field(e, fieldTLSSubject), c.subject)
+		}
+	}
+
+	e := Classify(x509.CertificateInvalidError{Cert: cert, Reason: x509.Expired})
+	if field(e, fieldTLSNotAfter) != cert.NotAfter || field(e, fieldRetryable) != false {
+		t.Errorf(`e.Fields() = %v`, e.(*errorMessage).fields)
+	}
+	e = Classify(x509.HostnameError{Certificate: cert, Host: "db.example.com"})
+	if field(e, fieldTLSHost) != "db.example.com" {
+		t.Errorf(`field(e, "tls.host") = %v`, field(e, fieldTLSHost))
+	}
+	e = Classify(tlsAlert("handshake failure"))
+	if field(e, fieldTLSReason) != "handshake" || field(e, fieldTLSDetail) != "handshake failure" {
+		t.Errorf(`e.Fields() = %v`, e.(*errorMessage).fields)
+	}
+}
+
+// tlsAlert mimics the unexported alert type of crypto/tls.
+type tlsAlert string
+
+func (a tlsAlert) Error() string { return "tls: " + string(a) }
@@ -0,0 +1,100 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.7
+// +build go1.7
+
+package errors
+
+import (
+	"context"
+	"sync"
+)
+
+// Field keys used to record the trace active when an error was created.
+const (
+	fieldTraceID = "trace_id"
+	fieldSpanID  = "span_id"
+)
+
+// TraceExtractor returns the identifiers of the trace and span active in ctx,
+// or empty strings if there is none. With OpenTelemetry, for instance:
+//
+//	func(ctx context.Context) (string, string) {
+//		sc := trace.SpanContextFromContext(ctx)
+//		if !sc.IsValid() {
+//			return "", ""
+//		}
+//		return sc.TraceID().String(), sc.SpanID().String()
+//	}
+type TraceExtractor func(ctx context.Context) (traceID, spanID string)
+
+var tracing struct {
+	sync.RWMutex
+	extract TraceExtractor
+}
+
+// SetTraceExtractor sets the function NewCtx uses to find the active trace;
+// nil (the default) disables trace recording.
+func SetTraceExtractor(f TraceExtractor) {
+	tracing.Lock()
+	tracing.extract = f
+	tracing.Unlock()
+}
+
+// NewCtx is like New, and also records the identifiers of the trace and span
+// active in ctx as the fields "trace_id" and "span_id", if a TraceExtractor
+// is set. With SetGoroutineCapture, it records the pprof labels of ctx too,
+// and with SetPropagatedFields, the fields of the error stored in ctx.
+func NewCtx(ctx context.Context, desc interface{}) Error {
+	return Default().newCtx(ctx, desc)
+}
+
+// NewCtx is like the package NewCtx function, using f.
+func (f *Factory) NewCtx(ctx context.Context, desc interface{}) Error {
+	return f.newCtx(ctx, desc)
+}
+
+// newCtx implements NewCtx.
+func (f *Factory) newCtx(ctx context.Context, desc interface{}) Error {
+	e := newError(desc, 5)
+	em := ownMessage(e)
+	if em == nil || ctx == nil {
+		return f.finish(e)
+	}
+	stampLabels(ctx, em)
+	propagateFields(ctx, em)
+	tracing.RLock()
+	extract := tracing.extract
+	tracing.RUnlock()
+	if extract != nil {
+		if traceID, spanID := extract(ctx); traceID != "" {
+			em.setField(fieldTraceID, traceID)
+			if spanID != "" {
+				em.setField(fieldSpanID, spanID)
+			}
+		}
+	}
+	return f.finish(e)
+}
+
+// Trace returns the identifiers of the trace and span recorded on err by
+// NewCtx, or empty strings.
+func Trace(err error) (traceID, spanID string) {
+	if em := messageOf(err); em != nil {
+		traceID, _ = em.field(fieldTraceID).(string)
+		spanID, _ = em.field(fieldSpanID).(string)
+	}
+	return
+}
@@ -0,0 +1,51 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.7
+// +build go1.7
+
+package errors
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type spanKey struct{}
+
+func TestNewCtx(t *testing.T) {
+	ctx := context.WithValue(context.Background(), spanKey{}, [2]string{"4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"})
+	if id, _ := Trace(NewCtx(ctx, "abc")); id != "" {
+		t.Errorf(`Trace(NewCtx(...)) = %q without extractor, want ""`, id)
+	}
+	SetTraceExtractor(func(ctx context.Context) (string, string) {
+		ids, _ := ctx.Value(spanKey{}).([2]string)
+		return ids[0], ids[1]
+	})
+	defer SetTraceExtractor(nil)
+	err := NewCtx(ctx, Desc{Text: "abc", Info: []string{"debug.stack"}})
+	if traceID, spanID := Trace(err); traceID != "4bf92f3577b34da6a3ce929d0e0e4736" || spanID != "00f067aa0ba902b7" {
+		t.Errorf(`Trace(NewCtx(...)) = %q, %q`, traceID, spanID)
+	}
+	// the stack trace starts at the caller of NewCtx
+	if !minimalBuild {
+		if lines := strings.SplitN(err.Info()[0], "\n", 3); !strings.Contains(lines[1], "errors.TestNewCtx") {
+			t.Errorf(`NewCtx(...) stack trace starts with %q`, lines[1])
+		}
+	}
+	if id, _ := Trace(NewCtx(context.Background(), "abc")); id != "" {
+		t.Errorf(`Trace(NewCtx(background)) = %q, want ""`, id)
+	}
+}
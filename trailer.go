@@ -0,0 +1,80 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Names of the trailers carrying error identifiers.
+const (
+	TRAILER_FINGERPRINT = "Error-Fingerprint"
+	TRAILER_REFERENCE   = "Error-Reference"
+)
+
+// SetTrailers sets the fingerprint and support reference of err as trailers
+// of the response written to w, even if its header has already been sent, so
+// that streams failing late still yield identifiers the client can report.
+// It does nothing if err is nil.
+func SetTrailers(w http.ResponseWriter, err error) {
+	if err == nil {
+		return
+	}
+	h := w.Header()
+	h.Set(http.TrailerPrefix+TRAILER_FINGERPRINT, Fingerprint(err))
+	if ref := Reference(err); ref != "" {
+		h.Set(http.TrailerPrefix+TRAILER_REFERENCE, ref)
+	}
+}
+
+// FromTrailers returns the error fingerprint and support reference set by
+// SetTrailers, from the trailer of a response, available once its body has
+// been read to the end.
+func FromTrailers(trailer http.Header) (fingerprint, reference string) {
+	return trailer.Get(TRAILER_FINGERPRINT), trailer.Get(TRAILER_REFERENCE)
+}
+
+// TrailerMetadata returns the fingerprint and support reference of err as
+// gRPC trailer metadata, with the lowercase keys gRPC requires; the result
+// can be converted to a metadata.MD and set with grpc.SetTrailer. It returns
+// nil if err is nil.
+func TrailerMetadata(err error) map[string][]string {
+	if err == nil {
+		return nil
+	}
+	md := map[string][]string{
+		strings.ToLower(TRAILER_FINGERPRINT): {Fingerprint(err)},
+	}
+	if ref := Reference(err); ref != "" {
+		md[strings.ToLower(TRAILER_REFERENCE)] = []string{ref}
+	}
+	return md
+}
+
+// FromTrailerMetadata returns the error fingerprint and support reference
+// from gRPC trailer metadata set from TrailerMetadata.
+func FromTrailerMetadata(md map[string][]string) (fingerprint, reference string) {
+	get := func(key string) string {
+		if v := md[strings.ToLower(key)]; len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+	return get(TRAILER_FINGERPRINT), get(TRAILER_REFERENCE)
+}
@@ -0,0 +1,56 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTrailers(t *testing.T) {
+	SetReferenceStore(NewMemoryCounterStore())
+	defer SetReferenceStore(nil)
+	err := New(Desc{Code: 0x17, Text: "stream broken"})
+	AssignReference(err)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		w.(http.Flusher).Flush()
+		SetTrailers(w, err)
+	}))
+	defer srv.Close()
+	resp, e := http.Get(srv.URL)
+	if e != nil {
+		t.Fatal(e)
+	}
+	ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if fp, ref := FromTrailers(resp.Trailer); fp != "0x17" || ref != "E-0017-1" {
+		t.Errorf(`FromTrailers(resp.Trailer) = %q, %q, want "0x17", "E-0017-1"`, fp, ref)
+	}
+
+	md := TrailerMetadata(err)
+	if fp, ref := FromTrailerMetadata(md); len(md["error-reference"]) != 1 || fp != "0x17" || ref != "E-0017-1" {
+		t.Errorf(`FromTrailerMetadata(%v) = %q, %q`, md, fp, ref)
+	}
+	if TrailerMetadata(nil) != nil || Fingerprint(New("abc")) != "abc" {
+		t.Errorf(`TrailerMetadata(nil) or Fingerprint(New("abc")) is wrong`)
+	}
+}
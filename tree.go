@@ -0,0 +1,99 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import "sort"
+
+// branch is a child of an error in a cause tree, with the identity of the
+// branch of the operation it occurred in.
+type branch struct {
+	name string
+	err  Error
+}
+
+// branchesByName sorts branches by their name.
+type branchesByName []branch
+
+func (bs branchesByName) Len() int           { return len(bs) }
+func (bs branchesByName) Less(i, j int) bool { return bs[i].name < bs[j].name }
+func (bs branchesByName) Swap(i, j int)      { bs[i], bs[j] = bs[j], bs[i] }
+
+func sortBranches(bs []branch) {
+	sort.Sort(branchesByName(bs))
+}
+
+// Tree returns an Error built from desc (anything accepted by New), caused by
+// the non-nil errors in branches, keyed by the identity of the branch of a
+// fan-out operation they occurred in (e.g. a shard or a region). Children may
+// be trees themselves. The tree is preserved by serialization, and rendered
+// by FormatTree and by Log at VERBOSITY_INFO and above.
+func Tree(desc interface{}, branches map[string]error) Error {
+	e := New(desc)
+	if em := ownMessage(e); em != nil {
+		for name, err := range branches {
+			if err != nil {
+				em.branches = append(em.branches, branch{name, from(err)})
+			}
+		}
+		sortBranches(em.branches)
+	}
+	return e
+}
+
+// Branches returns the children of err in a cause tree, keyed by branch
+// identity, or nil if it has none.
+func Branches(err error) map[string]Error {
+	em := messageOf(err)
+	if em == nil || len(em.branches) == 0 {
+		return nil
+	}
+	res := make(map[string]Error, len(em.branches))
+	for _, b := range em.branches {
+		res[b.name] = b.err
+	}
+	return res
+}
+
+// FormatTree renders err and, if it is the root of a cause tree, its
+// descendants, one per line, indented by depth and prefixed by their branch
+// identity.
+func FormatTree(err error) string {
+	if err == nil {
+		return ""
+	}
+	s := err.Error()
+	if em := messageOf(err); em != nil {
+		for _, line := range em.treeLines(nil, "  ") {
+			s += "\n" + line
+		}
+	}
+	return s
+}
+
+// treeLines appends to lines the rendering of the descendants of em, each
+// line starting with indent repeated once more than its parent.
+func (em *errorMessage) treeLines(lines []string, indent string) []string {
+	return em.appendTree(lines, indent, indent)
+}
+
+func (em *errorMessage) appendTree(lines []string, prefix, indent string) []string {
+	for _, b := range em.branches {
+		lines = append(lines, prefix+"["+b.name+"] "+b.err.Error())
+		if child := messageOf(b.err); child != nil {
+			lines = child.appendTree(lines, prefix+indent, indent)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,58 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestTree(t *testing.T) {
+	err := Tree("scatter failed", map[string]error{
+		"eu": Tree(Desc{Code: 1, Text: "region failed"}, map[string]error{
+			"shard-2": New("not found"),
+			"shard-1": fmt.Errorf("timeout"),
+		}),
+		"us": nil,
+		"ap": New(Desc{Level: WARNING, Text: "slow"}),
+	})
+	want := "scatter failed\n" +
+		"  [ap] slow\n" +
+		"  [eu] region failed (code: 0x0001)\n" +
+		"    [shard-1] timeout\n" +
+		"    [shard-2] not found"
+	if got := FormatTree(err); got != want {
+		t.Errorf(`FormatTree(err) = %q, want %q`, got, want)
+	}
+	if b := Branches(err); len(b) != 2 || b["ap"].Level() != WARNING || len(Branches(b["eu"])) != 2 {
+		t.Errorf(`Branches(err) = %v`, b)
+	}
+	if Branches(New("abc")) != nil {
+		t.Errorf(`Branches(New("abc")) != nil`)
+	}
+
+	defer SetVerbosity(VERBOSITY_TEXT)
+	SetVerbosity(VERBOSITY_INFO)
+	log := &mockLogger{}
+	err.Log(log)
+	want = "scatter failed\n" +
+		"\t[ap] slow\n" +
+		"\t[eu] region failed (code: 0x0001)\n" +
+		"\t\t[shard-1] timeout\n" +
+		"\t\t[shard-2] not found\n"
+	if log.log != want {
+		t.Errorf(`logging test got %q, want %q`, log.log, want)
+	}
+}
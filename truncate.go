@@ -0,0 +1,66 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import "sync/atomic"
+
+// maxText is the maximum number of characters kept of error texts, see
+// SetMaxTextLength.
+var maxText int64
+
+// SetMaxTextLength sets the maximum number of characters of the texts of
+// errors, as set by their constructors or SetText; longer texts are truncated
+// and end with an ellipsis, so that messages embedding huge payloads cannot
+// swamp logs and HTTP responses. The untruncated text is kept aside, see
+// FullText; it is not serialized. Zero, the default, means no limit.
+func SetMaxTextLength(n int) {
+	if n < 0 {
+		n = 0
+	}
+	atomic.StoreInt64(&maxText, int64(n))
+}
+
+// FullText returns the text of err before truncation, see SetMaxTextLength.
+func FullText(err error) string {
+	if em := messageOf(err); em != nil {
+		if em.fullText != "" {
+			return em.fullText
+		}
+		return em.text
+	}
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// truncateText enforces the maximum text length on em.
+func truncateText(em *errorMessage) {
+	max := int(atomic.LoadInt64(&maxText))
+	if max == 0 || len(em.text) <= max {
+		em.fullText = ""
+		return
+	}
+	n := 0
+	for i := range em.text {
+		if n == max {
+			em.fullText = em.text
+			em.text = em.text[:i] + "…"
+			return
+		}
+		n++
+	}
+	em.fullText = ""
+}
@@ -0,0 +1,43 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestMaxTextLength(t *testing.T) {
+	defer SetMaxTextLength(0)
+	long := "payload: " + strings.Repeat("é", 100)
+	if e := New(long); e.Text() != long || FullText(e) != long {
+		t.Errorf(`text truncated without a limit`)
+	}
+	SetMaxTextLength(12)
+	e := New(long)
+	if e.Text() != "payload: ééé…" || FullText(e) != long {
+		t.Errorf(`Text() = %q, FullText() = %q`, e.Text(), FullText(e))
+	}
+	if e.SetText("short"); e.Text() != "short" || FullText(e) != "short" {
+		t.Errorf(`after SetText: Text() = %q, FullText() = %q`, e.Text(), FullText(e))
+	}
+	if e := New("exactly 12 c"); e.Text() != "exactly 12 c" {
+		t.Errorf(`Text() = %q`, e.Text())
+	}
+	if FullText(fmt.Errorf("plain")) != "plain" || FullText(nil) != "" {
+		t.Errorf(`FullText of plain errors`)
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+// fieldInformational is the field key marking errors created by Warning.
+const fieldInformational = "informational"
+
+// Warning is like New, for expected conditions which should never page, such
+// as retries after an optimistic lock conflict: the error returned has the
+// level WARNING, whatever the level in desc, and is informational. While it
+// keeps that level, it is exempt from escalation (see SetEscalation), the
+// severity hook (see SetSeverityFn), ReclassifyByAge, and unhandled error
+// detection, however often it occurs. Setting another level with SetLevel
+// promotes it to a regular error.
+func Warning(desc interface{}) Error {
+	e := buildError(desc, 4)
+	if em := ownMessage(e); em != nil {
+		em.level = WARNING
+		em.setField(fieldInformational, true)
+	}
+	return Default().finish(e)
+}
+
+// IsInformational reports whether err was created by Warning and has not been
+// promoted since.
+func IsInformational(err error) bool {
+	em := messageOf(err)
+	return em != nil && em.informational()
+}
+
+// informational reports whether em is an informational warning.
+func (em *errorMessage) informational() bool {
+	info, _ := em.field(fieldInformational).(bool)
+	return info && em.level == WARNING
+}
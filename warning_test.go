@@ -0,0 +1,50 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWarning(t *testing.T) {
+	defer SetSeverityFn(nil)
+	defer SetEscalation(EscalationPolicy{})
+	SetSeverityFn(func(Error) Level { return LEVEL_ERROR })
+	SetEscalation(EscalationPolicy{Threshold: 1, Window: time.Hour})
+
+	for i := 0; i < 3; i++ {
+		err := Warning(Desc{Code: 0x55, Text: "version conflict", Level: FATAL})
+		if err.Level() != WARNING {
+			t.Errorf(`Warning(...).Level() = %d, want WARNING`, err.Level())
+		}
+		if !IsInformational(err) {
+			t.Errorf(`IsInformational(Warning(...)) = false`)
+		}
+		if Escalated(err) {
+			t.Errorf(`Escalated(Warning(...)) = true`)
+		}
+	}
+	err := Warning("retrying")
+	if ReclassifyByAge(err, time.Now().Add(time.Hour), AgeRule{Level: LEVEL_WARNING, To: LEVEL_ERROR}); err.Level() != WARNING {
+		t.Errorf(`ReclassifyByAge(Warning(...)).Level() = %d, want WARNING`, err.Level())
+	}
+	if err.SetLevel(ERROR); IsInformational(err) {
+		t.Errorf(`IsInformational(promoted) = true`)
+	}
+	if IsInformational(New("x")) {
+		t.Errorf(`IsInformational(New("x")) = true`)
+	}
+}
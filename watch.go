@@ -0,0 +1,93 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.21
+// +build go1.21
+
+package errors
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Field keys used to describe the cancellation of a watched context.
+const (
+	fieldContextCause    = "context.cause"
+	fieldContextInFlight = "context.in_flight"
+)
+
+// Watch tracks an operation bound to a context, see WatchContext.
+type Watch struct {
+	mu    sync.Mutex
+	err   Error
+	stop  func() bool
+	start time.Time
+}
+
+// WatchContext arranges for an Error to be created from desc (anything
+// accepted by New) when ctx is canceled before the returned Watch is
+// stopped, e.g. to track abandoned requests or failures induced by load
+// shedding. If desc is an Error, it is completed instead. The error records
+// the cause of the cancellation, see context.Cause, and how long the
+// operation was in flight, as the fields "context.cause" and
+// "context.in_flight"; if it has no code, it gets ERR_TIMEOUT when the
+// deadline of ctx was exceeded, and ERR_INTERRUPTED otherwise. It is then
+// sent to observers with EVENT_CONTEXT_CANCELED.
+func WatchContext(ctx context.Context, desc interface{}) *Watch {
+	w := &Watch{start: now()}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.stop = context.AfterFunc(ctx, func() {
+		e, ok := desc.(Error)
+		if !ok {
+			e = New(desc)
+		}
+		if em := ownMessage(e); em != nil {
+			cause := context.Cause(ctx)
+			em.setField(fieldContextCause, cause.Error())
+			em.setField(fieldContextInFlight, now().Sub(w.start))
+			if em.code == 0 {
+				if ctx.Err() == context.DeadlineExceeded {
+					em.code = ERR_TIMEOUT
+				} else {
+					em.code = ERR_INTERRUPTED
+				}
+			}
+		}
+		w.mu.Lock()
+		w.err = e
+		w.mu.Unlock()
+		notify(EVENT_CONTEXT_CANCELED, e)
+	})
+	return w
+}
+
+// Stop stops watching, typically when the operation completes. It reports
+// whether the watch was stopped before the context was canceled.
+func (w *Watch) Stop() bool {
+	w.mu.Lock()
+	stop := w.stop
+	w.mu.Unlock()
+	return stop()
+}
+
+// Err returns the error created when the context was canceled, or nil if it
+// has not been (yet).
+func (w *Watch) Err() Error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
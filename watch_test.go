@@ -0,0 +1,79 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.21
+// +build go1.21
+
+package errors
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+)
+
+func TestWatchContext(t *testing.T) {
+	canceled := make(chan Error, 2)
+	remove := AddObserver(func(event int8, err Error) {
+		if event == EVENT_CONTEXT_CANCELED {
+			canceled <- err
+		}
+	})
+	defer remove()
+	field := func(e Error, key string) interface{} {
+		v, _ := Field(e, key)
+		return v
+	}
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	w := WatchContext(ctx, Desc{Level: WARNING, Text: "checkout abandoned"})
+	cancel(stderrors.New("client went away"))
+	var e Error
+	select {
+	case e = <-canceled:
+	case <-time.After(5 * time.Second):
+		t.Fatal(`no error on cancellation`)
+	}
+	if e != w.Err() || e.Code() != ERR_INTERRUPTED || e.Level() != WARNING || field(e, fieldContextCause) != "client went away" {
+		t.Errorf(`error on cancellation = %v, cause %v`, e, field(e, fieldContextCause))
+	}
+	if _, ok := field(e, fieldContextInFlight).(time.Duration); !ok || w.Stop() {
+		t.Errorf(`in flight %v, Stop() after cancellation = true`, field(e, fieldContextInFlight))
+	}
+
+	pending := New("upload")
+	ctx, cancelTimeout := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancelTimeout()
+	w = WatchContext(ctx, pending)
+	select {
+	case e = <-canceled:
+	case <-time.After(5 * time.Second):
+		t.Fatal(`no error on deadline`)
+	}
+	if e != pending || e.Code() != ERR_TIMEOUT {
+		t.Errorf(`error on deadline = %v`, e)
+	}
+
+	ctx, cancel2 := context.WithCancel(context.Background())
+	w = WatchContext(ctx, "done in time")
+	if !w.Stop() {
+		t.Errorf(`Stop() before cancellation = false`)
+	}
+	cancel2()
+	time.Sleep(10 * time.Millisecond)
+	if len(canceled) != 0 || w.Err() != nil {
+		t.Errorf(`error created after Stop()`)
+	}
+}
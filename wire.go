@@ -0,0 +1,274 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// wireSchemaVersion is the version of the serialized form produced by toWire.
+// Any change to wireError which older decoders would misread requires a new
+// version, and keeping a decoder for the previous one in wireDecoders.
+const wireSchemaVersion = 1
+
+// wireDecoders maps the schema versions still readable to their decoders.
+var wireDecoders = map[int]func(data []byte) (*wireError, error){
+	1: decodeWireV1,
+}
+
+// SchemaVersion returns the version of the serialized form of errors written
+// by this package. Errors serialized with older versions remain readable.
+func SchemaVersion() int {
+	return wireSchemaVersion
+}
+
+// wireError is the serialized form of an Error. Only the root of a tree
+// carries the schema version.
+type wireError struct {
+	SchemaVersion int                    `json:"schema_version,omitempty"`
+	Level         string                 `json:"level"`
+	Domain        string                 `json:"domain,omitempty"`
+	Code          int                    `json:"code,omitempty"`
+	Text          string                 `json:"text"`
+	PublicText    string                 `json:"public_text,omitempty"`
+	Info          []string               `json:"info,omitempty"`
+	Fields        map[string]interface{} `json:"fields,omitempty"`
+	Time          *time.Time             `json:"time,omitempty"`
+	Branches      map[string]*wireError  `json:"branches,omitempty"`
+	Cause         *wireError             `json:"cause,omitempty"`
+}
+
+// toWire returns the serialized form of err.
+func toWire(err Error) *wireError {
+	if em := messageOf(err); em != nil {
+		checkInvariants(em, "externalized")
+	}
+	w := toWireNode(err)
+	w.SchemaVersion = wireSchemaVersion
+	return w
+}
+
+func toWireNode(err Error) *wireError {
+	w := &wireError{
+		Level: levelName(err.Level()),
+		Code:  err.Code(),
+		Text:  err.Text(),
+		Info:  err.Info(),
+	}
+	if em := messageOf(err); em != nil {
+		w.Domain = em.domain
+		w.PublicText = em.public
+		w.Fields = em.userFields()
+		if !em.time.IsZero() {
+			t := em.time
+			w.Time = &t
+		}
+		if len(em.branches) > 0 {
+			w.Branches = make(map[string]*wireError, len(em.branches))
+			for _, b := range em.branches {
+				w.Branches[b.name] = toWireNode(b.err)
+			}
+		}
+		if em.cause != nil {
+			w.Cause = toWireNode(from(em.cause))
+		}
+	}
+	return w
+}
+
+// fromWire returns the Error described by w.
+func fromWire(w *wireError) Error {
+	em := &errorMessage{
+		level:  levelByName(w.Level),
+		code:   w.Code,
+		domain: w.Domain,
+		text:   w.Text,
+		public: w.PublicText,
+		fields: w.Fields,
+	}
+	em.appendInfo(w.Info...)
+	if w.Time != nil {
+		em.time = *w.Time
+	}
+	for name, b := range w.Branches {
+		if b != nil {
+			em.branches = append(em.branches, branch{name, fromWire(b)})
+		}
+	}
+	sortBranches(em.branches)
+	if w.Cause != nil {
+		em.cause = fromWire(w.Cause)
+	}
+	return em
+}
+
+// encodeWire returns the serialized form of err as JSON, compressed if larger
+// than the threshold set with SetCompression.
+func encodeWire(err Error) ([]byte, error) {
+	data, e := marshalWire(err)
+	if e != nil {
+		return nil, e
+	}
+	return compress(data)
+}
+
+// sentinelWire is the serialized form of the errors created from a Sentinel,
+// without their time and the closing brace, along with the attributes it was
+// computed for, including the fields not defined by the template.
+type sentinelWire struct {
+	level                int8
+	code                 int
+	domain, text, public string
+	stamps               map[string]interface{}
+	data                 []byte
+}
+
+// pristine reports whether em, created from s, holds no data beyond the
+// template: no stack trace or other Info, changed field values, or cause tree
+// of its own. Fields not defined by the template, such as those stamped on
+// every error (origin, owner, goroutine, or added by the factory), are left
+// to stamps.
+func (s *Sentinel) pristine(em *errorMessage) bool {
+	if len(em.branches) > 0 || em.cause != nil {
+		return false
+	}
+	info := em.infoList()
+	if len(info) != len(s.desc.Info) {
+		return false
+	}
+	for i, line := range s.desc.Info {
+		if info[i] != line {
+			return false
+		}
+	}
+	for k, v := range s.desc.Fields {
+		if w, ok := em.fields[k]; !ok || !reflect.DeepEqual(w, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// stamps returns the fields of em not defined by the template of s, or nil if
+// there are none.
+func (s *Sentinel) stamps(em *errorMessage) map[string]interface{} {
+	var res map[string]interface{}
+	for k, v := range em.fields {
+		if _, ok := s.desc.Fields[k]; ok || isInfoKey(k) {
+			continue
+		}
+		if res == nil {
+			res = make(map[string]interface{})
+		}
+		res[k] = v
+	}
+	return res
+}
+
+// sameStamps reports whether em has the fields c was computed with, beyond
+// those of the template of s.
+func (c *sentinelWire) sameStamps(s *Sentinel, em *errorMessage) bool {
+	n := 0
+	for k, v := range em.fields {
+		if _, ok := s.desc.Fields[k]; ok || isInfoKey(k) {
+			continue
+		}
+		if w, ok := c.stamps[k]; !ok || !reflect.DeepEqual(w, v) {
+			return false
+		}
+		n++
+	}
+	return n == len(c.stamps)
+}
+
+// marshalWire returns the serialized form of err as JSON. The serialized
+// form of errors created from a Sentinel holding no data of their own is
+// computed once, and only completed with their time; it is computed again
+// when the fields stamped on the errors change.
+func marshalWire(err Error) ([]byte, error) {
+	em, _ := err.(*errorMessage)
+	if em == nil || em.sentinel == nil || !em.sentinel.pristine(em) {
+		return json.Marshal(toWire(err))
+	}
+	checkInvariants(em, "externalized")
+	c, _ := em.sentinel.wire.Load().(*sentinelWire)
+	if c == nil || c.level != em.level || c.code != em.code || c.domain != em.domain || c.text != em.text || c.public != em.public || !c.sameStamps(em.sentinel, em) {
+		w := toWireNode(em)
+		w.SchemaVersion, w.Time = wireSchemaVersion, nil
+		data, e := json.Marshal(w)
+		if e != nil {
+			return nil, e
+		}
+		c = &sentinelWire{em.level, em.code, em.domain, em.text, em.public, em.sentinel.stamps(em), data[:len(data)-1]}
+		em.sentinel.wire.Store(c)
+	}
+	data := append(make([]byte, 0, len(c.data)+48), c.data...)
+	if !em.time.IsZero() {
+		t, e := em.time.MarshalJSON()
+		if e != nil {
+			return nil, e
+		}
+		data = append(append(data, `,"time":`...), t...)
+	}
+	return append(data, '}'), nil
+}
+
+// decodeWire decodes the serialized form of an error, of any supported schema
+// version, decompressing it if needed; data written before versioning is
+// read as version 1.
+func decodeWire(data []byte) (*wireError, error) {
+	return decodeWireLimit(data, nil)
+}
+
+// decodeWireLimit is like decodeWire, but deducts the size of decompressed
+// content from budget if not nil, failing when it is exceeded.
+func decodeWireLimit(data []byte, budget *int64) (*wireError, error) {
+	var v struct {
+		SchemaVersion int                `json:"schema_version"`
+		Compressed    *compressedPayload `json:"compressed"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	if v.Compressed != nil {
+		data, err := v.Compressed.decompress(budget)
+		if err != nil {
+			return nil, err
+		}
+		return decodeWireLimit(data, budget)
+	}
+	if v.SchemaVersion == 0 {
+		v.SchemaVersion = 1
+	}
+	decode, ok := wireDecoders[v.SchemaVersion]
+	if !ok {
+		return nil, fmt.Errorf("unsupported error schema version %d", v.SchemaVersion)
+	}
+	return decode(data)
+}
+
+func decodeWireV1(data []byte) (*wireError, error) {
+	w := &wireError{}
+	if err := json.Unmarshal(data, w); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
@@ -0,0 +1,132 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !errors_minimal
+// +build !errors_minimal
+
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWireTree(t *testing.T) {
+	err := Tree("scatter failed", map[string]error{
+		"eu": Tree(Desc{Code: 1, Text: "region failed"}, map[string]error{
+			"shard-1": fmt.Errorf("timeout"),
+		}),
+		"ap": New(Desc{Level: WARNING, Text: "slow"}),
+	})
+	if got := fromWire(toWire(err)); !reflect.DeepEqual(got, err) {
+		t.Errorf(`fromWire(toWire(err)) = %s, want %s`, FormatTree(got), FormatTree(err))
+	}
+}
+
+func TestWireSchema(t *testing.T) {
+	w := toWire(Tree("abc", map[string]error{"x": New("xyz")}))
+	if w.SchemaVersion != SchemaVersion() || w.Branches["x"].SchemaVersion != 0 {
+		t.Errorf(`toWire(err) schema versions = %d, %d`, w.SchemaVersion, w.Branches["x"].SchemaVersion)
+	}
+	w, err := decodeWire([]byte(`{"level":"WARNING","code":5,"text":"abc"}`))
+	if err != nil || w.Level != "WARNING" || w.Code != 5 {
+		t.Errorf(`decodeWire(unversioned) = %+v, %v`, w, err)
+	}
+	if _, err = decodeWire([]byte(`{"schema_version":99,"text":"abc"}`)); err == nil {
+		t.Errorf(`decodeWire(version 99) did not fail`)
+	}
+}
+
+func TestWireSentinel(t *testing.T) {
+	s := Define(Desc{Code: 0x44, Level: WARNING, Text: "not found", PublicText: "Not found.", Fields: map[string]interface{}{"kind": "user"}})
+	for i := 0; i < 2; i++ {
+		e := s.New()
+		got, err := marshalWire(e)
+		want, _ := json.Marshal(toWire(e))
+		if err != nil || string(got) != string(want) {
+			t.Errorf(`marshalWire(sentinel error) = %s, %v; want %s`, got, err, want)
+		}
+	}
+	if s.wire.Load() == nil {
+		t.Errorf(`serialized form of sentinel errors not cached`)
+	}
+
+	e := s.New()
+	e.(*errorMessage).setField("id", 7)
+	got, _ := marshalWire(e)
+	if want, _ := json.Marshal(toWire(e)); string(got) != string(want) {
+		t.Errorf(`marshalWire(sentinel error with own field) = %s, want %s`, got, want)
+	}
+	e = s.New().SetLevel(ERROR)
+	got, _ = marshalWire(e)
+	if want, _ := json.Marshal(toWire(e)); string(got) != string(want) {
+		t.Errorf(`marshalWire(reclassified sentinel error) = %s, want %s`, got, want)
+	}
+	marshalWire(s.New())
+	e = WithField(s.New(), "kind", "admin")
+	got, _ = marshalWire(e)
+	if want, _ := json.Marshal(toWire(e)); string(got) != string(want) {
+		t.Errorf(`marshalWire(sentinel error with changed field) = %s, want %s`, got, want)
+	}
+}
+
+func TestWireSentinelStamped(t *testing.T) {
+	defer SetOrigin("", "")
+	s := Define(Desc{Code: 0x44, Text: "not found", Fields: map[string]interface{}{"kind": "user"}})
+	for _, instance := range []string{"host-1", "host-1", "host-2"} {
+		SetOrigin("svc", instance)
+		e := s.New()
+		if !s.pristine(e.(*errorMessage)) {
+			t.Errorf(`sentinel error with stamped fields is not pristine`)
+		}
+		got, _ := marshalWire(e)
+		if want, _ := json.Marshal(toWire(e)); string(got) != string(want) {
+			t.Errorf(`marshalWire(sentinel error from %s) = %s, want %s`, instance, got, want)
+		}
+	}
+}
+
+func BenchmarkWireSentinel(b *testing.B) {
+	e := Define(Desc{Code: 0x44, Level: WARNING, Text: "not found"}).New()
+	for i := 0; i < b.N; i++ {
+		marshalWire(e)
+	}
+}
+
+func TestWireDomain(t *testing.T) {
+	f := &Factory{Domain: "com.example.billing"}
+	e := f.New(Desc{Code: 0x10, Text: "card declined"})
+	data, err := marshalWire(e)
+	if err != nil || !strings.Contains(string(data), `"domain":"com.example.billing","code":16`) {
+		t.Errorf(`marshalWire(e) = %s, %v`, data, err)
+	}
+	w, _ := decodeWire(data)
+	if got := fromWire(w); Domain(got) != "com.example.billing" {
+		t.Errorf(`Domain(fromWire(...)) = %q`, Domain(got))
+	}
+	if Domain(New("abc")) != "" {
+		t.Errorf(`Domain(New("abc")) = %q`, Domain(New("abc")))
+	}
+
+	s := Define(Desc{Code: 0x10, Text: "card declined"})
+	marshalWire(s.New())
+	e = s.New()
+	e.(*errorMessage).domain = "com.example.billing"
+	if data, _ = marshalWire(e); !strings.Contains(string(data), `"domain"`) {
+		t.Errorf(`marshalWire(sentinel with domain) = %s`, data)
+	}
+}
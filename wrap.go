@@ -0,0 +1,197 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"runtime"
+	"strconv"
+)
+
+// fieldWrapAttempts is the field key counting identical wraps, see Wrap.
+const fieldWrapAttempts = "wrap.attempts"
+
+// Wrap returns an Error wrapping err: Unwrap returns it. Wrap returns nil if
+// err is nil. desc describes the wrapping error, as accepted by New:
+//   - a string gives the text "desc: " followed by the text of err, and the
+//     code and level of err if it is an Error;
+//   - a Desc, or pointer to one, gives the text of the Desc followed by that
+//     of err; its code and level, if set, replace those of err, and its Info
+//     and fields are kept on the wrapping error.
+//
+// If the chain of err has an error with Timeout or Temporary methods, such
+// as a net.Error, the result forwards them: it implements net.Error too.
+//
+// When err was itself returned by Wrap with the same string and from the same
+// place, as happens in retry loops, a copy of it is returned instead of
+// wrapping it again, with its field "wrap.attempts" incremented; see
+// WrapAttempts. err itself is left unchanged.
+func Wrap(err error, desc interface{}) Error {
+	if err == nil {
+		return nil
+	}
+	text, ok := desc.(string)
+	if !ok {
+		return wrapDesc(err, newError(desc, 4), desc)
+	}
+	site := text
+	if _, file, line, ok := runtime.Caller(1); ok {
+		site = file + ":" + strconv.Itoa(line) + " " + text
+	}
+	if e := rewrapped(err, site); e != nil {
+		return e
+	}
+	em := newMessage(text + ": " + err.Error())
+	if e, ok := err.(Error); ok {
+		em.text = text + ": " + e.Text()
+		em.code, em.level = e.Code(), e.Level()
+	}
+	em.cause, em.wrapSite = err, site
+	return wrapped(em, err)
+}
+
+// rewrapped returns a copy of err, with its field "wrap.attempts"
+// incremented, if err was returned by Wrap from site, and nil otherwise.
+// Read-only and sealed errors are wrapped again rather than copied.
+func rewrapped(err error, site string) Error {
+	em := ownMessage(err)
+	if em == nil || em.cause == nil || em.wrapSite != site {
+		return nil
+	}
+	e, c := copyMessage(err.(Error))
+	c.setField(fieldWrapAttempts, WrapAttempts(em)+1)
+	return e
+}
+
+// wrapDesc completes e, created by New from desc, wrapping err.
+func wrapDesc(err error, e Error, desc interface{}) Error {
+	em, ok := e.(*errorMessage)
+	if !ok {
+		return e
+	}
+	levelSet := false
+	switch d := desc.(type) {
+	case Desc:
+		levelSet = d.Level != 0
+	case *Desc:
+		levelSet = d.Level != 0
+	}
+	if c, ok := err.(Error); ok {
+		em.text += ": " + c.Text()
+		if em.code == 0 {
+			em.code = c.Code()
+		}
+		if !levelSet {
+			em.level = c.Level()
+		}
+	} else {
+		em.text += ": " + err.Error()
+	}
+	em.cause = err
+	return wrapped(em, err)
+}
+
+// wrapped completes em, wrapping err.
+func wrapped(em *errorMessage, err error) Error {
+	classifyCanceled(em, err)
+	e := Default().finish(em)
+	if _, ok := causeBehavior(err); ok {
+		return netWrapper{em}
+	}
+	return e
+}
+
+// netWrapper is an Error returned by Wrap for a cause with Timeout or
+// Temporary methods, such as a net.Error, which it forwards, for code
+// type-asserting these behaviors to keep working.
+type netWrapper struct {
+	*errorMessage
+}
+
+// sealed lets messageOf reach the wrapped errorMessage.
+func (w netWrapper) sealed() Error {
+	return w.errorMessage
+}
+
+// Timeout reports whether the cause of w is a timeout.
+func (w netWrapper) Timeout() bool {
+	b, _ := causeBehavior(w.cause)
+	return b.timeout
+}
+
+// Temporary reports whether the cause of w is temporary.
+func (w netWrapper) Temporary() bool {
+	b, _ := causeBehavior(w.cause)
+	return b.temporary
+}
+
+// behavior holds the results of the Timeout and Temporary methods of an
+// error.
+type behavior struct {
+	timeout, temporary bool
+}
+
+// causeBehavior returns the behavior of the first error in the chain of err
+// having a Timeout or Temporary method, and whether there is one.
+func causeBehavior(err error) (b behavior, ok bool) {
+	for err != nil {
+		t, hasTimeout := err.(interface {
+			Timeout() bool
+		})
+		p, hasTemporary := err.(interface {
+			Temporary() bool
+		})
+		if hasTimeout || hasTemporary {
+			if hasTimeout {
+				b.timeout = t.Timeout()
+			}
+			if hasTemporary {
+				b.temporary = p.Temporary()
+			}
+			return b, true
+		}
+		u, isWrapper := err.(interface {
+			Unwrap() error
+		})
+		if !isWrapper {
+			break
+		}
+		err = u.Unwrap()
+	}
+	return
+}
+
+// WrapAttempts returns the number of identical wraps err stands for, see
+// Wrap: 1 for a single wrap, and 0 if err was not returned by Wrap.
+func WrapAttempts(err error) int {
+	em := messageOf(err)
+	if em == nil || em.cause == nil {
+		return 0
+	}
+	if n, ok := em.field(fieldWrapAttempts).(int); ok {
+		return n
+	}
+	return 1
+}
+
+// Unwrap returns the error wrapped by em, if any.
+func (em *errorMessage) Unwrap() error {
+	return em.cause
+}
+
+// Cause returns the error wrapped by em, if any, like Unwrap, for code
+// walking chains through Cause methods.
+func (em *errorMessage) Cause() error {
+	return em.cause
+}
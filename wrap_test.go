@@ -0,0 +1,128 @@
+// Copyright 2015 ALRUX Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestWrap(t *testing.T) {
+	if Wrap(nil, "x") != nil {
+		t.Errorf(`Wrap(nil, "x") != nil`)
+	}
+	cause := New(Desc{Code: 0x42, Level: WARNING, Text: "timeout"})
+	e := Wrap(cause, "fetching")
+	if e.Text() != "fetching: timeout" || e.Code() != 0x42 || e.Level() != WARNING {
+		t.Errorf(`Wrap(cause, "fetching") = %v, level %d`, e, e.Level())
+	}
+	if u, _ := e.(interface{ Unwrap() error }); u == nil || u.Unwrap() != cause {
+		t.Errorf(`Unwrap() does not return the cause`)
+	}
+	if Wrap(fmt.Errorf("eof"), "reading").Text() != "reading: eof" {
+		t.Errorf(`Wrap(plain error) text`)
+	}
+
+	var err error = cause
+	for i := 0; i < 3; i++ {
+		err = Wrap(err, "retrying")
+	}
+	if WrapAttempts(err) != 3 || err.(Error).Text() != "retrying: timeout" {
+		t.Errorf(`after 3 retries: %v, %d attempts`, err, WrapAttempts(err))
+	}
+	if Wrap(Wrap(cause, "x"), "x") == Wrap(Wrap(cause, "x"), "y") {
+		t.Errorf(`wraps with different texts were merged`)
+	}
+	if WrapAttempts(Wrap(Wrap(cause, "x"), "x")) != 2 {
+		t.Errorf(`Wrap(Wrap(cause, "x"), "x") not merged`)
+	}
+	if WrapAttempts(Wrap(e, "fetching")) != 1 || WrapAttempts(cause) != 0 {
+		t.Errorf(`wraps from different places were merged`)
+	}
+
+	retry := func(err error) Error { return Wrap(err, "again") }
+	once := retry(cause)
+	if twice := retry(once); twice == once || WrapAttempts(twice) != 2 || WrapAttempts(once) != 1 {
+		t.Errorf(`merged wrap: %d attempts, original changed to %d`, WrapAttempts(twice), WrapAttempts(once))
+	}
+	ro := ReadOnly(once)
+	if again := retry(ro); WrapAttempts(again) != 1 || WrapAttempts(once) != 1 || again.(interface{ Unwrap() error }).Unwrap() != ro {
+		t.Errorf(`read-only wrap: %d attempts, original changed to %d`, WrapAttempts(again), WrapAttempts(once))
+	}
+}
+
+func TestWrapWithDesc(t *testing.T) {
+	if Wrap(nil, "x") != nil {
+		t.Errorf(`Wrap(nil, "x") != nil`)
+	}
+	cause := New(Desc{Code: 0x42, Level: WARNING, Text: "timeout"})
+	e := Wrap(cause, Desc{Code: 0x43, Text: "fetching", Info: []string{"attempt 2"}})
+	if e.Text() != "fetching: timeout" || e.Code() != 0x43 || e.Level() != WARNING {
+		t.Errorf(`Wrap(cause, Desc{...}) = %v, level %d`, e, e.Level())
+	}
+	if info := e.Info(); len(info) != 1 || info[0] != "attempt 2" {
+		t.Errorf(`Wrap(cause, Desc{...}).Info() = %q`, info)
+	}
+	if c, _ := e.(interface{ Cause() error }); c == nil || c.Cause() != cause {
+		t.Errorf(`Cause() does not return the cause`)
+	}
+	if e := Wrap(cause, Desc{Level: ERROR, Text: "fetching"}); e.Code() != 0x42 || e.Level() != ERROR {
+		t.Errorf(`Wrap(cause, Desc{Level: ERROR}) = %v, level %d`, e, e.Level())
+	}
+	if Wrap(fmt.Errorf("eof"), "reading").Text() != "reading: eof" {
+		t.Errorf(`Wrap(plain error) text`)
+	}
+	if info := Wrap(cause, Desc{Text: "x", Info: []string{"debug.stack"}}).Info(); !minimalBuild && (len(info) != 1 || !strings.Contains(info[0], "TestWrapWithDesc")) {
+		t.Errorf(`Wrap(cause, Desc{debug.stack}).Info() = %q`, info)
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string { return "i/o timeout" }
+func (timeoutError) Timeout() bool { return true }
+
+func TestWrapNetError(t *testing.T) {
+	var err error = &net.OpError{Op: "dial", Net: "tcp", Err: timeoutError{}}
+	e := Wrap(Wrap(err, "connecting"), "fetching")
+	ne, ok := e.(net.Error)
+	if !ok || !ne.Timeout() || ne.Temporary() {
+		t.Errorf(`Wrap(timeout) does not forward Timeout() and Temporary()`)
+	}
+	if messageOf(e) == nil || e.Text() != "fetching: connecting: "+err.Error() {
+		t.Errorf(`Wrap(timeout) text %q`, e.Text())
+	}
+	for i := 0; i < 2; i++ {
+		e = Wrap(e, "retrying")
+	}
+	if _, ok := e.(net.Error); !ok || WrapAttempts(e) != 2 {
+		t.Errorf(`retried wrap: net.Error %t, %d attempts`, ok, WrapAttempts(e))
+	}
+	if _, ok := Wrap(fmt.Errorf("eof"), "reading").(net.Error); ok {
+		t.Errorf(`Wrap(plain error) implements net.Error`)
+	}
+
+	// the errors returned for net errors are annotated like any other
+	var annotated error = e
+	Timer("fetch")(&annotated)
+	if _, ok := Timings(annotated)["fetch"]; !ok {
+		t.Errorf(`Timer did not annotate Wrap(timeout)`)
+	}
+	if ownMessage(ReadOnly(e)) != nil {
+		t.Errorf(`ownMessage reaches through read-only views`)
+	}
+}